@@ -3,7 +3,6 @@ package database
 import (
 	"clean-arch/pkg/config"
 	"clean-arch/pkg/util"
-	"context"
 	"sync"
 
 	"github.com/redis/go-redis/v9"
@@ -37,6 +36,10 @@ func CreateConnection() {
 			}
 			pg.Connect()
 
+		case "sqlite":
+			lite := sqliteConfig{Path: conf.Name}
+			lite.Connect()
+
 		default:
 			mysql := mysqlConfig{dbConfig: conf}
 			mysql.Connect()
@@ -51,10 +54,6 @@ func GetConnection() *gorm.DB {
 	return dbConn
 }
 
-func BeginTx(ctx context.Context, db *gorm.DB) *gorm.DB {
-	return db.WithContext(ctx).Begin()
-}
-
 func GetRedisClient() *redis.Client {
 
 	onceRdb.Do(func() {
@@ -181,8 +181,13 @@ func executeSQLQueryFile(wd string, migrationFileName string, conn *gorm.DB) err
 		return err
 	}
 
+	return applySQL(conn, migrationFileName, string(buffer))
+}
+
+// applySQL runs the query of a migration file and records the file in one transaction
+func applySQL(conn *gorm.DB, migrationFileName string, query string) error {
 	tx := conn.Begin()
-	err = tx.Exec(string(buffer)).Error
+	err := tx.Exec(query).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -196,8 +201,7 @@ func executeSQLQueryFile(wd string, migrationFileName string, conn *gorm.DB) err
 		tx.Rollback()
 		return err
 	}
-	tx.Commit()
-	return nil
+	return tx.Commit().Error
 }
 
 func migrationLog(state int, fileName string) {
@@ -227,13 +231,7 @@ func CopyBaseMigrations() error {
 		return err
 	}
 
-	var basePath string
-	switch dbDriver {
-	case "psql":
-		basePath = wd + "/database/migration/migration_base/psql/"
-	default:
-		basePath = wd + "/database/migration/migration_base/mysql/"
-	}
+	basePath := baseMigrationPath(wd, dbDriver)
 
 	destPath := wd + "/database/migration/migrations_file/"
 
@@ -267,6 +265,56 @@ func CopyBaseMigrations() error {
 	return nil
 }
 
+/*
+MigrateBase runs the base migrations of the driver on conn in order and records them, without
+copying them to migrations_file. It builds the schema of a fresh database, the integration tests
+use it on an in-memory sqlite database
+*/
+func MigrateBase(conn *gorm.DB, dbDriver string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if err := conn.AutoMigrate(&migrations{}); err != nil {
+		return err
+	}
+
+	basePath := baseMigrationPath(wd, dbDriver)
+	files, err := os.ReadDir(basePath)
+	if err != nil {
+		return fmt.Errorf("failed read base migration folder: %w", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		query, err := os.ReadFile(basePath + file.Name())
+		if err != nil {
+			return err
+		}
+
+		if err := applySQL(conn, file.Name(), string(query)); err != nil {
+			return fmt.Errorf("migrate %s: %w", file.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func baseMigrationPath(wd string, dbDriver string) string {
+	switch dbDriver {
+	case "psql":
+		return wd + "/database/migration/migration_base/psql/"
+	case "sqlite":
+		return wd + "/database/migration/migration_base/sqlite/"
+	default:
+		return wd + "/database/migration/migration_base/mysql/"
+	}
+}
+
 func copyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
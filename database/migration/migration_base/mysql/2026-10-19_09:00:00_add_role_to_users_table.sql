@@ -0,0 +1,2 @@
+ALTER TABLE `users`
+  ADD COLUMN `role` varchar(20) NOT NULL DEFAULT 'User' AFTER `password`;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `audit_events` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `event` varchar(64) NOT NULL,
+  `actor_id` bigint(20) unsigned NULL DEFAULT NULL,
+  `target_type` varchar(32) NOT NULL DEFAULT '',
+  `target_id` bigint(20) unsigned NULL DEFAULT NULL,
+  `ip_address` varchar(45) NOT NULL DEFAULT '',
+  `user_agent` varchar(512) NOT NULL DEFAULT '',
+  `metadata` json NULL,
+  `created_at` timestamp NULL DEFAULT current_timestamp(),
+  PRIMARY KEY (`id`),
+  KEY `audit_events_event_index` (`event`),
+  KEY `audit_events_actor_id_index` (`actor_id`),
+  KEY `audit_events_target_index` (`target_type`, `target_id`),
+  KEY `audit_events_created_at_index` (`created_at`)
+) ENGINE=InnoDB AUTO_INCREMENT=0 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,9 @@
+ALTER TABLE `user_sessions`
+  MODIFY `ip_address` varchar(45) NOT NULL,
+  ADD COLUMN `user_agent` varchar(512) NOT NULL DEFAULT '' AFTER `ip_address`,
+  ADD COLUMN `browser` varchar(64) NOT NULL DEFAULT '' AFTER `user_agent`,
+  ADD COLUMN `os` varchar(64) NOT NULL DEFAULT '' AFTER `browser`,
+  ADD COLUMN `device` varchar(32) NOT NULL DEFAULT '' AFTER `os`,
+  ADD COLUMN `country` varchar(8) NOT NULL DEFAULT '' AFTER `device`,
+  ADD COLUMN `region` varchar(128) NOT NULL DEFAULT '' AFTER `country`,
+  ADD COLUMN `city` varchar(128) NOT NULL DEFAULT '' AFTER `region`;
@@ -0,0 +1,4 @@
+ALTER TABLE `login_logs`
+  ADD COLUMN `status` varchar(16) NOT NULL DEFAULT 'success' AFTER `user_agent`,
+  ADD COLUMN `failure_reason` varchar(64) NOT NULL DEFAULT '' AFTER `status`,
+  ADD KEY `login_logs_user_id_created_at_index` (`user_id`, `created_at`);
@@ -0,0 +1,7 @@
+ALTER TABLE `users`
+  ADD COLUMN `status` varchar(16) NOT NULL DEFAULT 'active' AFTER `role`,
+  ADD COLUMN `status_reason` varchar(255) NOT NULL DEFAULT '' AFTER `status`,
+  ADD COLUMN `suspended_until` timestamp NULL DEFAULT NULL AFTER `status_reason`,
+  ADD COLUMN `status_changed_by` bigint NULL DEFAULT NULL AFTER `suspended_until`,
+  ADD COLUMN `status_changed_at` timestamp NULL DEFAULT NULL AFTER `status_changed_by`,
+  ADD INDEX `users_status_index` (`status`);
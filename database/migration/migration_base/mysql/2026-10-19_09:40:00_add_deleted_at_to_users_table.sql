@@ -0,0 +1,7 @@
+ALTER TABLE `users`
+  ADD COLUMN `deleted_at` timestamp NULL DEFAULT NULL AFTER `updated_at`,
+  ADD COLUMN `active_email` varchar(255) GENERATED ALWAYS AS (IF(`deleted_at` IS NULL, `email`, NULL)) STORED,
+  DROP INDEX `users_email_unique`,
+  ADD INDEX `users_email_index` (`email`),
+  ADD UNIQUE KEY `users_active_email_unique` (`active_email`),
+  ADD INDEX `users_deleted_at_index` (`deleted_at`);
@@ -0,0 +1,3 @@
+UPDATE `users`
+  SET `profile_image_url` = SUBSTRING_INDEX(`profile_image_url`, '/uploads/', -1)
+  WHERE `profile_image_url` LIKE '%/uploads/%';
@@ -0,0 +1,4 @@
+ALTER TABLE `users`
+  ADD COLUMN `erasure_requested_at` timestamp NULL DEFAULT NULL AFTER `status_changed_at`,
+  ADD COLUMN `erase_after` timestamp NULL DEFAULT NULL AFTER `erasure_requested_at`,
+  ADD INDEX `users_erase_after_index` (`erase_after`);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `erasure_receipts` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) unsigned NOT NULL,
+  `email_hash` char(64) NOT NULL,
+  `requested_at` timestamp NULL DEFAULT NULL,
+  `erased_at` timestamp NULL DEFAULT NULL,
+  `summary` json NULL,
+  `created_at` timestamp NULL DEFAULT current_timestamp(),
+  PRIMARY KEY (`id`),
+  KEY `erasure_receipts_user_id_index` (`user_id`),
+  KEY `erasure_receipts_email_hash_index` (`email_hash`)
+) ENGINE=InnoDB AUTO_INCREMENT=0 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+  ADD COLUMN `preferences` json NULL AFTER `profile_image_url`,
+  ADD COLUMN `attributes` json NULL AFTER `preferences`;
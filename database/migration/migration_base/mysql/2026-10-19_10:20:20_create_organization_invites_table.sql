@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `organization_invites` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `organization_id` bigint(20) unsigned NOT NULL,
+  `email` varchar(255) NOT NULL,
+  `role` varchar(16) NOT NULL DEFAULT 'member',
+  `token_hash` char(64) NOT NULL,
+  `invited_by` bigint(20) unsigned NULL DEFAULT NULL,
+  `expires_at` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT current_timestamp(),
+  `updated_at` timestamp NULL DEFAULT current_timestamp() ON UPDATE current_timestamp(),
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `organization_invites_organization_email_unique` (`organization_id`, `email`)
+) ENGINE=InnoDB AUTO_INCREMENT=0 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
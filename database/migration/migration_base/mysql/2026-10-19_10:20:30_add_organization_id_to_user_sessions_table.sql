@@ -0,0 +1,2 @@
+ALTER TABLE `user_sessions`
+  ADD COLUMN `organization_id` bigint(20) unsigned NULL DEFAULT NULL AFTER `user_id`;
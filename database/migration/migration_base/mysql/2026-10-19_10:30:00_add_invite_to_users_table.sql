@@ -0,0 +1,5 @@
+ALTER TABLE `users`
+  ADD COLUMN `invited_by` bigint NULL DEFAULT NULL AFTER `status_changed_at`,
+  ADD COLUMN `invited_at` timestamp NULL DEFAULT NULL AFTER `invited_by`,
+  ADD COLUMN `invite_expires_at` timestamp NULL DEFAULT NULL AFTER `invited_at`,
+  ADD COLUMN `invite_token_hash` varchar(64) NOT NULL DEFAULT '' AFTER `invite_expires_at`;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    event VARCHAR(64) NOT NULL,
+    actor_id BIGINT NULL,
+    target_type VARCHAR(32) NOT NULL DEFAULT '',
+    target_id BIGINT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    metadata JSONB NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_event_index ON audit_events (event);
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_index ON audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS audit_events_target_index ON audit_events (target_type, target_id);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_index ON audit_events (created_at);
+
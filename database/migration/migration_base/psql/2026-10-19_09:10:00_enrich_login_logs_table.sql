@@ -0,0 +1,9 @@
+ALTER TABLE login_logs
+    ALTER COLUMN ip_address TYPE VARCHAR(45),
+    ALTER COLUMN user_agent TYPE VARCHAR(512),
+    ADD COLUMN IF NOT EXISTS browser VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS os VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS device VARCHAR(32) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS country VARCHAR(8) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS region VARCHAR(128) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS city VARCHAR(128) NOT NULL DEFAULT '';
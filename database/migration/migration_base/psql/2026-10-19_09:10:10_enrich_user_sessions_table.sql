@@ -0,0 +1,9 @@
+ALTER TABLE user_sessions
+    ALTER COLUMN ip_address TYPE VARCHAR(45),
+    ADD COLUMN IF NOT EXISTS user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS browser VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS os VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS device VARCHAR(32) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS country VARCHAR(8) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS region VARCHAR(128) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS city VARCHAR(128) NOT NULL DEFAULT '';
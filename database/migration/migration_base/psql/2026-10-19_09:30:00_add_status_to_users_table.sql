@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS status_reason VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS status_changed_by BIGINT NULL,
+    ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS users_status_index ON users (status);
@@ -0,0 +1,4 @@
+-- profile_image_url used to hold APP_URL:APP_PORT/uploads/<file>, it now holds the storage key
+UPDATE users
+  SET profile_image_url = regexp_replace(profile_image_url, '^.*/uploads/', '')
+  WHERE profile_image_url LIKE '%/uploads/%';
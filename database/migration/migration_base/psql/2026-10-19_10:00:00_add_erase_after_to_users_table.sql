@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS erasure_requested_at TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS erase_after TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS users_erase_after_index ON users (erase_after);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS erasure_receipts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    email_hash CHAR(64) NOT NULL,
+    requested_at TIMESTAMPTZ NULL,
+    erased_at TIMESTAMPTZ NULL,
+    summary JSONB NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS erasure_receipts_user_id_index ON erasure_receipts (user_id);
+CREATE INDEX IF NOT EXISTS erasure_receipts_email_hash_index ON erasure_receipts (email_hash);
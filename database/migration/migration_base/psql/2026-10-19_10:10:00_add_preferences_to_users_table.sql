@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS preferences JSONB NULL,
+    ADD COLUMN IF NOT EXISTS attributes JSONB NULL;
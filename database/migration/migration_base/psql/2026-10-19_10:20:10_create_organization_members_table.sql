@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS organization_members (
+    id BIGSERIAL PRIMARY KEY,
+    organization_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'member',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT organization_members_organization_user_unique UNIQUE (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organization_members_user_id_index ON organization_members (user_id);
+
+CREATE TRIGGER trigger_update_timestamp
+BEFORE UPDATE ON organization_members
+FOR EACH ROW
+EXECUTE FUNCTION update_timestamp();
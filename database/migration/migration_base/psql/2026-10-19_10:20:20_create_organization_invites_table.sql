@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS organization_invites (
+    id BIGSERIAL PRIMARY KEY,
+    organization_id BIGINT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'member',
+    token_hash CHAR(64) NOT NULL,
+    invited_by BIGINT NULL,
+    expires_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT organization_invites_organization_email_unique UNIQUE (organization_id, email)
+);
+
+CREATE TRIGGER trigger_update_timestamp
+BEFORE UPDATE ON organization_invites
+FOR EACH ROW
+EXECUTE FUNCTION update_timestamp();
@@ -0,0 +1,2 @@
+ALTER TABLE user_sessions
+    ADD COLUMN IF NOT EXISTS organization_id BIGINT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS invited_by BIGINT NULL,
+    ADD COLUMN IF NOT EXISTS invited_at TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS invite_expires_at TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS invite_token_hash VARCHAR(64) NOT NULL DEFAULT '';
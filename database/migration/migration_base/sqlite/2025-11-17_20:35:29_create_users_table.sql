@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    email_verified_at DATETIME NULL,
+    password VARCHAR(255) NOT NULL,
+    phone_number VARCHAR(16) NOT NULL,
+    profile_image_url TEXT NULL,
+    created_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- a separate index so a later migration can drop it, sqlite can't drop an inline constraint
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_unique ON users (email);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(16) NOT NULL,
+    refresh_token_hash TEXT NOT NULL,
+    revoked INTEGER DEFAULT 0,
+    created_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS otps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    otp VARCHAR(10) NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 0,
+    next_request_at DATETIME NOT NULL,
+    expired_at DATETIME NOT NULL,
+    created_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+-- sqlite doesn't enforce varchar lengths, only the new columns are needed
+ALTER TABLE login_logs ADD COLUMN browser VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE login_logs ADD COLUMN os VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE login_logs ADD COLUMN device VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE login_logs ADD COLUMN country VARCHAR(8) NOT NULL DEFAULT '';
+ALTER TABLE login_logs ADD COLUMN region VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE login_logs ADD COLUMN city VARCHAR(128) NOT NULL DEFAULT '';
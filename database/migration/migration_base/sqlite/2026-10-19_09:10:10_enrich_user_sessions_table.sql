@@ -0,0 +1,7 @@
+ALTER TABLE user_sessions ADD COLUMN user_agent VARCHAR(512) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN browser VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN os VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN device VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN country VARCHAR(8) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN region VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN city VARCHAR(128) NOT NULL DEFAULT '';
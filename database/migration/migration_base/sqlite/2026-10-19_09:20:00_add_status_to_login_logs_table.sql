@@ -0,0 +1,4 @@
+ALTER TABLE login_logs ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'success';
+ALTER TABLE login_logs ADD COLUMN failure_reason VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS login_logs_user_id_created_at_index ON login_logs (user_id, created_at);
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN status_reason VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN suspended_until DATETIME NULL;
+ALTER TABLE users ADD COLUMN status_changed_by INTEGER NULL;
+ALTER TABLE users ADD COLUMN status_changed_at DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS users_status_index ON users (status);
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN deleted_at DATETIME NULL;
+
+-- deleted users keep their email, only active rows have to be unique so the address can register again
+DROP INDEX IF EXISTS users_email_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_active_email_unique ON users (email) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS users_email_index ON users (email);
+
+CREATE INDEX IF NOT EXISTS users_deleted_at_index ON users (deleted_at);
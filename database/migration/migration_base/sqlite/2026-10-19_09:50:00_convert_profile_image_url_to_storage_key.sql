@@ -0,0 +1,4 @@
+-- profile_image_url used to hold APP_URL:APP_PORT/uploads/<file>, it now holds the storage key
+UPDATE users
+  SET profile_image_url = substr(profile_image_url, instr(profile_image_url, '/uploads/') + length('/uploads/'))
+  WHERE profile_image_url LIKE '%/uploads/%';
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN erasure_requested_at DATETIME NULL;
+ALTER TABLE users ADD COLUMN erase_after DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS users_erase_after_index ON users (erase_after);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS erasure_receipts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    email_hash CHAR(64) NOT NULL,
+    requested_at DATETIME NULL,
+    erased_at DATETIME NULL,
+    summary TEXT NULL,
+    created_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS erasure_receipts_user_id_index ON erasure_receipts (user_id);
+CREATE INDEX IF NOT EXISTS erasure_receipts_email_hash_index ON erasure_receipts (email_hash);
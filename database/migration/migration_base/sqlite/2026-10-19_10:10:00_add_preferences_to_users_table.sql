@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN preferences TEXT NULL;
+ALTER TABLE users ADD COLUMN attributes TEXT NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS organization_members (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    organization_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'member',
+    created_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS organization_members_organization_user_unique ON organization_members (organization_id, user_id);
+CREATE INDEX IF NOT EXISTS organization_members_user_id_index ON organization_members (user_id);
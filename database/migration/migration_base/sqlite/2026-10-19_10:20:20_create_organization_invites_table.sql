@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS organization_invites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    organization_id INTEGER NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'member',
+    token_hash CHAR(64) NOT NULL,
+    invited_by INTEGER NULL,
+    expires_at DATETIME NULL,
+    created_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS organization_invites_organization_email_unique ON organization_invites (organization_id, email);
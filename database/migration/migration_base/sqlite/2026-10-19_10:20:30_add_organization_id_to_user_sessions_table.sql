@@ -0,0 +1 @@
+ALTER TABLE user_sessions ADD COLUMN organization_id INTEGER NULL;
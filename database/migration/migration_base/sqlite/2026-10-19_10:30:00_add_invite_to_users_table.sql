@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN invited_by INTEGER NULL;
+ALTER TABLE users ADD COLUMN invited_at DATETIME NULL;
+ALTER TABLE users ADD COLUMN invite_expires_at DATETIME NULL;
+ALTER TABLE users ADD COLUMN invite_token_hash VARCHAR(64) NOT NULL DEFAULT '';
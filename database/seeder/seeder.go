@@ -3,6 +3,7 @@ package seeder
 import (
 	"clean-arch/database"
 	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
 	"clean-arch/pkg/util"
 	"fmt"
 	"time"
@@ -45,8 +46,19 @@ func UserSeed(db *gorm.DB) error {
 			Email:           "demouser@gmail.com",
 			EmailVerifiedAt: &now,
 			Password:        string(hashedPasswordAdmin),
+			Role:            consts.RoleTypeUser,
+			Status:          consts.UserStatusActive,
 			PhoneNumber:     "08123456789",
 		},
+		{
+			Name:            "demoadmin",
+			Email:           "demoadmin@gmail.com",
+			EmailVerifiedAt: &now,
+			Password:        string(hashedPasswordAdmin),
+			Role:            consts.RoleTypeAdmin,
+			Status:          consts.UserStatusActive,
+			PhoneNumber:     "08123456780",
+		},
 	}
 
 	for _, data := range InsertModel {
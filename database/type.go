@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -26,6 +27,10 @@ type (
 		dbConfig
 		SSLMode string
 	}
+
+	sqliteConfig struct {
+		Path string
+	}
 )
 
 var err error
@@ -71,3 +76,35 @@ func (p *postgresConfig) Connect() {
 	}
 	dbConn = conn
 }
+
+func (conf sqliteConfig) Connect() {
+	conn, err := OpenSqlite(conf.Path)
+	if err != nil {
+		panic(err)
+	}
+	dbConn = conn
+}
+
+/*
+OpenSqlite opens the sqlite database at path with a pure Go driver, so it builds without cgo.
+":memory:" keeps the database in memory until the connection is closed
+
+The pool is limited to one connection, an in-memory database only lives on the connection that
+made it and sqlite serializes the writes anyway. Foreign keys are enforced like on mysql and psql
+*/
+func OpenSqlite(path string) (*gorm.DB, error) {
+	conn, err := gorm.Open(sqlite.Open(path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	return conn, nil
+}
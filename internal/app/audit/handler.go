@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/factory"
+	"clean-arch/internal/middleware"
+	"clean-arch/pkg/tracer"
+	"clean-arch/pkg/util"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type handler struct {
+	service      Service
+	authenticate gin.HandlerFunc
+}
+
+func NewHandler(f *factory.Factory) *handler {
+	return &handler{
+		service:      NewService(f),
+		authenticate: middleware.Authenticate(f),
+	}
+}
+
+func (h *handler) FindAll(c *gin.Context) {
+	payload := dto.PayloadAuditFilter{
+		Limit: 10,
+	}
+
+	if err := c.ShouldBindQuery(&payload); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.FindAll(c, payload)
+	if err != nil {
+		response := util.APIResponse("Failed to get audit events", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get audit events", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Get Audit Events")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Export(c *gin.Context) {
+	var payload dto.PayloadAuditFilter
+
+	if err := c.ShouldBindQuery(&payload); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	fileName := fmt.Sprintf("audit-events-%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+
+	if err := h.service.Export(c, payload, c.Writer); err != nil {
+		// headers may already be flushed, so only log the failure when the body was started
+		if !c.Writer.Written() {
+			response := util.APIResponse("Failed to export audit events", http.StatusBadRequest, "error", err.Error())
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+		tracer.Log(c, "error", err.Error())
+		return
+	}
+
+	tracer.Log(c, "info", "Export Audit Events")
+}
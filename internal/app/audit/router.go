@@ -0,0 +1,15 @@
+package audit
+
+import (
+	"clean-arch/internal/middleware"
+	"clean-arch/pkg/consts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// This function accepts gin.Routergroup to define a group route
+func (h *handler) Router(g *gin.RouterGroup) {
+	g.Use(h.authenticate, middleware.Authorize(consts.RoleTypeAdmin))
+	g.GET("", h.FindAll)
+	g.GET("/export", h.Export)
+}
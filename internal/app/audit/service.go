@@ -0,0 +1,244 @@
+package audit
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/factory"
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const exportBatchSize = 500
+
+type service struct {
+	AuditRepository repository.Audit
+}
+
+type Service interface {
+	Record(ctx context.Context, entry dto.AuditEntry) error
+	FindAll(ctx context.Context, reqHandler dto.PayloadAuditFilter) (*dto.ResponseAuditEvent, error)
+	Export(ctx context.Context, reqHandler dto.PayloadAuditFilter, w io.Writer) error
+}
+
+func NewService(f *factory.Factory) Service {
+	return &service{
+		AuditRepository: f.AuditRepository,
+	}
+}
+
+// Record appends an audit event, actor, ip and user agent are taken from the request when not set
+func (s *service) Record(ctx context.Context, entry dto.AuditEntry) error {
+	fillRequestMeta(ctx, &entry)
+
+	metadata := "{}"
+	if len(entry.Metadata) > 0 {
+		encoded, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			return fmt.Errorf("error encoding audit metadata %s", err.Error())
+		}
+		metadata = string(encoded)
+	}
+
+	insertModel := model.AuditEvent{
+		Event:      entry.Event,
+		ActorID:    entry.ActorID,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IPAddress:  entry.IP,
+		UserAgent:  entry.UserAgent,
+		Metadata:   metadata,
+	}
+
+	if err := s.AuditRepository.Store(ctx, insertModel); err != nil {
+		log.Println("Error storing audit event:", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) FindAll(ctx context.Context, reqHandler dto.PayloadAuditFilter) (*dto.ResponseAuditEvent, error) {
+	opts, err := buildFilter(reqHandler)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.AuditRepository.Count(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, dbutil.Order("id desc"), dbutil.Limit(reqHandler.Limit), dbutil.Offset(reqHandler.Offset))
+
+	fetch, err := s.AuditRepository.FindAll(ctx, "*", opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []dto.AuditEvent{}
+	for _, event := range fetch {
+		events = append(events, ToAuditEvent(event))
+	}
+
+	res := &dto.ResponseAuditEvent{
+		ResponseTotalRow: dto.ResponseTotalRow{
+			TotalRow: count,
+		},
+		Data: events,
+	}
+
+	return res, nil
+}
+
+// Export writes every matching event as CSV, rows are fetched in batches to keep memory flat
+func (s *service) Export(ctx context.Context, reqHandler dto.PayloadAuditFilter, w io.Writer) error {
+	opts, err := buildFilter(reqHandler)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	err = writer.Write([]string{"id", "event", "actor_id", "target_type", "target_id", "ip_address", "user_agent", "metadata", "created_at"})
+	if err != nil {
+		return err
+	}
+
+	lastID := 0
+	for {
+		batchOpts := append([]dbutil.QueryOption{}, opts...)
+		if lastID != 0 {
+			batchOpts = append(batchOpts, dbutil.Where("id < ?", lastID))
+		}
+		batchOpts = append(batchOpts, dbutil.Order("id desc"), dbutil.Limit(exportBatchSize))
+
+		fetch, err := s.AuditRepository.FindAll(ctx, "*", batchOpts...)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range fetch {
+			err = writer.Write([]string{
+				strconv.Itoa(event.ID),
+				string(event.Event),
+				optionalInt(event.ActorID),
+				event.TargetType,
+				optionalInt(event.TargetID),
+				event.IPAddress,
+				event.UserAgent,
+				event.Metadata,
+				event.CreatedAt.Format(consts.TimeFormatDateTime),
+			})
+			if err != nil {
+				return err
+			}
+			lastID = event.ID
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+
+		if len(fetch) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+func buildFilter(reqHandler dto.PayloadAuditFilter) ([]dbutil.QueryOption, error) {
+	var opts []dbutil.QueryOption
+
+	if reqHandler.Event != "" {
+		opts = append(opts, dbutil.Where("event = ?", reqHandler.Event))
+	}
+
+	if reqHandler.ActorID != 0 {
+		opts = append(opts, dbutil.Where("actor_id = ?", reqHandler.ActorID))
+	}
+
+	if reqHandler.TargetType != "" {
+		opts = append(opts, dbutil.Where("target_type = ?", reqHandler.TargetType))
+	}
+
+	if reqHandler.TargetID != 0 {
+		opts = append(opts, dbutil.Where("target_id = ?", reqHandler.TargetID))
+	}
+
+	if reqHandler.IP != "" {
+		opts = append(opts, dbutil.Where("ip_address = ?", reqHandler.IP))
+	}
+
+	dateOpts, err := dbutil.DateRange("created_at", reqHandler.DateFrom, reqHandler.DateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, dateOpts...), nil
+}
+
+// ToAuditEvent is the response form of an event, the metadata is decoded from its JSON column
+func ToAuditEvent(event *model.AuditEvent) dto.AuditEvent {
+	metadata := map[string]any{}
+	if event.Metadata != "" {
+		_ = json.Unmarshal([]byte(event.Metadata), &metadata)
+	}
+
+	return dto.AuditEvent{
+		ID:         event.ID,
+		Event:      string(event.Event),
+		ActorID:    event.ActorID,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		IPAddress:  event.IPAddress,
+		UserAgent:  event.UserAgent,
+		Metadata:   metadata,
+		CreatedAt:  event.CreatedAt.Format(consts.TimeFormatDateTime),
+	}
+}
+
+func optionalInt(val *int) string {
+	if val == nil {
+		return ""
+	}
+	return strconv.Itoa(*val)
+}
+
+/*
+fillRequestMeta completes the entry from the gin request when the service was called from a handler,
+the gin.Context is looked up by its key so it is still found after WithTenant or WithinTx wrapped it
+*/
+func fillRequestMeta(ctx context.Context, entry *dto.AuditEntry) {
+	if c, ok := ctx.Value(gin.ContextKey).(*gin.Context); ok {
+		if entry.IP == "" {
+			entry.IP = c.ClientIP()
+		}
+
+		if entry.UserAgent == "" {
+			entry.UserAgent = c.GetHeader("User-Agent")
+		}
+
+		if entry.ActorID == nil {
+			if value, exists := c.Get("user"); exists {
+				if sess, ok := value.(dto.JwtSession); ok && sess.ID != 0 {
+					actorID := sess.ID
+					entry.ActorID = &actorID
+				}
+			}
+		}
+	}
+
+	if len(entry.UserAgent) > 512 {
+		entry.UserAgent = strings.ToValidUTF8(entry.UserAgent[:512], "")
+	}
+}
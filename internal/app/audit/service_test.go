@@ -0,0 +1,67 @@
+package audit_test
+
+import (
+	"clean-arch/internal/app/audit"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/integration"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	// the migrations are read relative to the repository root
+	if err := os.Chdir("../../.."); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestRecordWithinTx(t *testing.T) {
+	h := integration.New(t)
+	svc := audit.NewService(h.Factory)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/organization", nil)
+	c.Request.RemoteAddr = "10.0.0.1:4321"
+	c.Request.Header.Set("User-Agent", strings.Repeat("a", 600))
+	c.Set("user", dto.JwtSession{ID: 7})
+
+	// the gin.Context is wrapped twice before it reaches Record
+	err := h.Factory.TxManager.WithinTx(dbutil.WithTenant(c, 1), func(ctx context.Context) error {
+		return svc.Record(ctx, dto.AuditEntry{Event: consts.AuditMemberJoined, TargetType: consts.AuditTargetOrg})
+	})
+	assert.Nil(t, err)
+
+	var events []model.AuditEvent
+	assert.Nil(t, h.DB.Find(&events).Error)
+	assert.Len(t, events, 1)
+	assert.Equal(t, 7, *events[0].ActorID)
+	assert.Equal(t, "10.0.0.1", events[0].IPAddress)
+	assert.Len(t, events[0].UserAgent, 512)
+}
+
+func TestRecordWithoutRequest(t *testing.T) {
+	h := integration.New(t)
+	svc := audit.NewService(h.Factory)
+
+	// a user agent passed by a job is cut like the one of a request
+	err := svc.Record(context.Background(), dto.AuditEntry{Event: consts.AuditMemberJoined, UserAgent: strings.Repeat("a", 600)})
+	assert.Nil(t, err)
+
+	var event model.AuditEvent
+	assert.Nil(t, h.DB.Take(&event).Error)
+	assert.Nil(t, event.ActorID)
+	assert.Empty(t, event.IPAddress)
+	assert.Len(t, event.UserAgent, 512)
+}
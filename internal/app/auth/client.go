@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/pkg/geoip"
+	"clean-arch/pkg/useragent"
+	"strings"
+)
+
+const maxUserAgentLength = 512
+
+// clientInfo parses the user agent and resolves the coarse location of the ip
+func clientInfo(geo *geoip.DB, ip string, userAgent string) model.ClientInfo {
+	ua := useragent.Parse(userAgent)
+	loc, _ := geo.Lookup(ip)
+
+	return model.ClientInfo{
+		Browser: ua.Browser,
+		OS:      ua.OS,
+		Device:  ua.Device,
+		Country: loc.Country,
+		Region:  loc.Region,
+		City:    loc.City,
+	}
+}
+
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= maxUserAgentLength {
+		return userAgent
+	}
+	return strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+}
+
+// clientFamily is the browser, os and device family of a stored login log or session
+func clientFamily(info model.ClientInfo, userAgent string) string {
+	if info.Browser == "" {
+		return useragent.Parse(userAgent).Family()
+	}
+
+	return useragent.Info{Browser: info.Browser, OS: info.OS, Device: info.Device}.Family()
+}
@@ -3,25 +3,27 @@ package auth
 import (
 	"clean-arch/internal/dto"
 	"clean-arch/internal/factory"
+	"clean-arch/internal/middleware"
 	"clean-arch/pkg/config"
 	"clean-arch/pkg/consts"
 	"clean-arch/pkg/util"
 	"fmt"
 	"io"
 	"net/http"
-	"regexp"
 
 	"github.com/gin-gonic/gin"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
 type handler struct {
-	service Service
+	service      Service
+	authenticate gin.HandlerFunc
 }
 
 func NewHandler(f *factory.Factory) *handler {
 	return &handler{
-		service: NewService(f),
+		service:      NewService(f),
+		authenticate: middleware.Authenticate(f),
 	}
 }
 
@@ -51,11 +53,14 @@ func (h *handler) Refresh(c *gin.Context) {
 }
 
 func (h *handler) Logout(c *gin.Context) {
-	header := c.Request.Header["Authorization"]
-	rep := regexp.MustCompile(`(Bearer)\s?`)
-	bearerStr := rep.ReplaceAllString(header[0], "")
+	sessionID, ok := middleware.SessionID(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
 
-	err := h.service.Logout(c, bearerStr)
+	err := h.service.Logout(c, sessionID)
 	if err != nil {
 		response := util.APIResponse(fmt.Sprintf("logout failed otp %s", err.Error()), http.StatusBadRequest, "failed", nil)
 		c.JSON(http.StatusBadRequest, response)
@@ -143,6 +148,85 @@ func (h *handler) RequestOTP(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+func (h *handler) NotMe(c *gin.Context) {
+	token := c.Param("token")
+
+	if token == "" {
+		response := util.APIResponse("data not valid", http.StatusUnprocessableEntity, "failed", nil)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := h.service.NotMe(c, token)
+	if err != nil {
+		response := util.APIResponse(fmt.Sprintf("failed to revoke sessions %s", err.Error()), http.StatusBadRequest, "failed", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("all sessions revoked, please change your password", http.StatusOK, "success", nil)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Sessions(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	res, err := h.service.Sessions(c, user.ID)
+	if err != nil {
+		response := util.APIResponse(fmt.Sprintf("failed to get sessions %s", err.Error()), http.StatusBadRequest, "failed", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("successfully get sessions", http.StatusOK, "success", res)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) SwitchOrganization(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	sessionID, okSession := middleware.SessionID(c)
+	if !ok || !okSession {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var body dto.PayloadSwitchOrganization
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.OrganizationID, validation.Min(0)),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.SwitchOrganization(c, user.ID, sessionID, body.OrganizationID)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err == consts.NotOrganizationMember {
+			status = http.StatusForbidden
+		}
+		response := util.APIResponse(err.Error(), status, "failed", nil)
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("successfully switch organization", http.StatusOK, "success", res)
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *handler) VerifyEmail(c *gin.Context) {
 	base64String := c.Param("hash")
 
@@ -252,6 +336,18 @@ func (h *handler) Login(c *gin.Context) {
 		return
 	}
 
+	if err == consts.UserBanned || err == consts.UserSuspended {
+		response := util.APIResponse(err.Error(), http.StatusForbidden, "failed", nil)
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	if err != nil {
+		response := util.APIResponse(err.Error(), http.StatusBadRequest, "failed", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
 	util.SetRefreshTokenCookie(c, *refreshToken, config.GetRefreshDuration())
 
 	response := util.APIResponse("Success Login", http.StatusOK, "success", data)
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"bytes"
+	"clean-arch/internal/factory"
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/crypto"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/geoip"
+	"clean-arch/pkg/useragent"
+	"clean-arch/pkg/util"
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+const (
+	riskHistorySize       = 50
+	riskMinHourHistory    = 10
+	riskMinTravelDistance = 500
+	notMeLinkDuration     = time.Hour * 72
+)
+
+// RiskAssessment describes why a login looks different from the user's history
+type RiskAssessment struct {
+	NewDevice        bool
+	NewCountry       bool
+	ImpossibleTravel bool
+	UnusualHour      bool
+	Location         geoip.Location
+}
+
+func (r RiskAssessment) Risky() bool {
+	return r.NewDevice || r.NewCountry || r.ImpossibleTravel || r.UnusualHour
+}
+
+func (r RiskAssessment) Reasons() []string {
+	reasons := []string{}
+	if r.NewDevice {
+		reasons = append(reasons, "new_device")
+	}
+	if r.NewCountry {
+		reasons = append(reasons, "new_country")
+	}
+	if r.ImpossibleTravel {
+		reasons = append(reasons, "impossible_travel")
+	}
+	if r.UnusualHour {
+		reasons = append(reasons, "unusual_hour")
+	}
+	return reasons
+}
+
+type riskEvaluator struct {
+	UserRepository repository.User
+	GeoIP          *geoip.DB
+	MaxTravelSpeed float64
+	Now            func() time.Time
+}
+
+type notMePayload struct {
+	UserID    int    `json:"uid"`
+	Purpose   string `json:"purpose"`
+	ExpiredAt int64  `json:"exp"`
+}
+
+func newRiskEvaluator(f *factory.Factory) *riskEvaluator {
+	return &riskEvaluator{
+		UserRepository: f.UserRepository,
+		GeoIP:          f.GeoIP,
+		MaxTravelSpeed: config.RiskMaxTravelSpeed(),
+		Now:            f.Clock.Now,
+	}
+}
+
+// Evaluate compares the login against the latest login logs of the user,
+// a user without history is never flagged since there is nothing to compare with
+func (e *riskEvaluator) Evaluate(ctx context.Context, userID int, ip string, userAgent string) (RiskAssessment, error) {
+	var res RiskAssessment
+
+	history, err := e.UserRepository.FindAllLoginLog(ctx, "id, ip_address, user_agent, browser, os, device, country, created_at", dbutil.Where("user_id = ? AND status = ?", userID, consts.LoginStatusSuccess), dbutil.Order("created_at desc"), dbutil.Limit(riskHistorySize))
+	if err != nil {
+		return res, err
+	}
+
+	if len(history) == 0 {
+		return res, nil
+	}
+
+	now := e.Now()
+	current, currentFound := e.GeoIP.Lookup(ip)
+	res.Location = current
+
+	res.NewDevice = true
+	family := useragent.Parse(userAgent).Family()
+	knownCountries := map[string]bool{}
+	knownHours := map[int]bool{}
+
+	for _, loginLog := range history {
+		if clientFamily(loginLog.ClientInfo, loginLog.UserAgent) == family {
+			res.NewDevice = false
+		}
+
+		if loginLog.Country != "" {
+			knownCountries[loginLog.Country] = true
+		} else if loc, ok := e.GeoIP.Lookup(loginLog.IPAddress); ok && loc.Country != "" {
+			knownCountries[loc.Country] = true
+		}
+
+		knownHours[loginLog.CreatedAt.In(now.Location()).Hour()] = true
+	}
+
+	if currentFound && current.Country != "" && len(knownCountries) > 0 && !knownCountries[current.Country] {
+		res.NewCountry = true
+	}
+
+	if currentFound {
+		res.ImpossibleTravel = e.impossibleTravel(history[0], current, now)
+	}
+
+	if len(history) >= riskMinHourHistory {
+		hour := now.Hour()
+		res.UnusualHour = !knownHours[hour] && !knownHours[(hour+1)%24] && !knownHours[(hour+23)%24]
+	}
+
+	return res, nil
+}
+
+func (e *riskEvaluator) impossibleTravel(last *model.LoginLog, current geoip.Location, now time.Time) bool {
+	previous, ok := e.GeoIP.Lookup(last.IPAddress)
+	if !ok {
+		return false
+	}
+
+	distance := geoip.DistanceKm(previous, current)
+	if distance < riskMinTravelDistance {
+		return false
+	}
+
+	hours := now.Sub(last.CreatedAt).Hours()
+	if hours <= 0 {
+		return true
+	}
+
+	return distance/hours > e.MaxTravelSpeed
+}
+
+// SendNewSignInEmail notifies the user about a risky login with a link to revoke every session
+func (s *service) SendNewSignInEmail(user model.User, risk RiskAssessment, ip string, userAgent string) error {
+	tmpl, err := template.ParseFiles(consts.TemplateEmailNewSignIn)
+	if err != nil {
+		return fmt.Errorf("error parsing template %s", err.Error())
+	}
+
+	token, err := crypto.SignPayload(s.SecretKey, notMePayload{
+		UserID:    user.ID,
+		Purpose:   "not_me",
+		ExpiredAt: s.Clock.Now().Add(notMeLinkDuration).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	var location []string
+	for _, part := range []string{risk.Location.City, risk.Location.Region, risk.Location.Country} {
+		if part != "" {
+			location = append(location, part)
+		}
+	}
+
+	data := struct {
+		AppUrl    string
+		Name      string
+		Time      string
+		IP        string
+		Location  string
+		UserAgent string
+		Url       string
+	}{
+		AppUrl:    util.GetEnv("APP_URL", "fallback") + ":" + util.GetEnv("APP_PORT", "fallback"),
+		Name:      user.Name,
+		Time:      s.Clock.Now().Format(consts.TimeFormatDateTime),
+		IP:        ip,
+		Location:  strings.Join(location, ", "),
+		UserAgent: userAgent,
+		Url:       util.GetEnv("FE_URL", "fallback") + "/auth/not-me/" + token,
+	}
+
+	var tplBuffer = new(bytes.Buffer)
+	if err := tmpl.Execute(tplBuffer, data); err != nil {
+		return fmt.Errorf("error executing template %s", err.Error())
+	}
+
+	go s.Mailer.Send(user.Email, s.TitleNewSignIn, tplBuffer.String())
+
+	return nil
+}
+
+// NotMe revokes every session of the user from the link in the new sign-in email
+func (s *service) NotMe(ctx context.Context, token string) error {
+	var payload notMePayload
+
+	err := crypto.VerifyPayload(s.SecretKey, token, &payload)
+	if err != nil || payload.Purpose != "not_me" || s.Clock.Now().Unix() > payload.ExpiredAt {
+		return consts.InvalidSignedLink
+	}
+
+	err = s.RevokeAllSessions(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, consts.AuditSessionRevoked, "", "", &payload.UserID, &payload.UserID, map[string]any{"reason": "not_me"})
+
+	return nil
+}
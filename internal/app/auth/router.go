@@ -20,6 +20,9 @@ func (h *handler) Router(g *gin.RouterGroup) {
 	g.POST("verify-email/:hash", h.VerifyEmail)
 	g.POST("request-otp", h.RequestOTP)
 	g.POST("verify-otp", h.VerifyOTP)
-	g.POST("logout", h.Logout)
+	g.POST("logout", h.authenticate, h.Logout)
 	g.POST("refresh", h.Refresh)
+	g.POST("not-me/:token", h.NotMe)
+	g.GET("sessions", h.authenticate, h.Sessions)
+	g.POST("switch-org", h.authenticate, h.SwitchOrganization)
 }
@@ -2,36 +2,50 @@ package auth
 
 import (
 	"bytes"
-	"clean-arch/database"
+	"clean-arch/internal/app/audit"
 	"clean-arch/internal/dto"
 	"clean-arch/internal/factory"
 	"clean-arch/internal/model"
 	"clean-arch/internal/repository"
+	"clean-arch/pkg/clock"
 	"clean-arch/pkg/config"
 	"clean-arch/pkg/consts"
 	"clean-arch/pkg/crypto"
 	"clean-arch/pkg/dbutil"
-	"clean-arch/pkg/helper"
+	"clean-arch/pkg/geoip"
+	"clean-arch/pkg/mailer"
+	"clean-arch/pkg/storage"
+	"clean-arch/pkg/token"
 	"clean-arch/pkg/util"
 	"context"
 	"encoding/base64"
 	"fmt"
 	"log"
-	"strconv"
 	"text/template"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"gorm.io/gorm"
 )
 
 type service struct {
-	UserRepository  repository.User
-	OtpRepository   repository.Otp
-	RedisRepository repository.Redis
-	TwoFactor       bool
-	TitleOTP        string
-	TitleVerify     string
+	UserRepository         repository.User
+	OtpRepository          repository.Otp
+	RedisRepository        repository.Redis
+	OrganizationRepository repository.Organization
+	AuditService           audit.Service
+	TxManager              dbutil.TxManager
+	Risk                   *riskEvaluator
+	GeoIP                  *geoip.DB
+	Storage                storage.Storage
+	Clock                  clock.Clock
+	Mailer                 mailer.Mailer
+	TokenIssuer            token.Issuer
+	SecretKey              string
+	TwoFactor              bool
+	RiskForceOTP           bool
+	TitleOTP               string
+	TitleVerify            string
+	TitleNewSignIn         string
 }
 
 type Service interface {
@@ -40,29 +54,47 @@ type Service interface {
 	RequestOTP(ctx context.Context, reqHandler dto.PayloadOtp) (dto.ResponseRequestOtp, error)
 	VerifyOTP(ctx context.Context, reqHandler dto.PayloadVerifyOtpTraced) (any, *string, error)
 	Refresh(ctx context.Context, refreshToken string, ip string) (dto.ResponseJWT, *string, error)
-	Logout(ctx context.Context, bearer string) error
+	Logout(ctx context.Context, sessionID int) error
+	NotMe(ctx context.Context, token string) error
+	Sessions(ctx context.Context, userID int) ([]dto.UserSession, error)
+	SwitchOrganization(ctx context.Context, userID int, sessionID int, orgID int) (dto.ResponseJWT, error)
 }
 
 func NewService(f *factory.Factory) Service {
 	return &service{
-		TwoFactor:       config.TwoFactor(),
-		UserRepository:  f.UserRepository,
-		OtpRepository:   f.OtpRepository,
-		RedisRepository: f.RedisRepository,
-		TitleOTP:        "Kode Verifikasi " + util.GetEnv("APP_NAME", "fallback"),
-		TitleVerify:     "Verifikasi Akun " + util.GetEnv("APP_NAME", "fallback"),
+		TwoFactor:              config.TwoFactor(),
+		RiskForceOTP:           config.RiskForceOTP(),
+		Risk:                   newRiskEvaluator(f),
+		GeoIP:                  f.GeoIP,
+		UserRepository:         f.UserRepository,
+		OtpRepository:          f.OtpRepository,
+		RedisRepository:        f.RedisRepository,
+		OrganizationRepository: f.OrganizationRepository,
+		AuditService:           audit.NewService(f),
+		TxManager:              f.TxManager,
+		Storage:                f.Storage,
+		Clock:                  f.Clock,
+		Mailer:                 f.Mailer,
+		TokenIssuer:            f.TokenIssuer,
+		SecretKey:              f.SecretKey,
+		TitleOTP:               "Kode Verifikasi " + util.GetEnv("APP_NAME", "fallback"),
+		TitleVerify:            "Verifikasi Akun " + util.GetEnv("APP_NAME", "fallback"),
+		TitleNewSignIn:         "Login Baru di Akun " + util.GetEnv("APP_NAME", "fallback"),
 	}
 }
 
 func (s *service) Refresh(ctx context.Context, refreshToken string, ip string) (dto.ResponseJWT, *string, error) {
-	var res dto.ResponseJWT
+	var (
+		res        dto.ResponseJWT
+		refreshExp *time.Time
+	)
 
 	session, err := s.UserRepository.FindSession(ctx, crypto.EncodeSHA256(refreshToken))
 	if err != nil {
 		return res, nil, fmt.Errorf("error find session: %s", err.Error())
 	}
 
-	if session.ExpiresAt.Before(time.Now()) {
+	if session.ExpiresAt.Before(s.Clock.Now()) {
 		return res, nil, fmt.Errorf("session expired")
 	}
 
@@ -70,43 +102,44 @@ func (s *service) Refresh(ctx context.Context, refreshToken string, ip string) (
 		return res, nil, fmt.Errorf("invalid refresh, ip address not match please re login")
 	}
 
-	user, err := s.UserRepository.FindOne(ctx, "id, email, name, profile_image_url, email_verified_at", dbutil.Where("id = ?", session.UserID))
+	user, err := s.UserRepository.FindOne(ctx, "id, email, name, profile_image_url, email_verified_at, status, suspended_until", dbutil.Where("id = ?", session.UserID))
 	if err != nil {
 		return res, nil, consts.UserNotFound
 	}
 
-	secretKey := []byte(util.GetEnv("APP_SECRET_KEY", "fallback"))
-	jwt, exp, refreshToken, refreshExp, err := s.GenerateToken(secretKey, strconv.Itoa(user.ID), user.Email)
-	if err != nil {
-		return res, nil, consts.ErrorGenerateJwt
+	if err := user.StatusError(s.Clock.Now()); err != nil {
+		return res, nil, err
 	}
 
-	if jwt == "" {
-		return res, nil, consts.EmptyGenerateJwt
+	refreshToken, refreshExp, err = s.GenerateRefreshToken()
+	if err != nil {
+		return res, nil, consts.ErrorGenerateJwt
 	}
 
 	session.RefreshTokenHash = crypto.EncodeSHA256(refreshToken)
 	session.ExpiresAt = *refreshExp
 
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
-	if err := tx.Error; err != nil {
-		return res, nil, err
+	err = s.UserRepository.UpdateSession(ctx, session.ID, session)
+	if err != nil {
+		return res, nil, consts.ErrorGenerateJwt
 	}
 
-	err = s.UserRepository.UpdateSession(tx, session.ID, session)
+	// the organization picked with SwitchOrganization stays on the session across refreshes
+	jwt, exp, err := s.GenerateToken(user.ID, user.Email, session.ID, session.OrganizationID)
 	if err != nil {
-		tx.Rollback()
 		return res, nil, consts.ErrorGenerateJwt
 	}
 
-	tx.Commit()
+	if jwt == "" {
+		return res, nil, consts.EmptyGenerateJwt
+	}
 
 	dataUser := dto.DataUserLogin{
 		ID:              user.ID,
 		Email:           user.Email,
 		Name:            user.Name,
 		EmailVerifiedAt: *user.EmailVerifiedAt,
-		ProfileImageURL: user.ProfileImageURL,
+		ProfileImageURL: s.Storage.URL(user.ProfileImageURL),
 	}
 
 	res = dto.ResponseJWT{
@@ -118,41 +151,118 @@ func (s *service) Refresh(ctx context.Context, refreshToken string, ip string) (
 	return res, &refreshToken, nil
 }
 
-func (s *service) Logout(ctx context.Context, bearer string) error {
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
-	if err := tx.Error; err != nil {
+func (s *service) Logout(ctx context.Context, sessionID int) error {
+	err := s.UserRepository.RevokeSession(ctx, sessionID)
+	if err != nil {
 		return err
 	}
 
-	err := s.UserRepository.RevokeSession(tx, bearer)
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditSessionRevoked,
+		TargetType: consts.AuditTargetSession,
+		TargetID:   &sessionID,
+		Metadata:   map[string]any{"reason": "logout"},
+	})
+
+	return nil
+}
+
+/*
+SwitchOrganization binds the session to one of the organizations of the user and returns an access
+token carrying it, middleware.Tenant uses it when the request has no X-Org-ID header. An orgID of 0
+removes the organization from the session
+*/
+func (s *service) SwitchOrganization(ctx context.Context, userID int, sessionID int, orgID int) (dto.ResponseJWT, error) {
+	var res dto.ResponseJWT
+
+	var sessionOrg *int
+	if orgID != 0 {
+		_, err := s.OrganizationRepository.FindMember(dbutil.WithTenant(ctx, orgID), "id", dbutil.Where("user_id = ?", userID))
+		if err != nil {
+			return res, consts.NotOrganizationMember
+		}
+		sessionOrg = &orgID
+	}
+
+	user, err := s.UserRepository.FindOne(ctx, "id, email", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return res, consts.UserNotFound
+	}
+
+	if err := s.UserRepository.SetSessionOrganization(ctx, sessionID, sessionOrg); err != nil {
+		return res, err
+	}
+
+	jwt, exp, err := s.GenerateToken(user.ID, user.Email, sessionID, sessionOrg)
+	if err != nil || jwt == "" {
+		return res, consts.ErrorGenerateJwt
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditOrgSwitched,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetSession,
+		TargetID:   &sessionID,
+		Metadata:   map[string]any{"organization_id": orgID},
+	})
+
+	res.TokenJwt = jwt
+	res.ExpiredAt = exp.Format(consts.TimeFormatDateTime)
+
+	return res, nil
+}
+
+// RevokeAllSessions revokes every active session of the user and drops the cached session data
+func (s *service) RevokeAllSessions(ctx context.Context, userID int) error {
+	err := s.UserRepository.RevokeUserSessions(ctx, userID)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	cacheKey := fmt.Sprintf("user_session-%d", userID)
+	_ = s.RedisRepository.Del(ctx, cacheKey)
 
 	return nil
 }
 
+func (s *service) Sessions(ctx context.Context, userID int) ([]dto.UserSession, error) {
+	fetch, err := s.UserRepository.FindAllSession(ctx, "*", dbutil.Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, consts.SessionActive, s.Clock.Now()), dbutil.Order("created_at desc"))
+	if err != nil {
+		return nil, err
+	}
+
+	res := []dto.UserSession{}
+	for _, session := range fetch {
+		res = append(res, dto.UserSession{
+			ID:        session.ID,
+			UserID:    session.UserID,
+			IPAddress: session.IPAddress,
+			UserAgent: session.UserAgent,
+			Browser:   session.Browser,
+			OS:        session.OS,
+			Device:    session.Device,
+			Country:   session.Country,
+			Region:    session.Region,
+			City:      session.City,
+			CreatedAt: session.CreatedAt.Format(consts.TimeFormatDateTime),
+			ExpiresAt: session.ExpiresAt.Format(consts.TimeFormatDateTime),
+			Revoked:   session.Revoked,
+		})
+	}
+
+	return res, nil
+}
+
 func (s *service) IncreaseAttempt(ctx context.Context, currentAttempt int, id int) error {
 	updateOtp := model.OTP{
 		Attempt: currentAttempt + 1,
 	}
 
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
-	if err := tx.Error; err != nil {
-		return err
-	}
-
-	err := s.OtpRepository.UpdateOne(tx, updateOtp, "id = ?", id)
+	err := s.OtpRepository.UpdateOne(ctx, id, updateOtp)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
-
 	return nil
 }
 
@@ -162,13 +272,13 @@ func (s *service) VerifyOTP(ctx context.Context, reqHandler dto.PayloadVerifyOtp
 		resFail dto.ResponseFailVerifyOtp
 	)
 
-	now := time.Now()
-	user, err := s.UserRepository.FindOne(ctx, "id, email, name, password, profile_image_url, email_verified_at", dbutil.Where("email = ?", reqHandler.Email))
+	now := s.Clock.Now()
+	user, err := s.UserRepository.FindOne(ctx, "id, email, name, password, profile_image_url, email_verified_at, status, suspended_until, erase_after", dbutil.Where("email = ?", reqHandler.Email))
 	if err != nil {
 		return res, nil, consts.UserNotFound
 	}
 
-	fetchOtp, err := s.OtpRepository.FindOne(ctx, true, "id, attempt, otp, expired_at", "user_id = ? AND expired_at > ?", user.ID, now)
+	fetchOtp, err := s.OtpRepository.FindLatest(ctx, "id, attempt, otp, expired_at", dbutil.Where("user_id = ? AND expired_at > ?", user.ID, now))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return res, nil, fmt.Errorf("otp already expired, please request new one")
@@ -178,6 +288,8 @@ func (s *service) VerifyOTP(ctx context.Context, reqHandler dto.PayloadVerifyOtp
 	}
 
 	if fetchOtp.Attempt == 5 {
+		s.storeFailedLogin(ctx, user.ID, reqHandler.IP, reqHandler.UserAgent, "otp_max_attempt")
+		s.recordAudit(ctx, consts.AuditOtpFailed, reqHandler.IP, reqHandler.UserAgent, nil, &user.ID, map[string]any{"reason": "max_attempt"})
 		return res, nil, fmt.Errorf("reached max verify attempt, please request a new one")
 	}
 
@@ -187,6 +299,9 @@ func (s *service) VerifyOTP(ctx context.Context, reqHandler dto.PayloadVerifyOtp
 			return res, nil, fmt.Errorf("failed update attemps data otp %s", err.Error())
 		}
 
+		s.storeFailedLogin(ctx, user.ID, reqHandler.IP, reqHandler.UserAgent, "invalid_otp")
+		s.recordAudit(ctx, consts.AuditOtpFailed, reqHandler.IP, reqHandler.UserAgent, nil, &user.ID, map[string]any{"attempt": fetchOtp.Attempt + 1})
+
 		left := 5 - (fetchOtp.Attempt + 1)
 		resFail = dto.ResponseFailVerifyOtp{
 			AttemptLeft: fmt.Sprint(left),
@@ -195,14 +310,19 @@ func (s *service) VerifyOTP(ctx context.Context, reqHandler dto.PayloadVerifyOtp
 		return resFail, nil, consts.OtpNotValid
 	}
 
-	secretKey := []byte(util.GetEnv("APP_SECRET_KEY", "fallback"))
-	jwt, exp, refreshToken, refreshExp, err := s.GenerateToken(secretKey, strconv.Itoa(user.ID), user.Email)
+	err = s.checkStatus(ctx, user, reqHandler.IP, reqHandler.UserAgent)
 	if err != nil {
-		return res, nil, consts.ErrorGenerateJwt
+		return res, nil, err
 	}
 
-	if jwt == "" {
-		return res, nil, consts.EmptyGenerateJwt
+	risk, err := s.Risk.Evaluate(ctx, user.ID, reqHandler.IP, reqHandler.UserAgent)
+	if err != nil {
+		log.Println("Error evaluating login risk:", err)
+	}
+
+	jwt, exp, refreshToken, err := s.startSession(ctx, user, reqHandler.IP, reqHandler.UserAgent)
+	if err != nil {
+		return res, nil, err
 	}
 
 	dataUser := dto.DataUserLogin{
@@ -210,41 +330,17 @@ func (s *service) VerifyOTP(ctx context.Context, reqHandler dto.PayloadVerifyOtp
 		Email:           user.Email,
 		Name:            user.Name,
 		EmailVerifiedAt: *user.EmailVerifiedAt,
-		ProfileImageURL: user.ProfileImageURL,
+		ProfileImageURL: s.Storage.URL(user.ProfileImageURL),
 	}
 
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
-	if err := tx.Error; err != nil {
-		return res, nil, err
-	}
-	sessionModel := model.UserSession{
-		UserID:           user.ID,
-		IPAddress:        reqHandler.IP,
-		RefreshTokenHash: crypto.EncodeSHA256(refreshToken),
-		ExpiresAt:        *refreshExp,
-	}
-
-	err = s.UserRepository.CreateSession(tx, sessionModel)
-	if err != nil {
-		tx.Rollback()
-		return res, nil, consts.ErrorGenerateJwt
-	}
+	cacheKey := fmt.Sprintf("user_session-%d", user.ID)
+	_ = s.RedisRepository.Del(ctx, cacheKey)
 
-	insertModel := model.LoginLog{
-		UserID:    user.ID,
-		IPAddress: reqHandler.IP,
-		UserAgent: reqHandler.UserAgent,
-	}
+	s.recordAudit(ctx, consts.AuditLoginSuccess, reqHandler.IP, reqHandler.UserAgent, &user.ID, &user.ID, map[string]any{"method": "otp"})
 
-	err = s.UserRepository.StoreLoginLog(tx, insertModel)
-	if err != nil {
-		tx.Rollback()
-		return res, nil, fmt.Errorf("error storing login logs %s", err.Error())
+	if risk.Risky() {
+		go s.SendNewSignInEmail(user, risk, reqHandler.IP, reqHandler.UserAgent)
 	}
-	tx.Commit()
-
-	cacheKey := fmt.Sprintf("user_session-%d", user.ID)
-	_ = s.RedisRepository.Del(ctx, cacheKey)
 
 	res = dto.ResponseJWT{
 		TokenJwt:  jwt,
@@ -275,78 +371,118 @@ func (s *service) VerifyEmail(ctx context.Context, base64String string) error {
 		return consts.ErrorLoadLocationTime
 	}
 
-	now := time.Now().In(loc)
+	now := s.Clock.Now().In(loc)
 
 	updateUser := model.User{
 		EmailVerifiedAt: &now,
 	}
 
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
-	if err := tx.Error; err != nil {
-		return err
-	}
-
-	err = s.UserRepository.UpdateOne(tx, user.ID, updateUser)
+	err = s.UserRepository.UpdateOne(ctx, user.ID, updateUser)
 	if err != nil {
-		tx.Rollback()
 		log.Println("Error updating user:", err)
 		return consts.FailedVerifyEmail
 	}
 
-	tx.Commit()
-
 	return nil
 }
 
-func (s *service) GenerateToken(secretKey []byte, userID string, email string) (string, *time.Time, string, *time.Time, error) {
-	loc, err := time.LoadLocation("Asia/Jakarta")
+// GenerateToken signs an access token bound to the session, Authenticate rejects it once the session is revoked
+func (s *service) GenerateToken(userID int, email string, sessionID int, orgID *int) (string, *time.Time, error) {
+	tokenString, exp, err := s.TokenIssuer.Issue(token.Claims{
+		UserID:         userID,
+		Email:          email,
+		SessionID:      sessionID,
+		OrganizationID: orgID,
+	})
 	if err != nil {
-		return "", nil, "", nil, err
+		return "", nil, err
 	}
 
-	jwtMode := util.GetEnv("JWT_MODE", "fallback")
-	nonUnixTime := time.Now().In(loc).Add(consts.TokenDurationDev)
-	expiredTime := nonUnixTime.Unix()
+	return tokenString, &exp, nil
+}
 
+func (s *service) GenerateRefreshToken() (string, *time.Time, error) {
 	refreshToken, err := util.GenerateRefreshToken()
 	if err != nil {
-		return "", nil, "", nil, err
+		return "", nil, err
+	}
+
+	refreshExp := s.Clock.Now().In(config.AppLocation()).Add(time.Hour * 24 * time.Duration(config.GetRefreshDuration()))
+
+	return refreshToken, &refreshExp, nil
+}
+
+/*
+startSession stores the session and login log of a successful login, the access token is signed once the session id is known.
+It runs once every check of the login passed, so it is also where a deactivated account is reactivated
+*/
+func (s *service) startSession(ctx context.Context, user model.User, ip string, userAgent string) (string, *time.Time, string, error) {
+	refreshToken, refreshExp, err := s.GenerateRefreshToken()
+	if err != nil {
+		return "", nil, "", consts.ErrorGenerateJwt
 	}
 
-	refreshExp := time.Now().In(loc).Add(time.Hour * 24 * consts.RefreshTokenDayAgeDev)
+	client := clientInfo(s.GeoIP, ip, userAgent)
+	sessionModel := model.UserSession{
+		UserID:           user.ID,
+		IPAddress:        ip,
+		UserAgent:        truncateUserAgent(userAgent),
+		RefreshTokenHash: crypto.EncodeSHA256(refreshToken),
+		ExpiresAt:        *refreshExp,
+		ClientInfo:       client,
+	}
 
-	if jwtMode == "release" {
-		nonUnixTime = time.Now().In(loc).Add(consts.TokenDurationRelease)
-		expiredTime = nonUnixTime.Unix()
+	reactivated := user.Status == consts.UserStatusDeactivated
 
-		refreshExp = time.Now().In(loc).Add(time.Hour * 24 * consts.RefreshTokenDayAgeRelease)
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		if reactivated {
+			if err := s.reactivate(ctx, user); err != nil {
+				return err
+			}
+		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"user_id": userID,
-			"email":   email,
-			"exp":     expiredTime,
-		})
+		err := s.UserRepository.CreateSession(ctx, &sessionModel)
+		if err != nil {
+			return consts.ErrorGenerateJwt
+		}
+
+		insertModel := model.LoginLog{
+			UserID:     user.ID,
+			IPAddress:  ip,
+			UserAgent:  truncateUserAgent(userAgent),
+			Status:     consts.LoginStatusSuccess,
+			ClientInfo: client,
+		}
 
-		tokenString, err := token.SignedString(secretKey)
+		err = s.UserRepository.StoreLoginLog(ctx, insertModel)
 		if err != nil {
-			return "", nil, "", nil, err
+			return fmt.Errorf("error storing login logs %s", err.Error())
 		}
 
-		return tokenString, &nonUnixTime, refreshToken, &refreshExp, nil
+		return nil
+	})
+	if err != nil {
+		return "", nil, "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID,
-		"email":   email,
-		"exp":     expiredTime,
-	})
+	if reactivated {
+		s.recordAudit(ctx, consts.AuditUserStatus, ip, userAgent, &user.ID, &user.ID, map[string]any{"from": user.Status, "to": consts.UserStatusActive, "reason": "reactivated_by_login"})
+
+		if user.EraseAfter != nil {
+			s.recordAudit(ctx, consts.AuditErasureCanceled, ip, userAgent, &user.ID, &user.ID, map[string]any{"reason": "reactivated_by_login"})
+		}
+	}
 
-	tokenString, err := token.SignedString(secretKey)
+	jwt, exp, err := s.GenerateToken(user.ID, user.Email, sessionModel.ID, nil)
 	if err != nil {
-		return "", nil, "", nil, err
+		return "", nil, "", consts.ErrorGenerateJwt
 	}
 
-	return tokenString, &nonUnixTime, refreshToken, &refreshExp, nil
+	if jwt == "" {
+		return "", nil, "", consts.EmptyGenerateJwt
+	}
+
+	return jwt, exp, refreshToken, nil
 }
 
 func (s *service) SendVerifyEmail(user model.User) error {
@@ -376,7 +512,7 @@ func (s *service) SendVerifyEmail(user model.User) error {
 		return consts.InvalidPassword
 	}
 
-	go helper.SendMail(user.Email, s.TitleVerify, tplBuffer.String())
+	go s.Mailer.Send(user.Email, s.TitleVerify, tplBuffer.String())
 
 	return nil
 }
@@ -391,20 +527,20 @@ func (s *service) RequestOTP(ctx context.Context, reqHandler dto.PayloadOtp) (dt
 		return res, consts.UserNotFound
 	}
 
-	now := time.Now()
+	now := s.Clock.Now()
 
 	otp, err := util.GenerateOTP(6)
 	if err != nil {
 		return res, fmt.Errorf("error while generating OTP %x", err.Error())
 	}
 
-	countOtpToday, err := s.OtpRepository.CountOTP(ctx, "user_id = ? AND DATE(created_at) = ?", thisUser.ID, now.Format(consts.TimeFormatDate))
+	countOtpToday, err := s.OtpRepository.Count(ctx, dbutil.Where("user_id = ? AND DATE(created_at) = ?", thisUser.ID, now.Format(consts.TimeFormatDate)))
 	if err != nil {
 		return res, err
 	}
 
 	if countOtpToday == 0 {
-		checkExpired, _ := s.OtpRepository.FindOne(ctx, true, "id, next_request_at, created_at", "user_id = ? AND expired_at < ?", thisUser.ID, now)
+		checkExpired, _ := s.OtpRepository.FindLatest(ctx, "id, next_request_at, created_at", dbutil.Where("user_id = ? AND expired_at < ?", thisUser.ID, now))
 		if checkExpired.ID != 0 && now.Before(checkExpired.NextRequestAt) {
 			res = dto.ResponseRequestOtp{
 				LastRequestOn: checkExpired.CreatedAt.Format(consts.TimeFormatDateTime),
@@ -415,7 +551,7 @@ func (s *service) RequestOTP(ctx context.Context, reqHandler dto.PayloadOtp) (dt
 		}
 	}
 
-	currentOtp, err := s.OtpRepository.FindOne(ctx, true, "id, attempt, expired_at, created_at, next_request_at", "user_id = ? AND expired_at > ?", thisUser.ID, now)
+	currentOtp, err := s.OtpRepository.FindLatest(ctx, "id, attempt, expired_at, created_at, next_request_at", dbutil.Where("user_id = ? AND expired_at > ?", thisUser.ID, now))
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return res, err
 	}
@@ -444,17 +580,10 @@ func (s *service) RequestOTP(ctx context.Context, reqHandler dto.PayloadOtp) (dt
 		NextRequestAt: nextRequest,
 	}
 
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
-	if err := tx.Error; err != nil {
-		return res, err
-	}
-
-	err = s.OtpRepository.StoreOTP(tx, insertModel)
+	err = s.OtpRepository.Store(ctx, &insertModel)
 	if err != nil {
-		tx.Rollback()
 		return res, fmt.Errorf("error while generating otp %s", err.Error())
 	}
-	tx.Commit()
 
 	dataOtp := dto.DataOtpEmail{
 		Name:  thisUser.Name,
@@ -464,6 +593,8 @@ func (s *service) RequestOTP(ctx context.Context, reqHandler dto.PayloadOtp) (dt
 
 	go s.SendOTPEmail(dataOtp)
 
+	s.recordAudit(ctx, consts.AuditOtpRequested, "", "", nil, &thisUser.ID, map[string]any{"next_request_at": nextRequest.Format(consts.TimeFormatDateTime)})
+
 	res = dto.ResponseRequestOtp{
 		LastRequestOn: now.Format(consts.TimeFormatDateTime),
 		NextRequestAt: nextRequest.Format(consts.TimeFormatDateTime),
@@ -512,15 +643,15 @@ func (s *service) SendOTPEmail(dataOtp dto.DataOtpEmail) error {
 		return consts.InvalidPassword
 	}
 
-	go helper.SendMail(dataOtp.Email, s.TitleOTP, tplBuffer.String())
+	go s.Mailer.Send(dataOtp.Email, s.TitleOTP, tplBuffer.String())
 
 	return nil
 }
 
 func (s *service) Process2FA(ctx context.Context, body dto.PayloadLoginTraced, thisUser model.User) error {
-	now := time.Now()
+	now := s.Clock.Now()
 
-	loginLog, err := s.UserRepository.FindLoginLog(ctx, dbutil.Where("ip_address = ? AND user_id = ? and DATE(created_at) = ?", body.IP, thisUser.ID, now.Format(consts.TimeFormatDate)))
+	loginLog, err := s.UserRepository.FindLoginLog(ctx, dbutil.Where("ip_address = ? AND user_id = ? AND status = ? AND DATE(created_at) = ?", body.IP, thisUser.ID, consts.LoginStatusSuccess, now.Format(consts.TimeFormatDate)))
 	if err != gorm.ErrRecordNotFound {
 		return err
 	}
@@ -532,24 +663,56 @@ func (s *service) Process2FA(ctx context.Context, body dto.PayloadLoginTraced, t
 	return consts.Required2FA
 }
 
+// RehashPassword stores a new hash of the plain password using the current parameters
+func (s *service) RehashPassword(ctx context.Context, userID int, password string) error {
+	hashedPassword, err := util.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	err = s.UserRepository.UpdateOne(ctx, userID, model.User{Password: hashedPassword})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *service) LoginAttempt(ctx context.Context, reqHandler dto.PayloadLoginTraced) (dto.ResponseJWT, *string, error) {
 	var (
 		res dto.ResponseJWT
 	)
 
-	user, err := s.UserRepository.FindOne(ctx, "id, email, name, profile_image_url, password, email_verified_at", dbutil.Where("email = ?", reqHandler.Email))
+	user, err := s.UserRepository.FindOne(ctx, "id, email, name, profile_image_url, password, email_verified_at, status, suspended_until, erase_after", dbutil.Where("email = ?", reqHandler.Email))
 	if err != nil {
+		s.recordAudit(ctx, consts.AuditLoginFailed, reqHandler.IP, reqHandler.UserAgent, nil, nil, map[string]any{"email": reqHandler.Email, "reason": "user_not_found"})
 		return res, nil, consts.UserNotFound
 	}
 
-	_, err = util.VerifyPassword(reqHandler.Password, user.Password)
-	if err != nil {
+	match, err := util.VerifyPassword(reqHandler.Password, user.Password)
+	if err != nil || !match {
+		s.storeFailedLogin(ctx, user.ID, reqHandler.IP, reqHandler.UserAgent, "invalid_password")
+		s.recordAudit(ctx, consts.AuditLoginFailed, reqHandler.IP, reqHandler.UserAgent, nil, &user.ID, map[string]any{"reason": "invalid_password"})
 		return res, nil, consts.InvalidPassword
 	}
 
+	err = s.checkStatus(ctx, user, reqHandler.IP, reqHandler.UserAgent)
+	if err != nil {
+		return res, nil, err
+	}
+
+	if util.PasswordNeedsRehash(user.Password) {
+		err = s.RehashPassword(ctx, user.ID, reqHandler.Password)
+		if err != nil {
+			log.Println("Error rehashing password:", err)
+		}
+	}
+
 	if user.EmailVerifiedAt == nil {
 		go s.SendVerifyEmail(user)
 
+		s.storeFailedLogin(ctx, user.ID, reqHandler.IP, reqHandler.UserAgent, "email_not_verified")
+		s.recordAudit(ctx, consts.AuditLoginFailed, reqHandler.IP, reqHandler.UserAgent, nil, &user.ID, map[string]any{"reason": "email_not_verified"})
 		return res, nil, consts.UserNotVerifyEmail
 	}
 
@@ -560,14 +723,22 @@ func (s *service) LoginAttempt(ctx context.Context, reqHandler dto.PayloadLoginT
 		}
 	}
 
-	secretKey := []byte(util.GetEnv("APP_SECRET_KEY", "fallback"))
-	jwt, exp, refreshToken, refreshExp, err := s.GenerateToken(secretKey, strconv.Itoa(user.ID), user.Email)
+	risk, err := s.Risk.Evaluate(ctx, user.ID, reqHandler.IP, reqHandler.UserAgent)
 	if err != nil {
-		return res, nil, consts.ErrorGenerateJwt
+		log.Println("Error evaluating login risk:", err)
 	}
 
-	if jwt == "" {
-		return res, nil, consts.EmptyGenerateJwt
+	if risk.Risky() {
+		s.recordAudit(ctx, consts.AuditLoginRisk, reqHandler.IP, reqHandler.UserAgent, nil, &user.ID, map[string]any{"reasons": risk.Reasons(), "country": risk.Location.Country})
+
+		if s.RiskForceOTP {
+			return res, nil, consts.Required2FA
+		}
+	}
+
+	jwt, exp, refreshToken, err := s.startSession(ctx, user, reqHandler.IP, reqHandler.UserAgent)
+	if err != nil {
+		return res, nil, err
 	}
 
 	dataUser := dto.DataUserLogin{
@@ -575,51 +746,81 @@ func (s *service) LoginAttempt(ctx context.Context, reqHandler dto.PayloadLoginT
 		Email:           user.Email,
 		Name:            user.Name,
 		EmailVerifiedAt: *user.EmailVerifiedAt,
-		ProfileImageURL: user.ProfileImageURL,
+		ProfileImageURL: s.Storage.URL(user.ProfileImageURL),
 	}
 
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
-	if err := tx.Error; err != nil {
-		return res, nil, err
+	cacheKey := fmt.Sprintf("user_session-%d", user.ID)
+	_ = s.RedisRepository.Del(ctx, cacheKey)
+
+	s.recordAudit(ctx, consts.AuditLoginSuccess, reqHandler.IP, reqHandler.UserAgent, &user.ID, &user.ID, map[string]any{"method": "password"})
+
+	if risk.Risky() {
+		go s.SendNewSignInEmail(user, risk, reqHandler.IP, reqHandler.UserAgent)
 	}
-	sessionModel := model.UserSession{
-		UserID:           user.ID,
-		IPAddress:        reqHandler.IP,
-		RefreshTokenHash: crypto.EncodeSHA256(refreshToken),
-		ExpiresAt:        *refreshExp,
+
+	res = dto.ResponseJWT{
+		TokenJwt:  jwt,
+		ExpiredAt: exp.Format(consts.TimeFormatDateTime),
+		DataUser:  &dataUser,
 	}
 
-	err = s.UserRepository.CreateSession(tx, sessionModel)
-	if err != nil {
-		tx.Rollback()
-		return res, nil, consts.ErrorGenerateJwt
+	return res, &refreshToken, nil
+}
+
+// checkStatus blocks banned and suspended users, a deactivated user may go on and is reactivated by startSession once every check passed
+func (s *service) checkStatus(ctx context.Context, user model.User, ip string, userAgent string) error {
+	err := user.StatusError(s.Clock.Now())
+	if err == nil || err == consts.UserDeactivated {
+		return nil
 	}
 
+	s.storeFailedLogin(ctx, user.ID, ip, userAgent, "user_"+string(user.Status))
+	s.recordAudit(ctx, consts.AuditLoginFailed, ip, userAgent, nil, &user.ID, map[string]any{"reason": "user_status", "status": user.Status})
+	return err
+}
+
+// reactivate makes a deactivated account active again and cancels its pending erasure
+func (s *service) reactivate(ctx context.Context, user model.User) error {
+	return s.UserRepository.UpdateColumns(ctx, user.ID, map[string]any{
+		"status":               consts.UserStatusActive,
+		"status_reason":        "",
+		"suspended_until":      nil,
+		"status_changed_by":    user.ID,
+		"status_changed_at":    s.Clock.Now(),
+		"erasure_requested_at": nil,
+		"erase_after":          nil,
+	})
+}
+
+// storeFailedLogin keeps failed attempts of known users in login_logs for the login history
+func (s *service) storeFailedLogin(ctx context.Context, userID int, ip string, userAgent string, reason string) {
 	insertModel := model.LoginLog{
-		UserID:    user.ID,
-		IPAddress: reqHandler.IP,
-		UserAgent: reqHandler.UserAgent,
+		UserID:        userID,
+		IPAddress:     ip,
+		UserAgent:     truncateUserAgent(userAgent),
+		Status:        consts.LoginStatusFailed,
+		FailureReason: reason,
+		ClientInfo:    clientInfo(s.GeoIP, ip, userAgent),
 	}
 
-	if err := tx.Error; err != nil {
-		return res, nil, err
+	if err := s.UserRepository.StoreLoginLog(ctx, insertModel); err != nil {
+		log.Println("Error storing failed login:", err)
 	}
+}
 
-	err = s.UserRepository.StoreLoginLog(tx, insertModel)
-	if err != nil {
-		tx.Rollback()
-		return res, nil, fmt.Errorf("error storing login logs %s", err.Error())
+func (s *service) recordAudit(ctx context.Context, event consts.AuditEvent, ip string, userAgent string, actorID *int, targetID *int, metadata map[string]any) {
+	entry := dto.AuditEntry{
+		Event:     event,
+		ActorID:   actorID,
+		IP:        ip,
+		UserAgent: userAgent,
+		Metadata:  metadata,
 	}
-	tx.Commit()
-
-	cacheKey := fmt.Sprintf("user_session-%d", user.ID)
-	_ = s.RedisRepository.Del(ctx, cacheKey)
 
-	res = dto.ResponseJWT{
-		TokenJwt:  jwt,
-		ExpiredAt: exp.Format(consts.TimeFormatDateTime),
-		DataUser:  &dataUser,
+	if targetID != nil {
+		entry.TargetType = consts.AuditTargetUser
+		entry.TargetID = targetID
 	}
 
-	return res, &refreshToken, nil
+	_ = s.AuditService.Record(ctx, entry)
 }
@@ -0,0 +1,445 @@
+package auth_test
+
+import (
+	"clean-arch/internal/app/auth"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository/memory"
+	"clean-arch/pkg/clock"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/crypto"
+	"clean-arch/pkg/mailer"
+	"clean-arch/pkg/storage"
+	"clean-arch/pkg/util"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testIP        = "10.0.0.1"
+	testUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+)
+
+type env struct {
+	svc    auth.Service
+	db     *memory.DB
+	clock  *clock.Fake
+	mailer *mailer.Recorder
+}
+
+func TestMain(m *testing.M) {
+	// the email templates are read relative to the repository root
+	if err := os.Chdir("../../.."); err != nil {
+		panic(err)
+	}
+	util.SetPasswordParams(util.PasswordParams{Memory: 1024})
+
+	os.Exit(m.Run())
+}
+
+func setup(t *testing.T) env {
+	c := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	db := memory.NewDB(c)
+	rec := &mailer.Recorder{}
+	store := storage.NewLocal(t.TempDir(), "http://localhost/storage", "secret")
+
+	return env{
+		svc:    auth.NewService(memory.NewFactory(db, rec, store)),
+		db:     db,
+		clock:  c,
+		mailer: rec,
+	}
+}
+
+func (e env) user(t *testing.T, email string, change func(*model.User)) model.User {
+	hash, err := util.HashPassword("secret123")
+	assert.Nil(t, err)
+
+	now := e.clock.Now()
+	user := model.User{
+		Name:            "Test",
+		Email:           email,
+		Password:        hash,
+		Role:            consts.RoleTypeUser,
+		Status:          consts.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+	if change != nil {
+		change(&user)
+	}
+
+	assert.Nil(t, memory.NewUserRepository(e.db).Store(context.Background(), &user))
+	return user
+}
+
+func (e env) login(email string, password string) (dto.ResponseJWT, *string, error) {
+	return e.svc.LoginAttempt(context.Background(), dto.PayloadLoginTraced{
+		Email:     email,
+		Password:  password,
+		IP:        testIP,
+		UserAgent: testUserAgent,
+	})
+}
+
+func (e env) sentTo(email string) func() bool {
+	return func() bool {
+		for _, msg := range e.mailer.Sent() {
+			if msg.To == email {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func loginLogs(db *memory.DB, status consts.LoginStatus) []model.LoginLog {
+	var res []model.LoginLog
+	for _, loginLog := range memory.Rows[model.LoginLog](db) {
+		if loginLog.Status == status {
+			res = append(res, loginLog)
+		}
+	}
+	return res
+}
+
+func TestLoginAttempt(t *testing.T) {
+	e := setup(t)
+	user := e.user(t, "budi@example.com", nil)
+
+	res, refreshToken, err := e.login("budi@example.com", "secret123")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, res.TokenJwt)
+	assert.Equal(t, user.ID, res.DataUser.ID)
+	assert.NotNil(t, refreshToken)
+
+	sessions := memory.Rows[model.UserSession](e.db)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, crypto.EncodeSHA256(*refreshToken), sessions[0].RefreshTokenHash)
+	assert.Equal(t, testIP, sessions[0].IPAddress)
+	assert.Len(t, loginLogs(e.db, consts.LoginStatusSuccess), 1)
+}
+
+func TestLoginAttemptWrongPassword(t *testing.T) {
+	e := setup(t)
+	e.user(t, "budi@example.com", nil)
+
+	_, refreshToken, err := e.login("budi@example.com", "wrong")
+	assert.Equal(t, consts.InvalidPassword, err)
+	assert.Nil(t, refreshToken)
+	assert.Empty(t, memory.Rows[model.UserSession](e.db))
+
+	failed := loginLogs(e.db, consts.LoginStatusFailed)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "invalid_password", failed[0].FailureReason)
+
+	_, _, err = e.login("nobody@example.com", "secret123")
+	assert.Equal(t, consts.UserNotFound, err)
+}
+
+func TestLoginAttemptUnverified(t *testing.T) {
+	e := setup(t)
+	e.user(t, "budi@example.com", func(u *model.User) {
+		u.EmailVerifiedAt = nil
+	})
+
+	_, _, err := e.login("budi@example.com", "secret123")
+	assert.Equal(t, consts.UserNotVerifyEmail, err)
+	assert.Empty(t, memory.Rows[model.UserSession](e.db))
+
+	// the verification link is sent again
+	assert.Eventually(t, e.sentTo("budi@example.com"), time.Second, 10*time.Millisecond)
+}
+
+func TestLoginAttemptDeactivatedUnverified(t *testing.T) {
+	e := setup(t)
+	e.user(t, "budi@example.com", func(u *model.User) {
+		u.Status = consts.UserStatusDeactivated
+		u.EmailVerifiedAt = nil
+	})
+
+	// a login that fails a later check leaves the account deactivated
+	_, _, err := e.login("budi@example.com", "secret123")
+	assert.Equal(t, consts.UserNotVerifyEmail, err)
+	assert.Equal(t, consts.UserStatusDeactivated, memory.Rows[model.User](e.db)[0].Status)
+	for _, event := range memory.Rows[model.AuditEvent](e.db) {
+		assert.NotEqual(t, consts.AuditUserStatus, event.Event)
+	}
+}
+
+func TestLoginAttemptSuspended(t *testing.T) {
+	e := setup(t)
+	until := e.clock.Now().Add(24 * time.Hour)
+	e.user(t, "budi@example.com", func(u *model.User) {
+		u.Status = consts.UserStatusSuspended
+		u.SuspendedUntil = &until
+	})
+
+	_, _, err := e.login("budi@example.com", "secret123")
+	assert.Equal(t, consts.UserSuspended, err)
+	assert.Empty(t, memory.Rows[model.UserSession](e.db))
+
+	// a suspension past its date no longer blocks the login
+	e.clock.Advance(25 * time.Hour)
+	_, _, err = e.login("budi@example.com", "secret123")
+	assert.Nil(t, err)
+}
+
+func TestLoginAttemptReactivates(t *testing.T) {
+	viper.Set("ENABLE_OTP", true)
+	defer viper.Set("ENABLE_OTP", nil)
+
+	e := setup(t)
+	eraseAfter := e.clock.Now().Add(30 * 24 * time.Hour)
+	user := e.user(t, "budi@example.com", func(u *model.User) {
+		u.Status = consts.UserStatusDeactivated
+		u.EraseAfter = &eraseAfter
+	})
+
+	// the password alone doesn't cancel the erasure while the second factor is pending
+	_, _, err := e.login("budi@example.com", "secret123")
+	assert.Equal(t, consts.Required2FA, err)
+
+	users := memory.Rows[model.User](e.db)
+	assert.Equal(t, consts.UserStatusDeactivated, users[0].Status)
+	assert.NotNil(t, users[0].EraseAfter)
+	for _, event := range memory.Rows[model.AuditEvent](e.db) {
+		assert.NotEqual(t, consts.AuditUserStatus, event.Event)
+		assert.NotEqual(t, consts.AuditErasureCanceled, event.Event)
+	}
+
+	_, err = e.svc.RequestOTP(context.Background(), dto.PayloadOtp{Email: user.Email})
+	assert.Nil(t, err)
+	_, _, err = e.verify(user.Email, e.latestOtp(t).OTP)
+	assert.Nil(t, err)
+
+	users = memory.Rows[model.User](e.db)
+	assert.Equal(t, user.ID, users[0].ID)
+	assert.Equal(t, consts.UserStatusActive, users[0].Status)
+	assert.Nil(t, users[0].EraseAfter)
+
+	var events []consts.AuditEvent
+	for _, event := range memory.Rows[model.AuditEvent](e.db) {
+		events = append(events, event.Event)
+	}
+	assert.Contains(t, events, consts.AuditUserStatus)
+	assert.Contains(t, events, consts.AuditErasureCanceled)
+}
+
+func TestGetCooldownOtp(t *testing.T) {
+	e := setup(t)
+	svc := e.svc.(interface {
+		GetCooldownOtp(countOtp int) (int, error)
+	})
+
+	for count, want := range []consts.AttemptCooldown{consts.AttemptOne, consts.AttemptTwo, consts.AttemptThree, consts.AttemptFour, consts.AttemptFive} {
+		cooldown, err := svc.GetCooldownOtp(count)
+		assert.Nil(t, err)
+		assert.Equal(t, int(want), cooldown)
+	}
+
+	_, err := svc.GetCooldownOtp(5)
+	assert.NotNil(t, err)
+}
+
+func TestRequestOTPCooldown(t *testing.T) {
+	e := setup(t)
+	user := e.user(t, "budi@example.com", nil)
+	ctx := context.Background()
+	payload := dto.PayloadOtp{Email: user.Email}
+	start := e.clock.Now()
+
+	res, err := e.svc.RequestOTP(ctx, payload)
+	assert.Nil(t, err)
+	assert.Equal(t, start.Format(consts.TimeFormatDateTime), res.LastRequestOn)
+	assert.Equal(t, start.Add(60*time.Second).Format(consts.TimeFormatDateTime), res.NextRequestAt)
+	assert.Len(t, memory.Rows[model.OTP](e.db), 1)
+	assert.Eventually(t, e.sentTo(user.Email), time.Second, 10*time.Millisecond)
+
+	// during the cooldown the same request is returned and no otp is made
+	e.clock.Advance(30 * time.Second)
+	res, err = e.svc.RequestOTP(ctx, payload)
+	assert.Nil(t, err)
+	assert.Equal(t, start.Format(consts.TimeFormatDateTime), res.LastRequestOn)
+	assert.Len(t, memory.Rows[model.OTP](e.db), 1)
+
+	// every otp of the day doubles the cooldown of the next one
+	for i, cooldown := range []consts.AttemptCooldown{consts.AttemptTwo, consts.AttemptThree, consts.AttemptFour, consts.AttemptFive} {
+		e.clock.Set(e.nextRequestAt(t))
+
+		now := e.clock.Now()
+		res, err = e.svc.RequestOTP(ctx, payload)
+		assert.Nil(t, err)
+		assert.Equal(t, now.Format(consts.TimeFormatDateTime), res.LastRequestOn)
+		assert.Equal(t, now.Add(time.Duration(cooldown)*time.Second).Format(consts.TimeFormatDateTime), res.NextRequestAt)
+		assert.Len(t, memory.Rows[model.OTP](e.db), i+2)
+	}
+
+	// the fifth otp of the day is the last one once it expires
+	e.clock.Advance(6 * time.Minute)
+	_, err = e.svc.RequestOTP(ctx, payload)
+	assert.Equal(t, consts.ErrorLimitOtp, err)
+
+	// the next day the cooldown of the fifth otp still applies
+	e.clock.Set(time.Date(2026, 1, 6, 0, 30, 0, 0, time.UTC))
+	res, err = e.svc.RequestOTP(ctx, payload)
+	assert.Nil(t, err)
+	assert.Equal(t, e.nextRequestAt(t).Format(consts.TimeFormatDateTime), res.NextRequestAt)
+	assert.Len(t, memory.Rows[model.OTP](e.db), 5)
+
+	e.clock.Set(e.nextRequestAt(t))
+	_, err = e.svc.RequestOTP(ctx, payload)
+	assert.Nil(t, err)
+	assert.Len(t, memory.Rows[model.OTP](e.db), 6)
+}
+
+func TestRequestOTPUnknownUser(t *testing.T) {
+	e := setup(t)
+
+	_, err := e.svc.RequestOTP(context.Background(), dto.PayloadOtp{Email: "nobody@example.com"})
+	assert.Equal(t, consts.UserNotFound, err)
+}
+
+func (e env) nextRequestAt(t *testing.T) time.Time {
+	otps := memory.Rows[model.OTP](e.db)
+	assert.NotEmpty(t, otps)
+	return otps[len(otps)-1].NextRequestAt
+}
+
+func (e env) latestOtp(t *testing.T) model.OTP {
+	otps := memory.Rows[model.OTP](e.db)
+	assert.NotEmpty(t, otps)
+	return otps[len(otps)-1]
+}
+
+func (e env) verify(email string, otp string) (any, *string, error) {
+	return e.svc.VerifyOTP(context.Background(), dto.PayloadVerifyOtpTraced{
+		Email:     email,
+		OTP:       otp,
+		IP:        testIP,
+		UserAgent: testUserAgent,
+	})
+}
+
+func wrongOtp(otp string) string {
+	if otp == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestVerifyOTP(t *testing.T) {
+	e := setup(t)
+	user := e.user(t, "budi@example.com", nil)
+
+	_, err := e.svc.RequestOTP(context.Background(), dto.PayloadOtp{Email: user.Email})
+	assert.Nil(t, err)
+	otp := e.latestOtp(t)
+
+	res, _, err := e.verify(user.Email, wrongOtp(otp.OTP))
+	assert.Equal(t, consts.OtpNotValid, err)
+	assert.Equal(t, dto.ResponseFailVerifyOtp{AttemptLeft: "4"}, res)
+	assert.Equal(t, 1, e.latestOtp(t).Attempt)
+
+	res, refreshToken, err := e.verify(user.Email, otp.OTP)
+	assert.Nil(t, err)
+	assert.NotNil(t, refreshToken)
+	assert.NotEmpty(t, res.(dto.ResponseJWT).TokenJwt)
+	assert.Len(t, memory.Rows[model.UserSession](e.db), 1)
+}
+
+func TestVerifyOTPMaxAttempt(t *testing.T) {
+	e := setup(t)
+	user := e.user(t, "budi@example.com", nil)
+
+	_, err := e.svc.RequestOTP(context.Background(), dto.PayloadOtp{Email: user.Email})
+	assert.Nil(t, err)
+	otp := e.latestOtp(t)
+
+	for left := 4; left >= 0; left-- {
+		res, _, err := e.verify(user.Email, wrongOtp(otp.OTP))
+		assert.Equal(t, consts.OtpNotValid, err)
+		assert.Equal(t, dto.ResponseFailVerifyOtp{AttemptLeft: fmt.Sprint(left)}, res)
+	}
+	assert.Equal(t, 5, e.latestOtp(t).Attempt)
+	assert.Len(t, loginLogs(e.db, consts.LoginStatusFailed), 5)
+
+	// after five wrong attempts even the right otp is refused
+	_, refreshToken, err := e.verify(user.Email, otp.OTP)
+	assert.EqualError(t, err, "reached max verify attempt, please request a new one")
+	assert.Nil(t, refreshToken)
+	assert.Equal(t, 5, e.latestOtp(t).Attempt)
+	assert.Empty(t, memory.Rows[model.UserSession](e.db))
+}
+
+func TestVerifyOTPExpired(t *testing.T) {
+	e := setup(t)
+	user := e.user(t, "budi@example.com", nil)
+
+	_, err := e.svc.RequestOTP(context.Background(), dto.PayloadOtp{Email: user.Email})
+	assert.Nil(t, err)
+	otp := e.latestOtp(t)
+
+	e.clock.Advance(6 * time.Minute)
+	_, _, err = e.verify(user.Email, otp.OTP)
+	assert.EqualError(t, err, "otp already expired, please request new one")
+}
+
+func TestRefresh(t *testing.T) {
+	e := setup(t)
+	user := e.user(t, "budi@example.com", nil)
+	ctx := context.Background()
+
+	_, refreshToken, err := e.login(user.Email, "secret123")
+	assert.Nil(t, err)
+
+	e.clock.Advance(time.Hour)
+	res, rotated, err := e.svc.Refresh(ctx, *refreshToken, testIP)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, res.TokenJwt)
+	assert.Equal(t, user.ID, res.DataUser.ID)
+	assert.NotEqual(t, *refreshToken, *rotated)
+
+	sessions := memory.Rows[model.UserSession](e.db)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, crypto.EncodeSHA256(*rotated), sessions[0].RefreshTokenHash)
+
+	// the rotated token replaces the old one
+	_, _, err = e.svc.Refresh(ctx, *refreshToken, testIP)
+	assert.NotNil(t, err)
+
+	_, _, err = e.svc.Refresh(ctx, *rotated, "10.0.0.2")
+	assert.EqualError(t, err, "invalid refresh, ip address not match please re login")
+}
+
+func TestRefreshExpired(t *testing.T) {
+	e := setup(t)
+	user := e.user(t, "budi@example.com", nil)
+
+	_, refreshToken, err := e.login(user.Email, "secret123")
+	assert.Nil(t, err)
+
+	e.clock.Advance(time.Duration(config.GetRefreshDuration()+1) * 24 * time.Hour)
+	_, _, err = e.svc.Refresh(context.Background(), *refreshToken, testIP)
+	assert.EqualError(t, err, "session expired")
+}
+
+func TestRefreshRevoked(t *testing.T) {
+	e := setup(t)
+	user := e.user(t, "budi@example.com", nil)
+
+	_, refreshToken, err := e.login(user.Email, "secret123")
+	assert.Nil(t, err)
+
+	sessions := memory.Rows[model.UserSession](e.db)
+	assert.Nil(t, e.svc.Logout(context.Background(), sessions[0].ID))
+
+	_, _, err = e.svc.Refresh(context.Background(), *refreshToken, testIP)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,74 @@
+package file
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/factory"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/util"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type handler struct {
+	service      Service
+	cacheControl string
+}
+
+func NewHandler(f *factory.Factory) *handler {
+	return &handler{
+		service:      NewService(f),
+		cacheControl: config.StorageCacheControl(),
+	}
+}
+
+// Serve answers Range, If-None-Match and If-Modified-Since requests through http.ServeContent
+func (h *handler) Serve(c *gin.Context) {
+	var payload dto.PayloadFile
+	if err := c.ShouldBindQuery(&payload); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.Open(c, strings.TrimPrefix(c.Param("key"), "/"), payload)
+	if err == consts.FileNotFound {
+		response := util.APIResponse(err.Error(), http.StatusNotFound, "failed", nil)
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	if err == consts.FileLinkInvalid {
+		response := util.APIResponse(err.Error(), http.StatusForbidden, "failed", nil)
+		c.JSON(http.StatusForbidden, response)
+		return
+	}
+
+	if err != nil {
+		response := util.APIResponse("Failed to get file", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if res.RedirectURL != "" {
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusFound, res.RedirectURL)
+		return
+	}
+	defer res.File.Close()
+
+	// signed links are personal, so shared caches must not keep them
+	cacheControl := h.cacheControl
+	if res.Private {
+		cacheControl = "private, no-store"
+	}
+
+	c.Header("Cache-Control", cacheControl)
+	c.Header("ETag", fmt.Sprintf(`"%x-%x"`, res.Info.ModTime().UnixNano(), res.Info.Size()))
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(c.Writer, c.Request, res.Info.Name(), res.Info.ModTime(), res.File)
+}
@@ -0,0 +1,11 @@
+package file
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// This function accepts gin.Routergroup to define a group route
+func (h *handler) Router(g *gin.RouterGroup) {
+	g.GET("/*key", h.Serve)
+	g.HEAD("/*key", h.Serve)
+}
@@ -0,0 +1,89 @@
+package file
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/factory"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/storage"
+	"context"
+	"strings"
+	"time"
+)
+
+type service struct {
+	Storage         storage.Storage
+	PrivatePrefixes []string
+	SignedURLExpiry time.Duration
+}
+
+type Service interface {
+	Open(ctx context.Context, key string, reqHandler dto.PayloadFile) (*dto.FileDownload, error)
+}
+
+func NewService(f *factory.Factory) Service {
+	return &service{
+		Storage:         f.Storage,
+		PrivatePrefixes: config.StoragePrivatePrefixes(),
+		SignedURLExpiry: config.StorageSignedURLExpiry(),
+	}
+}
+
+/*
+Open checks the key and the signature of private files, then opens the file
+
+Storages that can't open files for random access, like S3, answer with a short lived
+signed link instead so the client downloads straight from the bucket
+*/
+func (s *service) Open(ctx context.Context, key string, reqHandler dto.PayloadFile) (*dto.FileDownload, error) {
+	key, err := storage.CleanKey(key)
+	if err != nil {
+		return nil, consts.FileNotFound
+	}
+
+	res := &dto.FileDownload{
+		Private: s.isPrivate(key),
+	}
+
+	if res.Private {
+		verifier, ok := s.Storage.(storage.Verifier)
+		if !ok || verifier.VerifySignature(key, reqHandler.Expires, reqHandler.Signature) != nil {
+			return nil, consts.FileLinkInvalid
+		}
+	}
+
+	opener, ok := s.Storage.(storage.Opener)
+	if !ok {
+		res.RedirectURL, err = s.Storage.SignedURL(ctx, key, s.SignedURLExpiry)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	file, err := opener.Open(ctx, key)
+	if err == storage.ErrNotFound {
+		return nil, consts.FileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res.Info, err = file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	res.File = file
+
+	return res, nil
+}
+
+func (s *service) isPrivate(key string) bool {
+	for _, prefix := range s.PrivatePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
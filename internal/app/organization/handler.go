@@ -0,0 +1,370 @@
+package organization
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/factory"
+	"clean-arch/internal/middleware"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/tracer"
+	"clean-arch/pkg/util"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type handler struct {
+	service      Service
+	authenticate gin.HandlerFunc
+	tenant       gin.HandlerFunc
+}
+
+func NewHandler(f *factory.Factory) *handler {
+	return &handler{
+		service:      NewService(f),
+		authenticate: middleware.Authenticate(f),
+		tenant:       middleware.Tenant(f),
+	}
+}
+
+func (h *handler) FindMine(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	res, err := h.service.FindMine(c, user.ID)
+	if err != nil {
+		response := util.APIResponse("Failed to get organizations", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get organizations", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Get Organizations")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Create(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var body dto.PayloadOrganization
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&body.Slug, validation.Length(3, slugMaxLength), validation.Match(slugPattern).Error("must be lowercase letters, digits and dashes")),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.Create(c, user.ID, body)
+	if err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to create organization", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully create organization", http.StatusCreated, "success", res)
+	tracer.Log(c, "info", "Create Organization")
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *handler) FindCurrent(c *gin.Context) {
+	tenant, _ := middleware.CurrentTenant(c)
+
+	res, err := h.service.FindCurrent(c, tenant)
+	if err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to get organization", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get organization", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Get Organization")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Update(c *gin.Context) {
+	tenant, _ := middleware.CurrentTenant(c)
+
+	var body dto.PayloadUpdateOrganization
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Name, validation.Required, validation.Length(1, 255)),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := h.service.Update(c, tenant, body); err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to update organization", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully update organization", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Update Organization")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Leave(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+	tenant, _ := middleware.CurrentTenant(c)
+
+	if err := h.service.Leave(c, tenant, user.ID); err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to leave organization", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully leave organization", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Leave Organization")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) FindMembers(c *gin.Context) {
+	res, err := h.service.FindMembers(c)
+	if err != nil {
+		response := util.APIResponse("Failed to get members", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get members", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Get Organization Members")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) UpdateMemberRole(c *gin.Context) {
+	tenant, _ := middleware.CurrentTenant(c)
+
+	memberID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response := util.APIResponse("Invalid member id", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	var body dto.PayloadMemberRole
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err = validation.ValidateStruct(&body,
+		validation.Field(&body.Role, validation.Required, validation.In(consts.OrgRoleOwner, consts.OrgRoleAdmin, consts.OrgRoleMember)),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := h.service.UpdateMemberRole(c, tenant, memberID, body.Role); err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to change member role", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully change member role", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Change Organization Member Role")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) RemoveMember(c *gin.Context) {
+	tenant, _ := middleware.CurrentTenant(c)
+
+	memberID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response := util.APIResponse("Invalid member id", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := h.service.RemoveMember(c, tenant, memberID); err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to remove member", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully remove member", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Remove Organization Member")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) FindInvites(c *gin.Context) {
+	res, err := h.service.FindInvites(c)
+	if err != nil {
+		response := util.APIResponse("Failed to get invites", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get invites", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Get Organization Invites")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Invite(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+	tenant, _ := middleware.CurrentTenant(c)
+
+	body := dto.PayloadOrgInvite{
+		Role: consts.OrgRoleMember,
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Email, validation.Required, validation.Length(0, 255), is.EmailFormat),
+		validation.Field(&body.Role, validation.Required, validation.In(consts.OrgRoleOwner, consts.OrgRoleAdmin, consts.OrgRoleMember)),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.Invite(c, tenant, user.ID, body)
+	if err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to invite member", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully send invite", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Invite Organization Member")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) RevokeInvite(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	inviteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response := util.APIResponse("Invalid invite id", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := h.service.RevokeInvite(c, user.ID, inviteID); err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to revoke invite", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully revoke invite", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Revoke Organization Invite")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) AcceptInvite(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	var body dto.PayloadAcceptInvite
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Token, validation.Required),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.AcceptInvite(c, user.ID, body.Token)
+	if err != nil {
+		status := errorStatus(err)
+		response := util.APIResponse("Failed to accept invite", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully join organization", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Accept Organization Invite")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) FindAudit(c *gin.Context) {
+	tenant, _ := middleware.CurrentTenant(c)
+
+	payload := dto.PayloadAuditFilter{
+		Limit: 10,
+	}
+
+	if err := c.ShouldBindQuery(&payload); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.FindAudit(c, tenant, payload)
+	if err != nil {
+		response := util.APIResponse("Failed to get audit events", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get audit events", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Get Organization Audit Events")
+	c.JSON(http.StatusOK, response)
+}
+
+// errorStatus maps the errors of the service to a response status, unknown errors are a bad request
+func errorStatus(err error) int {
+	switch err {
+	case consts.OrganizationNotFound, consts.MemberNotFound, consts.InviteNotFound:
+		return http.StatusNotFound
+	case consts.OrganizationSlugTaken, consts.MemberAlreadyExists, consts.LastOrganizationOwner:
+		return http.StatusConflict
+	case consts.OwnerRoleRequired, consts.InviteEmailMismatch:
+		return http.StatusForbidden
+	case consts.OrganizationSlugRequired:
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
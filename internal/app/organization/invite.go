@@ -0,0 +1,298 @@
+package organization
+
+import (
+	"bytes"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/crypto"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	inviteLinkDuration = time.Hour * 24 * 7
+	invitePurpose      = "org_invite"
+)
+
+// invitePayload is signed into the link, the nonce is only stored hashed so a leaked table can't accept invites
+type invitePayload struct {
+	InviteID       int    `json:"iid"`
+	OrganizationID int    `json:"org"`
+	Nonce          string `json:"nonce"`
+	Purpose        string `json:"purpose"`
+	ExpiredAt      int64  `json:"exp"`
+}
+
+/*
+Invite emails a link to join the organization of the context, inviting an email again replaces
+the pending invite so only the newest link works
+
+The invitee doesn't need an account yet, the link is accepted after signing in with the invited email
+*/
+func (s *service) Invite(ctx context.Context, tenant dto.Tenant, userID int, reqHandler dto.PayloadOrgInvite) (*dto.OrganizationInvite, error) {
+	if reqHandler.Role == consts.OrgRoleOwner && tenant.Role != consts.OrgRoleOwner {
+		return nil, consts.OwnerRoleRequired
+	}
+
+	orgID := tenant.OrganizationID
+	email := strings.ToLower(strings.TrimSpace(reqHandler.Email))
+
+	if err := s.checkNotMember(ctx, email); err != nil {
+		return nil, err
+	}
+
+	org, err := s.OrganizationRepository.FindOne(ctx, "id, name", dbutil.Where("id = ?", orgID))
+	if err != nil {
+		return nil, consts.OrganizationNotFound
+	}
+
+	inviter, err := s.UserRepository.FindOne(ctx, "id, name", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return nil, consts.NotFoundDataUser
+	}
+
+	nonce, err := util.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invite := model.OrganizationInvite{
+		Email:     email,
+		Role:      reqHandler.Role,
+		TokenHash: crypto.EncodeSHA256(nonce),
+		InvitedBy: &userID,
+		ExpiresAt: s.Clock.Now().Add(inviteLinkDuration),
+	}
+
+	pending, err := s.OrganizationRepository.FindInvite(ctx, "id, created_at", dbutil.Where("email = ?", email))
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	resend := err == nil
+
+	if resend {
+		invite.ID = pending.ID
+		invite.CreatedAt = pending.CreatedAt
+		err = s.OrganizationRepository.UpdateInvite(ctx, pending.ID, map[string]any{
+			"role":       invite.Role,
+			"token_hash": invite.TokenHash,
+			"invited_by": invite.InvitedBy,
+			"expires_at": invite.ExpiresAt,
+		})
+	} else {
+		err = s.OrganizationRepository.StoreInvite(ctx, &invite)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := crypto.SignPayload(s.SecretKey, invitePayload{
+		InviteID:       invite.ID,
+		OrganizationID: orgID,
+		Nonce:          nonce,
+		Purpose:        invitePurpose,
+		ExpiredAt:      invite.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sendInvite(org, inviter, invite, token); err != nil {
+		return nil, err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditMemberInvited,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetOrg,
+		TargetID:   &orgID,
+		Metadata:   map[string]any{"email": email, "role": invite.Role},
+	})
+
+	res := toOrganizationInvite(&invite, s.Clock.Now())
+	return &res, nil
+}
+
+func (s *service) FindInvites(ctx context.Context) ([]dto.OrganizationInvite, error) {
+	fetch, err := s.OrganizationRepository.FindAllInvite(ctx, "*", dbutil.Order("id desc"))
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.Clock.Now()
+	res := []dto.OrganizationInvite{}
+	for _, invite := range fetch {
+		res = append(res, toOrganizationInvite(invite, now))
+	}
+
+	return res, nil
+}
+
+func (s *service) RevokeInvite(ctx context.Context, userID int, inviteID int) error {
+	invite, err := s.OrganizationRepository.FindInvite(ctx, "id, organization_id, email", dbutil.Where("id = ?", inviteID))
+	if err != nil {
+		return consts.InviteNotFound
+	}
+
+	if err := s.OrganizationRepository.DeleteInvite(ctx, invite.ID); err != nil {
+		return err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditInviteRevoked,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetOrg,
+		TargetID:   &invite.OrganizationID,
+		Metadata:   map[string]any{"email": invite.Email},
+	})
+
+	return nil
+}
+
+/*
+AcceptInvite adds the signed in user to the organization of the link, the account must use the
+invited email. The organization comes from the signed link, so the queries are scoped to it
+*/
+func (s *service) AcceptInvite(ctx context.Context, userID int, token string) (*dto.Organization, error) {
+	var payload invitePayload
+
+	err := crypto.VerifyPayload(s.SecretKey, token, &payload)
+	if err != nil || payload.Purpose != invitePurpose || s.Clock.Now().Unix() > payload.ExpiredAt {
+		return nil, consts.InvalidSignedLink
+	}
+
+	ctx = dbutil.WithTenant(ctx, payload.OrganizationID)
+
+	// a resent or revoked invite changes or drops the row, so older links stop working
+	invite, err := s.OrganizationRepository.FindInvite(ctx, "*", dbutil.Where("id = ?", payload.InviteID))
+	if err != nil || invite.TokenHash != crypto.EncodeSHA256(payload.Nonce) || s.Clock.Now().After(invite.ExpiresAt) {
+		return nil, consts.InvalidSignedLink
+	}
+
+	user, err := s.UserRepository.FindOne(ctx, "id, email", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return nil, consts.NotFoundDataUser
+	}
+
+	if !strings.EqualFold(user.Email, invite.Email) {
+		return nil, consts.InviteEmailMismatch
+	}
+
+	org, err := s.OrganizationRepository.FindOne(ctx, "*", dbutil.Where("id = ?", payload.OrganizationID))
+	if err != nil {
+		return nil, consts.OrganizationNotFound
+	}
+
+	role := invite.Role
+	member, err := s.OrganizationRepository.FindMember(ctx, "id, role", dbutil.Where("user_id = ?", userID))
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	joined := err == gorm.ErrRecordNotFound
+
+	// a member who accepts a stale invite keeps the current role
+	if !joined {
+		role = member.Role
+	}
+
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		if joined {
+			if err := s.OrganizationRepository.StoreMember(ctx, &model.OrganizationMember{UserID: userID, Role: role}); err != nil {
+				return err
+			}
+		}
+
+		return s.OrganizationRepository.DeleteInvite(ctx, invite.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if joined {
+		_ = s.AuditService.Record(ctx, dto.AuditEntry{
+			Event:      consts.AuditMemberJoined,
+			ActorID:    &userID,
+			TargetType: consts.AuditTargetOrg,
+			TargetID:   &org.ID,
+			Metadata:   map[string]any{"role": role, "invite_id": invite.ID},
+		})
+	}
+
+	res := toOrganization(org, role)
+	return &res, nil
+}
+
+// checkNotMember rejects inviting an email whose account is already in the organization of the context
+func (s *service) checkNotMember(ctx context.Context, email string) error {
+	user, err := s.UserRepository.FindOne(ctx, "id", dbutil.Where("email = ?", email))
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.OrganizationRepository.FindMember(ctx, "id", dbutil.Where("user_id = ?", user.ID))
+	if err == nil {
+		return consts.MemberAlreadyExists
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) sendInvite(org model.Organization, inviter model.User, invite model.OrganizationInvite, token string) error {
+	tmpl, err := template.ParseFiles(consts.TemplateEmailOrgInvite)
+	if err != nil {
+		return fmt.Errorf("error parsing template %s", err.Error())
+	}
+
+	data := struct {
+		AppUrl       string
+		InvitedBy    string
+		Organization string
+		Role         string
+		Email        string
+		ExpiredAt    string
+		Url          string
+	}{
+		AppUrl:       util.GetEnv("APP_URL", "fallback") + ":" + util.GetEnv("APP_PORT", "fallback"),
+		InvitedBy:    inviter.Name,
+		Organization: org.Name,
+		Role:         string(invite.Role),
+		Email:        invite.Email,
+		ExpiredAt:    invite.ExpiresAt.Format(consts.TimeFormatDateTime),
+		Url:          util.GetEnv("FE_URL", "fallback") + "/org/invite/" + token,
+	}
+
+	var tplBuffer = new(bytes.Buffer)
+	if err := tmpl.Execute(tplBuffer, data); err != nil {
+		return fmt.Errorf("error executing template %s", err.Error())
+	}
+
+	go s.Mailer.Send(invite.Email, "Undangan Bergabung ke "+org.Name, tplBuffer.String())
+
+	return nil
+}
+
+func toOrganizationInvite(invite *model.OrganizationInvite, now time.Time) dto.OrganizationInvite {
+	return dto.OrganizationInvite{
+		ID:        invite.ID,
+		Email:     invite.Email,
+		Role:      invite.Role,
+		InvitedBy: invite.InvitedBy,
+		Expired:   now.After(invite.ExpiresAt),
+		ExpiresAt: invite.ExpiresAt.Format(consts.TimeFormatDateTime),
+		CreatedAt: invite.CreatedAt.Format(consts.TimeFormatDateTime),
+	}
+}
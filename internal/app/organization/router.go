@@ -0,0 +1,30 @@
+package organization
+
+import (
+	"clean-arch/internal/middleware"
+	"clean-arch/pkg/consts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// This function accepts gin.Routergroup to define a group route, the routes under /current work on the organization picked by middleware.Tenant
+func (h *handler) Router(g *gin.RouterGroup) {
+	g.Use(h.authenticate)
+	g.GET("", h.FindMine)
+	g.POST("", h.Create)
+	g.POST("/invites/accept", h.AcceptInvite)
+
+	manage := middleware.AuthorizeOrg(consts.OrgRoleOwner, consts.OrgRoleAdmin)
+
+	current := g.Group("/current", h.tenant)
+	current.GET("", h.FindCurrent)
+	current.PATCH("", manage, h.Update)
+	current.POST("/leave", h.Leave)
+	current.GET("/members", h.FindMembers)
+	current.PATCH("/members/:id", manage, h.UpdateMemberRole)
+	current.DELETE("/members/:id", manage, h.RemoveMember)
+	current.GET("/invites", manage, h.FindInvites)
+	current.POST("/invites", manage, h.Invite)
+	current.DELETE("/invites/:id", manage, h.RevokeInvite)
+	current.GET("/audit", manage, h.FindAudit)
+}
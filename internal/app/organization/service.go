@@ -0,0 +1,319 @@
+package organization
+
+import (
+	"clean-arch/internal/app/audit"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/factory"
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/clock"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/mailer"
+	"context"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const slugMaxLength = 64
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+type service struct {
+	OrganizationRepository repository.Organization
+	UserRepository         repository.User
+	AuditService           audit.Service
+	TxManager              dbutil.TxManager
+	Clock                  clock.Clock
+	Mailer                 mailer.Mailer
+	SecretKey              string
+}
+
+/*
+Service works on the organization of the context for every method but Create, FindMine and
+AcceptInvite, the handlers pass the gin context that middleware.Tenant filled
+*/
+type Service interface {
+	Create(ctx context.Context, userID int, reqHandler dto.PayloadOrganization) (*dto.Organization, error)
+	FindMine(ctx context.Context, userID int) ([]dto.Organization, error)
+	FindCurrent(ctx context.Context, tenant dto.Tenant) (*dto.Organization, error)
+	Update(ctx context.Context, tenant dto.Tenant, reqHandler dto.PayloadUpdateOrganization) error
+	FindMembers(ctx context.Context) ([]dto.OrganizationMember, error)
+	UpdateMemberRole(ctx context.Context, tenant dto.Tenant, memberID int, role consts.OrgRole) error
+	RemoveMember(ctx context.Context, tenant dto.Tenant, memberID int) error
+	Leave(ctx context.Context, tenant dto.Tenant, userID int) error
+	Invite(ctx context.Context, tenant dto.Tenant, userID int, reqHandler dto.PayloadOrgInvite) (*dto.OrganizationInvite, error)
+	FindInvites(ctx context.Context) ([]dto.OrganizationInvite, error)
+	RevokeInvite(ctx context.Context, userID int, inviteID int) error
+	AcceptInvite(ctx context.Context, userID int, token string) (*dto.Organization, error)
+	FindAudit(ctx context.Context, tenant dto.Tenant, reqHandler dto.PayloadAuditFilter) (*dto.ResponseAuditEvent, error)
+}
+
+func NewService(f *factory.Factory) Service {
+	return &service{
+		OrganizationRepository: f.OrganizationRepository,
+		UserRepository:         f.UserRepository,
+		AuditService:           audit.NewService(f),
+		TxManager:              f.TxManager,
+		Clock:                  f.Clock,
+		Mailer:                 f.Mailer,
+		SecretKey:              f.SecretKey,
+	}
+}
+
+// Create adds the organization with the user as its first owner
+func (s *service) Create(ctx context.Context, userID int, reqHandler dto.PayloadOrganization) (*dto.Organization, error) {
+	slug := reqHandler.Slug
+	if slug == "" {
+		slug = slugify(reqHandler.Name)
+	}
+	if slug == "" {
+		return nil, consts.OrganizationSlugRequired
+	}
+
+	_, err := s.OrganizationRepository.FindOne(ctx, "id", dbutil.Where("slug = ?", slug))
+	if err == nil {
+		return nil, consts.OrganizationSlugTaken
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	org := model.Organization{
+		Name:      strings.TrimSpace(reqHandler.Name),
+		Slug:      slug,
+		CreatedBy: &userID,
+	}
+
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.OrganizationRepository.Store(ctx, &org); err != nil {
+			return err
+		}
+
+		// the organization only exists inside the transaction, so its id becomes the tenant there
+		owner := model.OrganizationMember{
+			UserID: userID,
+			Role:   consts.OrgRoleOwner,
+		}
+		return s.OrganizationRepository.StoreMember(dbutil.WithTenant(ctx, org.ID), &owner)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditOrgCreated,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetOrg,
+		TargetID:   &org.ID,
+		Metadata:   map[string]any{"slug": org.Slug},
+	})
+
+	res := toOrganization(org, consts.OrgRoleOwner)
+	return &res, nil
+}
+
+func (s *service) FindMine(ctx context.Context, userID int) ([]dto.Organization, error) {
+	fetch, err := s.OrganizationRepository.FindMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []dto.Organization{}
+	for _, member := range fetch {
+		if member.Organization == nil {
+			continue
+		}
+		res = append(res, toOrganization(*member.Organization, member.Role))
+	}
+
+	return res, nil
+}
+
+func (s *service) FindCurrent(ctx context.Context, tenant dto.Tenant) (*dto.Organization, error) {
+	org, err := s.OrganizationRepository.FindOne(ctx, "*", dbutil.Where("id = ?", tenant.OrganizationID))
+	if err != nil {
+		return nil, consts.OrganizationNotFound
+	}
+
+	res := toOrganization(org, tenant.Role)
+	return &res, nil
+}
+
+func (s *service) Update(ctx context.Context, tenant dto.Tenant, reqHandler dto.PayloadUpdateOrganization) error {
+	err := s.OrganizationRepository.UpdateColumns(ctx, tenant.OrganizationID, map[string]any{"name": strings.TrimSpace(reqHandler.Name)})
+	if err != nil {
+		return err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditOrgUpdated,
+		TargetType: consts.AuditTargetOrg,
+		TargetID:   &tenant.OrganizationID,
+		Metadata:   map[string]any{"fields": []string{"name"}},
+	})
+
+	return nil
+}
+
+func (s *service) FindMembers(ctx context.Context) ([]dto.OrganizationMember, error) {
+	fetch, err := s.OrganizationRepository.FindAllMember(ctx, "*",
+		dbutil.Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, name, email")
+		}),
+		dbutil.Order("id asc"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []dto.OrganizationMember{}
+	for _, member := range fetch {
+		res = append(res, toOrganizationMember(member))
+	}
+
+	return res, nil
+}
+
+// UpdateMemberRole changes the role of a member, only an owner can make or unmake an owner
+func (s *service) UpdateMemberRole(ctx context.Context, tenant dto.Tenant, memberID int, role consts.OrgRole) error {
+	member, err := s.OrganizationRepository.FindMember(ctx, "id, user_id, role", dbutil.Where("id = ?", memberID))
+	if err != nil {
+		return consts.MemberNotFound
+	}
+
+	if member.Role == role {
+		return nil
+	}
+
+	if err := s.checkOwnerChange(ctx, tenant, member, role); err != nil {
+		return err
+	}
+
+	if err := s.OrganizationRepository.UpdateMember(ctx, member.ID, map[string]any{"role": role}); err != nil {
+		return err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditMemberRole,
+		TargetType: consts.AuditTargetOrg,
+		TargetID:   &tenant.OrganizationID,
+		Metadata:   map[string]any{"user_id": member.UserID, "from": member.Role, "to": role},
+	})
+
+	return nil
+}
+
+func (s *service) RemoveMember(ctx context.Context, tenant dto.Tenant, memberID int) error {
+	member, err := s.OrganizationRepository.FindMember(ctx, "id, user_id, role", dbutil.Where("id = ?", memberID))
+	if err != nil {
+		return consts.MemberNotFound
+	}
+
+	if err := s.checkOwnerChange(ctx, tenant, member, ""); err != nil {
+		return err
+	}
+
+	return s.removeMember(ctx, tenant, member, "removed")
+}
+
+// Leave removes the user from the organization, the last owner has to hand over the organization first
+func (s *service) Leave(ctx context.Context, tenant dto.Tenant, userID int) error {
+	member, err := s.OrganizationRepository.FindMember(ctx, "id, user_id, role", dbutil.Where("user_id = ?", userID))
+	if err != nil {
+		return consts.MemberNotFound
+	}
+
+	if err := s.checkLastOwner(ctx, member); err != nil {
+		return err
+	}
+
+	return s.removeMember(ctx, tenant, member, "left")
+}
+
+func (s *service) removeMember(ctx context.Context, tenant dto.Tenant, member model.OrganizationMember, reason string) error {
+	if err := s.OrganizationRepository.DeleteMember(ctx, member.ID); err != nil {
+		return err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditMemberRemoved,
+		TargetType: consts.AuditTargetOrg,
+		TargetID:   &tenant.OrganizationID,
+		Metadata:   map[string]any{"user_id": member.UserID, "reason": reason},
+	})
+
+	return nil
+}
+
+// checkOwnerChange guards the owners, role is the new role of the member or empty when the member is removed
+func (s *service) checkOwnerChange(ctx context.Context, tenant dto.Tenant, member model.OrganizationMember, role consts.OrgRole) error {
+	if (member.Role == consts.OrgRoleOwner || role == consts.OrgRoleOwner) && tenant.Role != consts.OrgRoleOwner {
+		return consts.OwnerRoleRequired
+	}
+
+	return s.checkLastOwner(ctx, member)
+}
+
+func (s *service) checkLastOwner(ctx context.Context, member model.OrganizationMember) error {
+	if member.Role != consts.OrgRoleOwner {
+		return nil
+	}
+
+	owners, err := s.OrganizationRepository.CountMember(ctx, dbutil.Where("role = ?", consts.OrgRoleOwner))
+	if err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return consts.LastOrganizationOwner
+	}
+
+	return nil
+}
+
+// FindAudit lists the audit events of the organization, the target filter is always the tenant so other organizations stay hidden
+func (s *service) FindAudit(ctx context.Context, tenant dto.Tenant, reqHandler dto.PayloadAuditFilter) (*dto.ResponseAuditEvent, error) {
+	reqHandler.TargetType = consts.AuditTargetOrg
+	reqHandler.TargetID = tenant.OrganizationID
+
+	return s.AuditService.FindAll(ctx, reqHandler)
+}
+
+// slugify lowercases the name and joins its words with dashes, it is used when no slug is sent
+func slugify(name string) string {
+	slug := strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > slugMaxLength {
+		slug = strings.TrimRight(slug[:slugMaxLength], "-")
+	}
+	return slug
+}
+
+func toOrganization(org model.Organization, role consts.OrgRole) dto.Organization {
+	return dto.Organization{
+		ID:        org.ID,
+		Name:      org.Name,
+		Slug:      org.Slug,
+		Role:      role,
+		CreatedAt: org.CreatedAt.Format(consts.TimeFormatDateTime),
+	}
+}
+
+func toOrganizationMember(member *model.OrganizationMember) dto.OrganizationMember {
+	res := dto.OrganizationMember{
+		ID:       member.ID,
+		UserID:   member.UserID,
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt.Format(consts.TimeFormatDateTime),
+	}
+
+	// deleted users aren't preloaded, the membership stays until the user is purged or erased
+	if member.User != nil {
+		res.Name = member.User.Name
+		res.Email = member.User.Email
+	}
+
+	return res
+}
@@ -0,0 +1,111 @@
+package user
+
+import (
+	"bytes"
+	"clean-arch/pkg/imaging"
+	"clean-arch/pkg/storage"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"regexp"
+	"strconv"
+)
+
+const (
+	avatarPrefix  = "avatars"
+	avatarMaxSize = 5 * 1024 * 1024
+)
+
+// avatarSizes are the square variants made of every avatar, the largest one is the key kept on the user
+var avatarSizes = []int{64, 256, 512}
+
+var avatarOptions = imaging.Options{
+	MaxPixels: 5000 * 5000,
+	Sizes:     avatarSizes,
+	Quality:   85,
+}
+
+// avatarKeyPattern matches the keys made by putAvatar, older uploads are single files without variants
+var avatarKeyPattern = regexp.MustCompile(`^(` + avatarPrefix + `/[^/]+)/\d+\.jpg$`)
+
+// putAvatar resizes the avatar into every size, uploads them and returns the key of the largest one
+func (s *service) putAvatar(ctx context.Context, file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, avatarMaxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > avatarMaxSize {
+		return "", fmt.Errorf("file size to large, max size allowed is 5Mb")
+	}
+
+	variants, err := imaging.Process(data, avatarOptions)
+	if err != nil {
+		return "", err
+	}
+
+	base, err := storage.NewKey(avatarPrefix, "", s.Clock.Now())
+	if err != nil {
+		return "", err
+	}
+
+	key := ""
+	for _, variant := range variants {
+		key = avatarVariantKey(base, variant.Size)
+		if err := s.Storage.Put(ctx, key, bytes.NewReader(variant.Data), "image/jpeg"); err != nil {
+			s.deleteAvatar(ctx, avatarVariantKey(base, avatarSizes[len(avatarSizes)-1]))
+			return "", err
+		}
+	}
+
+	return key, nil
+}
+
+// avatarURLs returns the link of every size keyed by the size, nil for avatars uploaded before variants existed
+func (s *service) avatarURLs(key string) map[string]string {
+	m := avatarKeyPattern.FindStringSubmatch(key)
+	if m == nil {
+		return nil
+	}
+
+	urls := make(map[string]string, len(avatarSizes))
+	for _, size := range avatarSizes {
+		urls[strconv.Itoa(size)] = s.Storage.URL(avatarVariantKey(m[1], size))
+	}
+	return urls
+}
+
+// deleteAvatar removes every size of an avatar
+func (s *service) deleteAvatar(ctx context.Context, key string) {
+	m := avatarKeyPattern.FindStringSubmatch(key)
+	if m == nil {
+		s.deleteFile(ctx, key)
+		return
+	}
+
+	for _, size := range avatarSizes {
+		s.deleteFile(ctx, avatarVariantKey(m[1], size))
+	}
+}
+
+// deleteFile removes a stored file, failures are only logged since the row no longer points at it
+func (s *service) deleteFile(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+
+	if err := s.Storage.Delete(ctx, key); err != nil {
+		log.Println("Error deleting file:", err)
+	}
+}
+
+func avatarVariantKey(base string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", base, size)
+}
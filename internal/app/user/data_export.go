@@ -0,0 +1,316 @@
+package user
+
+import (
+	"archive/zip"
+	"clean-arch/internal/app/audit"
+	"clean-arch/internal/dto"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const dataExportFormat = "zip"
+
+// dataExportOtp leaves out the code, a used or expired code is of no use to the user
+type dataExportOtp struct {
+	Attempt   int    `json:"attempt"`
+	ExpiredAt string `json:"expired_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+// dataExportFile is one JSON file of the archive, load returns its content and how many records it has
+type dataExportFile struct {
+	name string
+	load func(ctx context.Context, userID int) (any, int, error)
+}
+
+/*
+QueueDataExport collects the personal data of the user into a ZIP of JSON files in the background,
+the job can only be seen and downloaded by the user and expires like the user exports
+*/
+func (s *service) QueueDataExport(ctx context.Context, userID int) (*dto.ExportJob, error) {
+	id, err := newExportID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.Clock.Now()
+	ttl := config.UserExportTTL()
+
+	job := &dto.ExportJob{
+		ID:        id,
+		Status:    exportStatusPending,
+		Format:    dataExportFormat,
+		FileName:  fmt.Sprintf("my-data-%s.zip", now.Format("20060102150405")),
+		CreatedBy: userID,
+		CreatedAt: now.Format(consts.TimeFormatDateTime),
+		ExpiresAt: now.Add(ttl).Format(consts.TimeFormatDateTime),
+	}
+
+	if err := s.RedisRepository.Set(ctx, dataExportKey(id), job, ttl); err != nil {
+		return nil, err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditDataExported,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &userID,
+		Metadata:   map[string]any{"job_id": id},
+	})
+
+	go s.runDataExport(context.Background(), *job, ttl)
+
+	return job, nil
+}
+
+func (s *service) FindDataExport(ctx context.Context, userID int, id string) (*dto.ExportJob, error) {
+	cached, err := s.RedisRepository.Get(ctx, dataExportKey(id))
+	if err == redis.Nil {
+		return nil, consts.ExportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job dto.ExportJob
+	if err := json.Unmarshal([]byte(cached), &job); err != nil {
+		return nil, err
+	}
+
+	if job.CreatedBy != userID {
+		return nil, consts.ExportJobNotFound
+	}
+
+	if job.Status == exportStatusDone {
+		job.DownloadURL = fmt.Sprintf("%s:%s/api/v1/me/data-export/%s/download", util.GetEnv("APP_URL", "http://localhost"), util.GetEnv("APP_PORT", "8080"), job.ID)
+	}
+
+	return &job, nil
+}
+
+// DataExportFile returns the path of a finished data export of the user
+func (s *service) DataExportFile(ctx context.Context, userID int, id string) (string, *dto.ExportJob, error) {
+	job, err := s.FindDataExport(ctx, userID, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if job.Status != exportStatusDone {
+		return "", job, consts.ExportNotReady
+	}
+
+	return dataExportPath(userID, job.ID), job, nil
+}
+
+func (s *service) runDataExport(ctx context.Context, job dto.ExportJob, ttl time.Duration) {
+	removeExpiredExports(ttl)
+
+	total, err := s.writeDataExport(ctx, job)
+	if err != nil {
+		log.Println("Error exporting personal data:", err)
+		job.Status = exportStatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = exportStatusDone
+		job.TotalRow = total
+	}
+
+	if err := s.RedisRepository.Set(ctx, dataExportKey(job.ID), job, ttl); err != nil {
+		log.Println("Error saving data export job:", err)
+	}
+}
+
+func (s *service) writeDataExport(ctx context.Context, job dto.ExportJob) (int, error) {
+	if err := os.MkdirAll(exportDir, os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	path := dataExportPath(job.CreatedBy, job.ID)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := s.writeDataArchive(ctx, job.CreatedBy, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(path)
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// writeDataArchive writes every file of dataExportFiles into a zip and returns the number of records
+func (s *service) writeDataArchive(ctx context.Context, userID int, w io.Writer) (int, error) {
+	archive := zip.NewWriter(w)
+
+	total := 0
+	for _, entry := range s.dataExportFiles() {
+		data, count, err := entry.load(ctx, userID)
+		if err != nil {
+			archive.Close()
+			return total, fmt.Errorf("error loading %s %s", entry.name, err.Error())
+		}
+
+		entryWriter, err := archive.Create(entry.name)
+		if err != nil {
+			archive.Close()
+			return total, err
+		}
+
+		encoder := json.NewEncoder(entryWriter)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(data); err != nil {
+			archive.Close()
+			return total, err
+		}
+
+		total += count
+	}
+
+	return total, archive.Close()
+}
+
+func (s *service) dataExportFiles() []dataExportFile {
+	return []dataExportFile{
+		{name: "profile.json", load: s.loadProfile},
+		{name: "sessions.json", load: s.loadSessions},
+		{name: "login_logs.json", load: s.loadLoginLogs},
+		{name: "otps.json", load: s.loadOtps},
+		{name: "audit_events.json", load: s.loadAuditEvents},
+	}
+}
+
+func (s *service) loadProfile(ctx context.Context, userID int) (any, int, error) {
+	user, err := s.UserRepository.FindOne(ctx, "*", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	preferences := user.Preferences.Resolve()
+
+	res := s.toUser(&user)
+	res.Preferences = &preferences
+	res.Attributes = user.Attributes
+
+	return res, 1, nil
+}
+
+// loadSessions leaves out the refresh token hash, it is a credential and not data about the user
+func (s *service) loadSessions(ctx context.Context, userID int) (any, int, error) {
+	fetch, err := s.UserRepository.FindAllSession(ctx, "*", dbutil.Where("user_id = ?", userID), dbutil.Order("created_at asc"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res := []dto.UserSession{}
+	for _, session := range fetch {
+		res = append(res, dto.UserSession{
+			ID:        session.ID,
+			UserID:    session.UserID,
+			IPAddress: session.IPAddress,
+			UserAgent: session.UserAgent,
+			Browser:   session.Browser,
+			OS:        session.OS,
+			Device:    session.Device,
+			Country:   session.Country,
+			Region:    session.Region,
+			City:      session.City,
+			CreatedAt: session.CreatedAt.Format(consts.TimeFormatDateTime),
+			ExpiresAt: session.ExpiresAt.Format(consts.TimeFormatDateTime),
+			Revoked:   session.Revoked,
+		})
+	}
+
+	return res, len(res), nil
+}
+
+func (s *service) loadLoginLogs(ctx context.Context, userID int) (any, int, error) {
+	fetch, err := s.UserRepository.FindAllLoginLog(ctx, "*", dbutil.Where("user_id = ?", userID), dbutil.Order("created_at asc"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res := []dto.LoginLog{}
+	for _, loginLog := range fetch {
+		res = append(res, toLoginLog(loginLog))
+	}
+
+	return res, len(res), nil
+}
+
+func (s *service) loadOtps(ctx context.Context, userID int) (any, int, error) {
+	fetch, err := s.OtpRepository.FindAll(ctx, "attempt, expired_at, created_at", dbutil.Where("user_id = ?", userID), dbutil.Order("created_at asc"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res := []dataExportOtp{}
+	for _, otp := range fetch {
+		res = append(res, dataExportOtp{
+			Attempt:   otp.Attempt,
+			ExpiredAt: otp.ExpiredAt.Format(consts.TimeFormatDateTime),
+			CreatedAt: otp.CreatedAt.Format(consts.TimeFormatDateTime),
+		})
+	}
+
+	return res, len(res), nil
+}
+
+// loadAuditEvents returns the events done by the user and the ones done to the account
+func (s *service) loadAuditEvents(ctx context.Context, userID int) (any, int, error) {
+	fetch, err := s.AuditRepository.FindAll(ctx, "*",
+		dbutil.Where("actor_id = ? OR (target_type = ? AND target_id = ?)", userID, consts.AuditTargetUser, userID),
+		dbutil.Order("created_at asc, id asc"),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res := []dto.AuditEvent{}
+	for _, event := range fetch {
+		res = append(res, audit.ToAuditEvent(event))
+	}
+
+	return res, len(res), nil
+}
+
+// removeDataExports deletes every data export file of the user, used once the account is erased
+func removeDataExports(userID int) {
+	paths, err := filepath.Glob(filepath.Join(exportDir, fmt.Sprintf("data-%d-*.%s", userID, dataExportFormat)))
+	if err != nil {
+		return
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			log.Println("Error removing data export:", err)
+		}
+	}
+}
+
+func dataExportKey(id string) string {
+	return fmt.Sprintf("data_export-%s", id)
+}
+
+// dataExportPath starts with the user id so the files of an erased account can be found
+func dataExportPath(userID int, id string) string {
+	return filepath.Join(exportDir, fmt.Sprintf("data-%d-%s.%s", userID, id, dataExportFormat))
+}
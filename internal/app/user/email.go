@@ -0,0 +1,251 @@
+package user
+
+import (
+	"bytes"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/crypto"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	emailChangeLinkDuration = time.Hour * 24
+	emailChangePurpose      = "email_change"
+)
+
+// emailChangeRequest is the pending change kept in redis, only the latest request of a user can be confirmed
+type emailChangeRequest struct {
+	Email     string `json:"email"`
+	Nonce     string `json:"nonce"`
+	SessionID int    `json:"session_id"`
+}
+
+type emailChangePayload struct {
+	UserID    int    `json:"uid"`
+	Email     string `json:"email"`
+	Nonce     string `json:"nonce"`
+	Purpose   string `json:"purpose"`
+	ExpiredAt int64  `json:"exp"`
+}
+
+func emailChangeKey(userID int) string {
+	return fmt.Sprintf("email_change-%d", userID)
+}
+
+/*
+RequestEmailChange sends a confirmation link to the new address and a notice to the current one
+
+The email of the account doesn't change until the link is opened, sessionID is the session
+that asked for the change, it is the only one kept once the change is confirmed
+*/
+func (s *service) RequestEmailChange(ctx context.Context, userID int, sessionID int, reqHandler dto.PayloadChangeEmail) error {
+	user, err := s.UserRepository.FindOne(ctx, "id, name, email, password", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	match, err := util.VerifyPassword(reqHandler.Password, user.Password)
+	if err != nil || !match {
+		return consts.InvalidPassword
+	}
+
+	// emails are stored lower-cased like the import does, the unique index doesn't ignore the case
+	email := strings.ToLower(strings.TrimSpace(reqHandler.Email))
+	if strings.EqualFold(email, user.Email) {
+		return consts.EmailSameCurrent
+	}
+
+	if err := s.checkEmailAvailable(ctx, userID, email); err != nil {
+		return err
+	}
+
+	nonce, err := util.GenerateRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	expiredAt := s.Clock.Now().Add(emailChangeLinkDuration)
+	token, err := crypto.SignPayload(s.SecretKey, emailChangePayload{
+		UserID:    userID,
+		Email:     email,
+		Nonce:     nonce,
+		Purpose:   emailChangePurpose,
+		ExpiredAt: expiredAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	pending := emailChangeRequest{
+		Email:     email,
+		Nonce:     nonce,
+		SessionID: sessionID,
+	}
+	if err := s.RedisRepository.Set(ctx, emailChangeKey(userID), pending, emailChangeLinkDuration); err != nil {
+		return err
+	}
+
+	if err := s.sendEmailChange(user, email, token, expiredAt); err != nil {
+		return err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditEmailRequested,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &userID,
+		Metadata:   map[string]any{"from": user.Email, "to": email},
+	})
+
+	return nil
+}
+
+// ConfirmEmailChange applies the change from the link sent to the new address and signs out the other sessions
+func (s *service) ConfirmEmailChange(ctx context.Context, token string) error {
+	var payload emailChangePayload
+
+	err := crypto.VerifyPayload(s.SecretKey, token, &payload)
+	if err != nil || payload.Purpose != emailChangePurpose || s.Clock.Now().Unix() > payload.ExpiredAt {
+		return consts.InvalidSignedLink
+	}
+
+	pending, err := s.findEmailChange(ctx, payload.UserID)
+	if err != nil {
+		return err
+	}
+
+	// a newer request or a confirmed one replaces the pending change, so older links stop working
+	if pending == nil || pending.Nonce != payload.Nonce || pending.Email != payload.Email {
+		return consts.InvalidSignedLink
+	}
+
+	user, err := s.UserRepository.FindOne(ctx, "id, email", dbutil.Where("id = ?", payload.UserID))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	// the address could have been taken since the request
+	if err := s.checkEmailAvailable(ctx, user.ID, payload.Email); err != nil {
+		return err
+	}
+
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.UserRepository.UpdateColumns(ctx, user.ID, map[string]any{
+			"email":             payload.Email,
+			"email_verified_at": s.Clock.Now(),
+		})
+		if err != nil {
+			return consts.FailedUpdateUser
+		}
+
+		if pending.SessionID > 0 {
+			err = s.UserRepository.RevokeOtherSessions(ctx, user.ID, pending.SessionID)
+		} else {
+			err = s.UserRepository.RevokeUserSessions(ctx, user.ID)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = s.RedisRepository.Del(ctx, emailChangeKey(user.ID))
+	// the cached session data holds the email
+	_ = s.RedisRepository.Del(ctx, fmt.Sprintf("user_session-%d", user.ID))
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditEmailChanged,
+		ActorID:    &user.ID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &user.ID,
+		Metadata:   map[string]any{"from": user.Email, "to": payload.Email},
+	})
+
+	return nil
+}
+
+// checkEmailAvailable ignores the case and also looks at deleted users, they keep their email until purged
+func (s *service) checkEmailAvailable(ctx context.Context, userID int, email string) error {
+	_, err := s.UserRepository.FindOne(ctx, "id", dbutil.Where("LOWER(email) = ? AND id <> ?", strings.ToLower(email), userID), dbutil.WithDeleted())
+	if err == nil {
+		return consts.EmailAlreadyExists
+	}
+
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) findEmailChange(ctx context.Context, userID int) (*emailChangeRequest, error) {
+	cached, err := s.RedisRepository.Get(ctx, emailChangeKey(userID))
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pending emailChangeRequest
+	if err := json.Unmarshal([]byte(cached), &pending); err != nil {
+		return nil, err
+	}
+
+	return &pending, nil
+}
+
+func (s *service) sendEmailChange(user model.User, email string, token string, expiredAt time.Time) error {
+	verifyTmpl, err := template.ParseFiles(consts.TemplateEmailChangeVerify)
+	if err != nil {
+		return fmt.Errorf("error parsing template %s", err.Error())
+	}
+
+	noticeTmpl, err := template.ParseFiles(consts.TemplateEmailChangeNotice)
+	if err != nil {
+		return fmt.Errorf("error parsing template %s", err.Error())
+	}
+
+	data := struct {
+		AppUrl    string
+		Name      string
+		Email     string
+		Time      string
+		ExpiredAt string
+		Url       string
+	}{
+		AppUrl:    util.GetEnv("APP_URL", "fallback") + ":" + util.GetEnv("APP_PORT", "fallback"),
+		Name:      user.Name,
+		Email:     email,
+		Time:      s.Clock.Now().Format(consts.TimeFormatDateTime),
+		ExpiredAt: expiredAt.Format(consts.TimeFormatDateTime),
+		Url:       util.GetEnv("FE_URL", "fallback") + "/auth/confirm-email/" + token,
+	}
+
+	var verifyBuffer = new(bytes.Buffer)
+	if err := verifyTmpl.Execute(verifyBuffer, data); err != nil {
+		return fmt.Errorf("error executing template %s", err.Error())
+	}
+
+	var noticeBuffer = new(bytes.Buffer)
+	if err := noticeTmpl.Execute(noticeBuffer, data); err != nil {
+		return fmt.Errorf("error executing template %s", err.Error())
+	}
+
+	appName := util.GetEnv("APP_NAME", "fallback")
+	go s.Mailer.Send(email, "Konfirmasi Email Baru "+appName, verifyBuffer.String())
+	go s.Mailer.Send(user.Email, "Permintaan Ganti Email "+appName, noticeBuffer.String())
+
+	return nil
+}
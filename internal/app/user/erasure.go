@@ -0,0 +1,195 @@
+package user
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/factory"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/crypto"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const erasureBatchSize = 100
+
+/*
+RequestErasure schedules the erasure of the account after USER_ERASURE_GRACE_DAYS, the account is
+deactivated and signed out right away, signing in again during the grace period cancels the request
+*/
+func (s *service) RequestErasure(ctx context.Context, userID int, reqHandler dto.PayloadErasure) (*dto.ResponseErasure, error) {
+	user, err := s.UserRepository.FindOne(ctx, "id, password, status, erase_after", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return nil, consts.NotFoundDataUser
+	}
+
+	match, err := util.VerifyPassword(reqHandler.Password, user.Password)
+	if err != nil || !match {
+		return nil, consts.InvalidPassword
+	}
+
+	if user.EraseAfter != nil {
+		return &dto.ResponseErasure{EraseAfter: user.EraseAfter.Format(consts.TimeFormatDateTime)}, nil
+	}
+
+	now := s.Clock.Now()
+	eraseAfter := now.Add(config.UserErasureGrace())
+
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.UserRepository.UpdateColumns(ctx, userID, map[string]any{
+			"erasure_requested_at": now,
+			"erase_after":          eraseAfter,
+			"status":               consts.UserStatusDeactivated,
+			"status_reason":        "erasure_requested",
+			"suspended_until":      nil,
+			"status_changed_by":    userID,
+			"status_changed_at":    now,
+		})
+		if err != nil {
+			return consts.FailedUpdateUser
+		}
+
+		return s.UserRepository.RevokeUserSessions(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.RedisRepository.Del(ctx, fmt.Sprintf("user_session-%d", userID))
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditErasureRequest,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &userID,
+		Metadata: map[string]any{
+			"from":        user.Status,
+			"reason":      reqHandler.Reason,
+			"erase_after": eraseAfter.Format(consts.TimeFormatDateTime),
+		},
+	})
+
+	return &dto.ResponseErasure{EraseAfter: eraseAfter.Format(consts.TimeFormatDateTime)}, nil
+}
+
+// Erase erases every account whose grace period ended before now, soft deleted accounts included
+func (s *service) Erase(ctx context.Context, now time.Time) (int, error) {
+	erased := 0
+
+	for {
+		fetch, err := s.UserRepository.FindAll(ctx, "id, email, profile_image_url, erasure_requested_at", dbutil.WithDeleted(), dbutil.Where("erase_after <= ?", now), dbutil.Order("id asc"), dbutil.Limit(erasureBatchSize))
+		if err != nil {
+			return erased, err
+		}
+
+		for _, user := range fetch {
+			if err := s.eraseOne(ctx, user); err != nil {
+				return erased, err
+			}
+			erased++
+		}
+
+		if len(fetch) < erasureBatchSize {
+			return erased, nil
+		}
+	}
+}
+
+/*
+eraseOne removes the account with its sessions, otps and files, the login logs and audit events are kept
+for the statistics and the trail but anonymized, the receipt only keeps a hash of the email
+*/
+func (s *service) eraseOne(ctx context.Context, user *model.User) error {
+	now := s.Clock.Now()
+
+	summary, err := json.Marshal(map[string]any{
+		"user":         "deleted",
+		"sessions":     "deleted",
+		"otps":         "deleted",
+		"avatar":       user.ProfileImageURL != "",
+		"login_logs":   "anonymized",
+		"audit_events": "anonymized",
+	})
+	if err != nil {
+		return err
+	}
+
+	receipt := model.ErasureReceipt{
+		UserID:      user.ID,
+		EmailHash:   crypto.EncodeSHA256(strings.ToLower(user.Email)),
+		RequestedAt: user.EraseRequestAt,
+		ErasedAt:    &now,
+		Summary:     string(summary),
+	}
+
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.UserRepository.AnonymizeLoginLogs(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if err := s.AuditRepository.Anonymize(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if err := s.OrganizationRepository.RemoveUser(ctx, user.ID); err != nil {
+			return err
+		}
+
+		if err := s.UserRepository.ForceDelete(ctx, user.ID); err != nil {
+			return err
+		}
+
+		return s.UserRepository.StoreErasureReceipt(ctx, &receipt)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deleteAvatar(ctx, user.ProfileImageURL)
+	removeDataExports(user.ID)
+
+	_ = s.RedisRepository.Del(ctx, fmt.Sprintf("user_session-%d", user.ID))
+	_ = s.RedisRepository.Del(ctx, emailChangeKey(user.ID))
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserErased,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &user.ID,
+		Metadata:   map[string]any{"receipt_id": receipt.ID},
+	})
+
+	return nil
+}
+
+// StartErasureJob erases the accounts whose grace period is over on every interval until ctx is done
+func StartErasureJob(ctx context.Context, f *factory.Factory, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s := NewService(f)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		erased, err := s.Erase(ctx, f.Clock.Now())
+		if err != nil {
+			log.Println("Error erasing accounts:", err)
+		} else if erased > 0 {
+			log.Printf("Erased %d accounts\n", erased)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package user
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/sheet"
+	"clean-arch/pkg/util"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	exportBatchSize = 500
+	exportDir       = "storage/exports"
+
+	exportStatusPending = "pending"
+	exportStatusDone    = "done"
+	exportStatusFailed  = "failed"
+)
+
+// exportColumns are written when the client doesn't pick fields
+var exportColumns = []string{"id", "name", "email", "phone_number", "status", "status_reason", "suspended_until", "email_verified_at", "profile_image_url", "created_at", "updated_at"}
+
+/*
+QueueExport starts a background export when the result has more rows than USER_EXPORT_ASYNC_ROWS
+and returns its job, a nil job means the result is small enough to be streamed with Export
+*/
+func (s *service) QueueExport(ctx context.Context, actorID int, reqHandler dto.PayloadExportUser, query dbutil.ParsedQuery) (*dto.ExportJob, error) {
+	count, err := s.UserRepository.Count(ctx, query.Where...)
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= config.UserExportAsyncRows() {
+		return nil, nil
+	}
+
+	id, err := newExportID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.Clock.Now()
+	ttl := config.UserExportTTL()
+
+	job := &dto.ExportJob{
+		ID:        id,
+		Status:    exportStatusPending,
+		Format:    reqHandler.Format,
+		TotalRow:  count,
+		FileName:  exportFileName(reqHandler.Format, now),
+		CreatedBy: actorID,
+		CreatedAt: now.Format(consts.TimeFormatDateTime),
+		ExpiresAt: now.Add(ttl).Format(consts.TimeFormatDateTime),
+	}
+
+	if err := s.RedisRepository.Set(ctx, exportKey(id), job, ttl); err != nil {
+		return nil, err
+	}
+
+	s.recordExport(ctx, reqHandler, count, job.ID)
+
+	// the request context is done once the response is sent, the job runs on its own
+	go s.runExport(context.Background(), *job, reqHandler, query, ttl)
+
+	return job, nil
+}
+
+// Export streams every matching user into w, rows are fetched in keyset batches so memory stays flat
+func (s *service) Export(ctx context.Context, reqHandler dto.PayloadExportUser, query dbutil.ParsedQuery, w io.Writer) error {
+	rows, err := s.writeExport(ctx, reqHandler, query, w)
+	if err != nil {
+		return err
+	}
+
+	s.recordExport(ctx, reqHandler, rows, "")
+	return nil
+}
+
+func (s *service) FindExport(ctx context.Context, actorID int, id string) (*dto.ExportJob, error) {
+	cached, err := s.RedisRepository.Get(ctx, exportKey(id))
+	if err == redis.Nil {
+		return nil, consts.ExportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job dto.ExportJob
+	if err := json.Unmarshal([]byte(cached), &job); err != nil {
+		return nil, err
+	}
+
+	// an export holds the data of every user, so only the admin who started it can see it
+	if job.CreatedBy != actorID {
+		return nil, consts.ExportJobNotFound
+	}
+
+	if job.Status == exportStatusDone {
+		job.DownloadURL = fmt.Sprintf("%s:%s/api/v1/user/export/%s/download", util.GetEnv("APP_URL", "http://localhost"), util.GetEnv("APP_PORT", "8080"), job.ID)
+	}
+
+	return &job, nil
+}
+
+// ExportFile returns the path of a finished background export
+func (s *service) ExportFile(ctx context.Context, actorID int, id string) (string, *dto.ExportJob, error) {
+	job, err := s.FindExport(ctx, actorID, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if job.Status != exportStatusDone {
+		return "", job, consts.ExportNotReady
+	}
+
+	return exportPath(job.ID, job.Format), job, nil
+}
+
+func (s *service) runExport(ctx context.Context, job dto.ExportJob, reqHandler dto.PayloadExportUser, query dbutil.ParsedQuery, ttl time.Duration) {
+	removeExpiredExports(ttl)
+
+	err := s.writeExportFile(ctx, job, reqHandler, query)
+	if err != nil {
+		log.Println("Error exporting users:", err)
+		job.Status = exportStatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = exportStatusDone
+	}
+
+	if err := s.RedisRepository.Set(ctx, exportKey(job.ID), job, ttl); err != nil {
+		log.Println("Error saving export job:", err)
+	}
+}
+
+func (s *service) writeExportFile(ctx context.Context, job dto.ExportJob, reqHandler dto.PayloadExportUser, query dbutil.ParsedQuery) error {
+	if err := os.MkdirAll(exportDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	path := exportPath(job.ID, job.Format)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.writeExport(ctx, reqHandler, query, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+
+	return nil
+}
+
+// writeExport walks the result with a keyset on the requested order and returns how many rows were written
+func (s *service) writeExport(ctx context.Context, reqHandler dto.PayloadExportUser, query dbutil.ParsedQuery, w io.Writer) (int, error) {
+	columns := query.Fields
+	if len(columns) == 0 {
+		columns = exportColumns
+	}
+
+	keyset, err := dbutil.NewKeyset(s.SecretKey, query.Order, "", exportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	selectedFields := listFields
+	if query.Select != "" {
+		selectedFields = withSortColumns(query.Select, keyset.Keys)
+	}
+
+	writer, err := sheet.NewWriter(w, reqHandler.Format, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	for {
+		opts := append(append([]dbutil.QueryOption{}, query.Where...), keyset.Options()...)
+
+		fetch, err := s.UserRepository.FindAll(ctx, selectedFields, opts...)
+		if err != nil {
+			writer.Close()
+			return rows, err
+		}
+
+		hasMore := len(fetch) > exportBatchSize
+		if hasMore {
+			fetch = fetch[:exportBatchSize]
+		}
+
+		for _, user := range fetch {
+			if err := writer.Write(s.exportValues(user, columns)); err != nil {
+				writer.Close()
+				return rows, err
+			}
+			rows++
+		}
+
+		if !hasMore {
+			return rows, writer.Close()
+		}
+
+		keyset.Cursor = &dbutil.Cursor{Values: sortValues(keyset.Keys, fetch[len(fetch)-1])}
+	}
+}
+
+func (s *service) recordExport(ctx context.Context, reqHandler dto.PayloadExportUser, rows int, jobID string) {
+	metadata := map[string]any{
+		"format": reqHandler.Format,
+		"rows":   rows,
+	}
+	if jobID != "" {
+		metadata["job_id"] = jobID
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserExported,
+		TargetType: consts.AuditTargetUser,
+		Metadata:   metadata,
+	})
+}
+
+// exportValues returns the cells of a user in column order, the values match what GET /user returns
+func (s *service) exportValues(user *model.User, columns []string) []any {
+	res := s.toUser(user)
+
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		switch column {
+		case "id":
+			values[i] = res.ID
+		case "name":
+			values[i] = res.Name
+		case "email":
+			values[i] = res.Email
+		case "phone_number":
+			values[i] = res.PhoneNumber
+		case "status":
+			values[i] = res.Status
+		case "status_reason":
+			values[i] = res.StatusReason
+		case "suspended_until":
+			values[i] = optionalString(res.SuspendedUntil)
+		case "email_verified_at":
+			values[i] = optionalString(res.EmailVerifiedAt)
+		case "profile_image_url":
+			values[i] = res.ProfileImageURL
+		case "created_at":
+			values[i] = res.CreatedAt
+		case "updated_at":
+			values[i] = res.UpdatedAt
+		}
+	}
+
+	return values
+}
+
+func optionalString(val *string) any {
+	if val == nil {
+		return nil
+	}
+	return *val
+}
+
+// removeExpiredExports deletes files whose job has already expired from redis
+func removeExpiredExports(ttl time.Duration) {
+	entries, err := os.ReadDir(exportDir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < ttl {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(exportDir, entry.Name())); err != nil {
+			log.Println("Error removing expired export:", err)
+		}
+	}
+}
+
+func newExportID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", b), nil
+}
+
+func exportKey(id string) string {
+	return fmt.Sprintf("user_export-%s", id)
+}
+
+func exportPath(id string, format string) string {
+	return filepath.Join(exportDir, id+"."+format)
+}
+
+func exportFileName(format string, now time.Time) string {
+	return fmt.Sprintf("users-%s.%s", now.Format("20060102150405"), format)
+}
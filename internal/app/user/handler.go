@@ -3,32 +3,40 @@ package user
 import (
 	"clean-arch/internal/dto"
 	"clean-arch/internal/factory"
+	"clean-arch/internal/middleware"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/imaging"
+	"clean-arch/pkg/sheet"
 	"clean-arch/pkg/tracer"
 	"clean-arch/pkg/util"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
-	"path/filepath"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
 )
 
 type handler struct {
-	service Service
+	service      Service
+	authenticate gin.HandlerFunc
 }
 
 func NewHandler(f *factory.Factory) *handler {
 	return &handler{
-		service: NewService(f),
+		service:      NewService(f),
+		authenticate: middleware.Authenticate(f),
 	}
 }
 
 func (h *handler) FindAll(c *gin.Context) {
 	limit := c.DefaultQuery("limit", "10")
 	offset := c.DefaultQuery("offset", "0")
-	search := c.DefaultQuery("search", "")
 
 	limitInt, err := strconv.Atoi(limit)
 	if err != nil {
@@ -44,19 +52,56 @@ func (h *handler) FindAll(c *gin.Context) {
 		return
 	}
 
+	if _, useCursor := c.GetQuery("cursor"); useCursor && (limitInt < 1 || limitInt > 100) {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", "limit must be between 1 and 100")
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	query, err := listQuery.Parse(c.Request.URL.Query())
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
 	payload := dto.PayloadBasicTable{
-		Limit:  limitInt,
-		Offset: offsetInt,
-		Search: search,
+		Limit:         limitInt,
+		Offset:        offsetInt,
+		PayloadCursor: bindCursor(c),
 	}
 
-	res, err := h.service.FindAll(c, payload)
+	res, err := h.service.FindAll(c, payload, query)
 	if err != nil {
 		response := util.APIResponse("Failed to get user list", http.StatusBadRequest, "error", err.Error())
 		c.JSON(http.StatusBadRequest, response)
 		return
 	}
 
+	if len(query.Fields) > 0 {
+		picked := dto.ResponsePage[map[string]any]{
+			Data:       []map[string]any{},
+			TotalRow:   res.TotalRow,
+			NextCursor: res.NextCursor,
+			PrevCursor: res.PrevCursor,
+		}
+
+		for _, user := range res.Data {
+			row, err := util.PickFields(user, query.Fields)
+			if err != nil {
+				response := util.APIResponse("Failed to get user list", http.StatusInternalServerError, "error", err.Error())
+				c.JSON(http.StatusInternalServerError, response)
+				return
+			}
+			picked.Data = append(picked.Data, row)
+		}
+
+		response := util.APIResponse("Successfully get user list", http.StatusOK, "success", picked)
+		tracer.Log(c, "info", "Get User List")
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
 	response := util.APIResponse("Successfully get user list", http.StatusOK, "success", res)
 	tracer.Log(c, "info", "Get User List")
 	c.JSON(http.StatusOK, response)
@@ -76,44 +121,17 @@ func (h *handler) Store(c *gin.Context) {
 		return
 	}
 
-	var uploadedFile string
-
-	if req.File != nil {
-		fileExt := filepath.Ext(req.File.Filename)
-		allowedExt := []string{".jpg", ".png", ".jpeg", ".bmp"}
-		if !util.InArrayStr(allowedExt, fileExt) {
-			response := util.APIResponse(fmt.Sprintf("File ext not valid, allowed ext is %v", allowedExt), http.StatusUnprocessableEntity, "failed", nil)
-			c.JSON(http.StatusUnprocessableEntity, response)
-			return
-		}
+	if req.File != nil && !checkAvatar(c, req.File) {
+		return
+	}
 
-		if req.File.Size > (5 * 1024 * 1024) {
-			response := util.APIResponse("File size to large, max size allowed is 5Mb", http.StatusUnprocessableEntity, "failed", nil)
+	if err := h.service.Store(c, req); err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedImage) || errors.Is(err, imaging.ErrImageTooLarge) {
+			response := util.APIResponse(err.Error(), http.StatusUnprocessableEntity, "failed", nil)
 			c.JSON(http.StatusUnprocessableEntity, response)
 			return
 		}
 
-		fileURL, err := util.SaveFile(req.File)
-		if err != nil {
-			response := util.APIResponse("Failed to upload file", http.StatusInternalServerError, "error", err.Error())
-			c.JSON(http.StatusInternalServerError, response)
-			return
-		}
-
-		appUrl := util.GetEnv("APP_URL", "http://localhost")
-		appPort := util.GetEnv("APP_PORT", "8080")
-
-		baseURL := fmt.Sprintf("%s:%s/", appUrl, appPort)
-		sanitizedLink := strings.Replace(fileURL, baseURL, "", 1)
-
-		uploadedFile = sanitizedLink
-		req.URL = fileURL
-	}
-
-	if err := h.service.Store(c, req); err != nil {
-		if req.File != nil {
-			_ = util.DeleteFile(uploadedFile)
-		}
 		response := util.APIResponse("Failed to store user", http.StatusInternalServerError, "error", err.Error())
 		c.JSON(http.StatusInternalServerError, response)
 		return
@@ -141,54 +159,201 @@ func (h *handler) Update(c *gin.Context) {
 		return
 	}
 
-	var uploadedFile string
+	if req.File != nil && !checkAvatar(c, req.File) {
+		return
+	}
 
-	if req.File != nil {
-		fileExt := filepath.Ext(req.File.Filename)
-		allowedExt := []string{".jpg", ".png", ".jpeg", ".bmp"}
-		if !util.InArrayStr(allowedExt, fileExt) {
-			response := util.APIResponse(fmt.Sprintf("File ext not valid, allowed ext is %v", allowedExt), http.StatusUnprocessableEntity, "failed", nil)
+	if err := h.service.Update(c, intId, req); err != nil {
+		if errors.Is(err, consts.EmailChangeRequired) {
+			response := util.APIResponse(err.Error(), http.StatusUnprocessableEntity, "failed", nil)
 			c.JSON(http.StatusUnprocessableEntity, response)
 			return
 		}
 
-		if req.File.Size > (5 * 1024 * 1024) {
-			response := util.APIResponse("File size to large, max size allowed is 5Mb", http.StatusUnprocessableEntity, "failed", nil)
+		if errors.Is(err, imaging.ErrUnsupportedImage) || errors.Is(err, imaging.ErrImageTooLarge) {
+			response := util.APIResponse(err.Error(), http.StatusUnprocessableEntity, "failed", nil)
 			c.JSON(http.StatusUnprocessableEntity, response)
 			return
 		}
 
-		fileURL, err := util.SaveFile(req.File)
-		if err != nil {
-			response := util.APIResponse("Failed to upload file", http.StatusInternalServerError, "error", err.Error())
-			c.JSON(http.StatusInternalServerError, response)
+		response := util.APIResponse("Failed to update user", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully update user", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Update User")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Import(c *gin.Context) {
+	var payload dto.PayloadImportUser
+	if err := c.ShouldBind(&payload); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response := util.APIResponse("File is required", http.StatusUnprocessableEntity, "failed", nil)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if fileHeader.Size > (10 * 1024 * 1024) {
+		response := util.APIResponse("File size to large, max size allowed is 10Mb", http.StatusUnprocessableEntity, "failed", nil)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response := util.APIResponse("Failed to read file", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	defer file.Close()
+
+	records, err := sheet.Read(file, fileHeader.Filename)
+	if err != nil {
+		response := util.APIResponse("Failed to read file", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.Import(c, payload, records)
+	if err == consts.ImportHasInvalidRow {
+		response := util.APIResponse(err.Error(), http.StatusUnprocessableEntity, "failed", res)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err != nil {
+		response := util.APIResponse("Failed to import users", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully import users", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Import User")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Export(c *gin.Context) {
+	actor, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	payload := dto.PayloadExportUser{
+		Format: c.DefaultQuery("format", sheet.FormatCSV),
+	}
+
+	contentType := sheet.ContentType(payload.Format)
+	if contentType == "" {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", "format must be csv, xlsx or ndjson")
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	query, err := listQuery.Parse(c.Request.URL.Query())
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	job, err := h.service.QueueExport(c, actor.ID, payload, query)
+	if err != nil {
+		response := util.APIResponse("Failed to export users", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if job != nil {
+		response := util.APIResponse("Export is being prepared, check the job for the download link", http.StatusAccepted, "success", job)
+		tracer.Log(c, "info", "Queue User Export")
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", exportFileName(payload.Format, time.Now())))
+
+	if err := h.service.Export(c, payload, query, c.Writer); err != nil {
+		// headers may already be flushed, so only log the failure when the body was started
+		if !c.Writer.Written() {
+			response := util.APIResponse("Failed to export users", http.StatusBadRequest, "error", err.Error())
+			c.JSON(http.StatusBadRequest, response)
 			return
 		}
+		tracer.Log(c, "error", err.Error())
+		return
+	}
 
-		appUrl := util.GetEnv("APP_URL", "http://localhost")
-		appPort := util.GetEnv("APP_PORT", "8080")
+	tracer.Log(c, "info", "Export User")
+}
 
-		baseURL := fmt.Sprintf("%s:%s/", appUrl, appPort)
-		sanitizedLink := strings.Replace(fileURL, baseURL, "", 1)
+func (h *handler) FindExport(c *gin.Context) {
+	actor, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
 
-		uploadedFile = sanitizedLink
-		req.URL = fileURL
+	job, err := h.service.FindExport(c, actor.ID, c.Param("id"))
+	if err == consts.ExportJobNotFound {
+		response := util.APIResponse(err.Error(), http.StatusNotFound, "failed", nil)
+		c.JSON(http.StatusNotFound, response)
+		return
 	}
 
-	if err := h.service.Update(c, intId, req); err != nil {
-		if req.File != nil {
-			_ = util.DeleteFile(uploadedFile)
-		}
-		response := util.APIResponse("Failed to update user", http.StatusInternalServerError, "error", err.Error())
-		c.JSON(http.StatusInternalServerError, response)
+	if err != nil {
+		response := util.APIResponse("Failed to get export", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
 		return
 	}
 
-	response := util.APIResponse("Successfully update user", http.StatusOK, "success", nil)
-	tracer.Log(c, "info", "Update User")
+	response := util.APIResponse("Successfully get export", http.StatusOK, "success", job)
+	tracer.Log(c, "info", "Get User Export")
 	c.JSON(http.StatusOK, response)
 }
 
+func (h *handler) DownloadExport(c *gin.Context) {
+	actor, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	path, job, err := h.service.ExportFile(c, actor.ID, c.Param("id"))
+	if err == consts.ExportJobNotFound {
+		response := util.APIResponse(err.Error(), http.StatusNotFound, "failed", nil)
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	if err == consts.ExportNotReady {
+		response := util.APIResponse(err.Error(), http.StatusConflict, "failed", job)
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	if err != nil {
+		response := util.APIResponse("Failed to download export", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	tracer.Log(c, "info", "Download User Export")
+	c.FileAttachment(path, job.FileName)
+}
+
 func (h *handler) FindOne(c *gin.Context) {
 	id := c.Param("id")
 	intId, _ := strconv.Atoi(id)
@@ -210,8 +375,13 @@ func (h *handler) Delete(c *gin.Context) {
 	intId, _ := strconv.Atoi(id)
 
 	if err := h.service.Delete(c, intId); err != nil {
-		response := util.APIResponse("Failed to delete user", http.StatusInternalServerError, "error", err.Error())
-		c.JSON(http.StatusInternalServerError, response)
+		status := http.StatusInternalServerError
+		if err == consts.NotFoundDataUser {
+			status = http.StatusNotFound
+		}
+
+		response := util.APIResponse("Failed to delete user", status, "error", err.Error())
+		c.JSON(status, response)
 		return
 	}
 
@@ -219,3 +389,728 @@ func (h *handler) Delete(c *gin.Context) {
 	tracer.Log(c, "info", "Delete User")
 	c.JSON(http.StatusOK, response)
 }
+
+func (h *handler) Restore(c *gin.Context) {
+	id := c.Param("id")
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		response := util.APIResponse("Invalid user id", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := h.service.Restore(c, intId); err != nil {
+		status := http.StatusInternalServerError
+		if err == consts.NotFoundDataUser {
+			status = http.StatusNotFound
+		} else if err == consts.EmailAlreadyExists {
+			status = http.StatusConflict
+		}
+
+		response := util.APIResponse("Failed to restore user", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully restore user", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Restore User")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) MyLoginHistory(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	h.loginHistory(c, user.ID)
+}
+
+func (h *handler) LoginHistory(c *gin.Context) {
+	id := c.Param("id")
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		response := util.APIResponse("Invalid user id", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	h.loginHistory(c, intId)
+}
+
+func (h *handler) loginHistory(c *gin.Context, userID int) {
+	payload := dto.PayloadLoginHistory{
+		Limit: 10,
+	}
+
+	if err := c.ShouldBindQuery(&payload); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	payload.PayloadCursor = bindCursor(c)
+
+	err := validation.ValidateStruct(&payload,
+		validation.Field(&payload.Limit, validation.Min(1), validation.Max(100)),
+		validation.Field(&payload.Offset, validation.Min(0)),
+		validation.Field(&payload.Status, validation.In(string(consts.LoginStatusSuccess), string(consts.LoginStatusFailed))),
+	)
+	if err != nil {
+		response := util.APIResponse("Validation failed", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.LoginHistory(c, userID, payload)
+	if err != nil {
+		response := util.APIResponse("Failed to get login history", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get login history", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Get Login History")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) UpdateStatus(c *gin.Context) {
+	id := c.Param("id")
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		response := util.APIResponse("Invalid user id", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	actor, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var body dto.PayloadUserStatus
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	statuses := []any{}
+	for _, status := range consts.UserStatuses {
+		statuses = append(statuses, string(status))
+	}
+
+	err = validation.ValidateStruct(&body,
+		validation.Field(&body.Status, validation.Required, validation.In(statuses...)),
+		validation.Field(&body.Reason, validation.Length(0, 255)),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if intId == actor.ID {
+		response := util.APIResponse("You can't change your own status", http.StatusBadRequest, "failed", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := h.service.UpdateStatus(c, intId, actor.ID, body); err != nil {
+		response := util.APIResponse("Failed to update user status", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully update user status", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Update User Status")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Deactivate(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var body dto.PayloadDeactivate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Password, validation.Required),
+		validation.Field(&body.Reason, validation.Length(0, 255)),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := h.service.Deactivate(c, user.ID, body); err != nil {
+		response := util.APIResponse("Failed to deactivate account", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Your account has been deactivated, sign in again to reactivate it", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Deactivate User")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) RequestEmailChange(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	sessionID, _ := middleware.SessionID(c)
+
+	var body dto.PayloadChangeEmail
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Email, validation.Required, validation.Length(0, 255), is.EmailFormat),
+		validation.Field(&body.Password, validation.Required),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := h.service.RequestEmailChange(c, user.ID, sessionID, body); err != nil {
+		if errors.Is(err, consts.EmailAlreadyExists) {
+			response := util.APIResponse(err.Error(), http.StatusConflict, "failed", nil)
+			c.JSON(http.StatusConflict, response)
+			return
+		}
+
+		response := util.APIResponse("Failed to request email change", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Please open the link sent to your new email to confirm the change", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Request Email Change")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) ConfirmEmailChange(c *gin.Context) {
+	if err := h.service.ConfirmEmailChange(c, c.Param("token")); err != nil {
+		if errors.Is(err, consts.EmailAlreadyExists) {
+			response := util.APIResponse(err.Error(), http.StatusConflict, "failed", nil)
+			c.JSON(http.StatusConflict, response)
+			return
+		}
+
+		response := util.APIResponse("Failed to confirm email change", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Your email has been changed", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Confirm Email Change")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) Me(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	res, err := h.service.FindOne(c, user.ID)
+	if err != nil {
+		response := util.APIResponse("Failed to get profile", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get profile", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Get Profile")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) UpdateMe(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var body dto.PayloadProfile
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Name, validation.NilOrNotEmpty, validation.Length(1, 255)),
+		validation.Field(&body.PhoneNumber, validation.Match(phonePattern).Error("must be 8 to 15 digits")),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := h.service.UpdateProfile(c, user.ID, body); err != nil {
+		if errors.Is(err, consts.EmailChangeRequired) || errors.Is(err, consts.InvalidAttributes) {
+			response := util.APIResponse(err.Error(), http.StatusUnprocessableEntity, "failed", nil)
+			c.JSON(http.StatusUnprocessableEntity, response)
+			return
+		}
+
+		response := util.APIResponse("Failed to update profile", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully update profile", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Update Profile")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) UpdatePreferences(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var body dto.PayloadPreferences
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := body.Validate(); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.UpdatePreferences(c, user.ID, body)
+	if err != nil {
+		response := util.APIResponse("Failed to update preferences", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully update preferences", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Update Preferences")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) ProfileSchema(c *gin.Context) {
+	response := util.APIResponse("Successfully get profile schema", http.StatusOK, "success", h.service.ProfileSchemaInfo())
+	tracer.Log(c, "info", "Get Profile Schema")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) ChangePassword(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	sessionID, _ := middleware.SessionID(c)
+
+	var body dto.PayloadChangePassword
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.LastPassword, validation.Required),
+		validation.Field(&body.NewPassword, validation.Required, validation.Length(8, 0).Error(consts.MinimCharacterPassword.Error())),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := h.service.ChangePassword(c, user.ID, sessionID, body); err != nil {
+		response := util.APIResponse("Failed to change password", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Your password has been changed, other sessions are signed out", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Change Password")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) UpdateAvatar(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var req dto.PayloadAvatar
+	if err := c.ShouldBind(&req); err != nil || req.File == nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", "file is required")
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if !checkAvatar(c, req.File) {
+		return
+	}
+
+	if err := h.service.UpdateAvatar(c, user.ID, req.File); err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedImage) || errors.Is(err, imaging.ErrImageTooLarge) {
+			response := util.APIResponse(err.Error(), http.StatusUnprocessableEntity, "failed", nil)
+			c.JSON(http.StatusUnprocessableEntity, response)
+			return
+		}
+
+		response := util.APIResponse("Failed to update avatar", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully update avatar", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Update Avatar")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) DeleteAvatar(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	if err := h.service.DeleteAvatar(c, user.ID); err != nil {
+		response := util.APIResponse("Failed to delete avatar", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully delete avatar", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Delete Avatar")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) DataExport(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	job, err := h.service.QueueDataExport(c, user.ID)
+	if err != nil {
+		response := util.APIResponse("Failed to export your data", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Your data is being prepared, check the job for the download link", http.StatusAccepted, "success", job)
+	tracer.Log(c, "info", "Queue Data Export")
+	c.JSON(http.StatusAccepted, response)
+}
+
+func (h *handler) FindDataExport(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	job, err := h.service.FindDataExport(c, user.ID, c.Param("id"))
+	if err == consts.ExportJobNotFound {
+		response := util.APIResponse(err.Error(), http.StatusNotFound, "failed", nil)
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	if err != nil {
+		response := util.APIResponse("Failed to get data export", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully get data export", http.StatusOK, "success", job)
+	tracer.Log(c, "info", "Get Data Export")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) DownloadDataExport(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	path, job, err := h.service.DataExportFile(c, user.ID, c.Param("id"))
+	if err == consts.ExportJobNotFound {
+		response := util.APIResponse(err.Error(), http.StatusNotFound, "failed", nil)
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	if err == consts.ExportNotReady {
+		response := util.APIResponse(err.Error(), http.StatusConflict, "failed", job)
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	if err != nil {
+		response := util.APIResponse("Failed to download data export", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	tracer.Log(c, "info", "Download Data Export")
+	c.FileAttachment(path, job.FileName)
+}
+
+func (h *handler) RequestErasure(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	var body dto.PayloadErasure
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Password, validation.Required),
+		validation.Field(&body.Reason, validation.Length(0, 255)),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.RequestErasure(c, user.ID, body)
+	if err != nil {
+		response := util.APIResponse("Failed to request account erasure", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Your account will be erased after the grace period, sign in again before it ends to cancel", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Request Account Erasure")
+	c.JSON(http.StatusOK, response)
+}
+
+// bindCursor enables keyset pagination when the cursor param is sent, count=false skips the total row
+func (h *handler) Invite(c *gin.Context) {
+	actor, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	body := dto.PayloadInviteUser{
+		Role: string(consts.RoleTypeUser),
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Email, validation.Required, validation.Length(0, 255), is.EmailFormat),
+		validation.Field(&body.Name, validation.Length(0, 255)),
+		validation.Field(&body.Role, validation.Required, validation.In(string(consts.RoleTypeAdmin), string(consts.RoleTypeUser))),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	res, err := h.service.Invite(c, actor.ID, body)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, consts.EmailAlreadyExists) || errors.Is(err, consts.UserAlreadyInvited) {
+			status = http.StatusConflict
+		}
+
+		response := util.APIResponse("Failed to invite user", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully invite user", http.StatusCreated, "success", res)
+	tracer.Log(c, "info", "Invite User")
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *handler) ResendInvite(c *gin.Context) {
+	intId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response := util.APIResponse("Invalid user id", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	actor, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	res, err := h.service.ResendInvite(c, actor.ID, intId)
+	if err != nil {
+		status := inviteErrorStatus(err)
+		response := util.APIResponse("Failed to resend invite", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully resend invite", http.StatusOK, "success", res)
+	tracer.Log(c, "info", "Resend User Invite")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) RevokeInvite(c *gin.Context) {
+	intId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response := util.APIResponse("Invalid user id", http.StatusBadRequest, "error", nil)
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	actor, ok := middleware.CurrentUser(c)
+	if !ok {
+		response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	if err := h.service.RevokeInvite(c, actor.ID, intId); err != nil {
+		status := inviteErrorStatus(err)
+		response := util.APIResponse("Failed to revoke invite", status, "error", err.Error())
+		c.JSON(status, response)
+		return
+	}
+
+	response := util.APIResponse("Successfully revoke invite", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Revoke User Invite")
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *handler) AcceptInvite(c *gin.Context) {
+	var body dto.PayloadAcceptUserInvite
+	if err := c.ShouldBindJSON(&body); err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	err := validation.ValidateStruct(&body,
+		validation.Field(&body.Token, validation.Required),
+		validation.Field(&body.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&body.Password, validation.Required, validation.Length(8, 0).Error(consts.MinimCharacterPassword.Error())),
+	)
+	if err != nil {
+		response := util.APIResponse("Invalid request", http.StatusUnprocessableEntity, "failed", err.Error())
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := h.service.AcceptInvite(c, body); err != nil {
+		response := util.APIResponse("Failed to accept invite", http.StatusBadRequest, "error", err.Error())
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response := util.APIResponse("Your account is ready, please sign in", http.StatusOK, "success", nil)
+	tracer.Log(c, "info", "Accept User Invite")
+	c.JSON(http.StatusOK, response)
+}
+
+// inviteErrorStatus maps the errors of resending and revoking an invite, unknown errors are a bad request
+func inviteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, consts.NotFoundDataUser):
+		return http.StatusNotFound
+	case errors.Is(err, consts.UserNotInvited):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+func bindCursor(c *gin.Context) dto.PayloadCursor {
+	cursor, useCursor := c.GetQuery("cursor")
+
+	return dto.PayloadCursor{
+		Cursor:    cursor,
+		UseCursor: useCursor,
+		SkipCount: c.Query("count") == "false",
+	}
+}
+
+// checkAvatar looks at the content of the upload instead of its name, the error response is written when it returns false
+func checkAvatar(c *gin.Context, file *multipart.FileHeader) bool {
+	if file.Size > avatarMaxSize {
+		response := util.APIResponse("File size to large, max size allowed is 5Mb", http.StatusUnprocessableEntity, "failed", nil)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return false
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		response := util.APIResponse("Failed to read file", http.StatusInternalServerError, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, response)
+		return false
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+
+	if imaging.Sniff(head[:n]) == "" {
+		response := util.APIResponse(imaging.ErrUnsupportedImage.Error(), http.StatusUnprocessableEntity, "failed", nil)
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return false
+	}
+
+	return true
+}
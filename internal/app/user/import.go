@@ -0,0 +1,395 @@
+package user
+
+import (
+	"bytes"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/crypto"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+const (
+	importBatchSize = 100
+	importMaxRows   = 5000
+
+	importStatusCreated = "created"
+	importStatusFailed  = "failed"
+	importStatusSkipped = "skipped"
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+
+type importRow struct {
+	result   dto.ImportUserRow
+	name     string
+	phone    string
+	password string
+	user     *model.User
+	// nonce is set for a row without password, its user is invited and sets the password from the link
+	nonce string
+}
+
+/*
+Import creates users from the rows of a CSV or XLSX file, the first row is the header
+
+	name,email,phone_number,password
+	John Doe,john@example.com,08123456789,
+
+name and email are required. A row without password is stored as an invited user, the welcome
+email then carries the accept invite link to set it instead of a password
+*/
+func (s *service) Import(ctx context.Context, reqHandler dto.PayloadImportUser, records [][]string) (*dto.ResponseImportUser, error) {
+	if len(records) == 0 {
+		return nil, consts.ImportHeaderInvalid
+	}
+
+	columns := map[string]int{}
+	for i, header := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, consts.ImportHeaderInvalid
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, consts.ImportHeaderInvalid
+	}
+
+	if len(records)-1 > importMaxRows {
+		return nil, fmt.Errorf("%w, max %d rows per import", consts.ImportTooManyRows, importMaxRows)
+	}
+
+	rows := []*importRow{}
+	seen := map[string]int{}
+
+	for i, record := range records[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+
+		row := &importRow{
+			result: dto.ImportUserRow{
+				Row:   i + 2,
+				Email: strings.ToLower(cell(record, columns, "email")),
+			},
+			name:     cell(record, columns, "name"),
+			phone:    cell(record, columns, "phone_number"),
+			password: cell(record, columns, "password"),
+		}
+
+		row.result.Errors = validateImportRow(row)
+
+		if first, ok := seen[row.result.Email]; ok && row.result.Email != "" {
+			row.result.Errors = append(row.result.Errors, fmt.Sprintf("email is duplicated in row %d", first))
+		} else {
+			seen[row.result.Email] = row.result.Row
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := s.markExistingEmails(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	res := &dto.ResponseImportUser{
+		Total: len(rows),
+		Rows:  []dto.ImportUserRow{},
+	}
+
+	var valid []*importRow
+	for _, row := range rows {
+		if len(row.result.Errors) == 0 {
+			valid = append(valid, row)
+		}
+	}
+
+	if !reqHandler.Partial && len(valid) != len(rows) {
+		for _, row := range rows {
+			if len(row.result.Errors) == 0 {
+				row.result.Status = importStatusSkipped
+			} else {
+				row.result.Status = importStatusFailed
+				res.Failed++
+			}
+			res.Rows = append(res.Rows, row.result)
+		}
+
+		return res, consts.ImportHasInvalidRow
+	}
+
+	var err error
+	if reqHandler.Partial {
+		err = s.importPartial(ctx, valid)
+	} else {
+		err = s.importAll(ctx, valid)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var created []*importRow
+	for _, row := range rows {
+		if row.result.Status == importStatusCreated {
+			created = append(created, row)
+			res.Created++
+		} else {
+			row.result.Status = importStatusFailed
+			res.Failed++
+		}
+		res.Rows = append(res.Rows, row.result)
+	}
+
+	if reqHandler.SendInvite && len(created) > 0 {
+		if reqHandler.WaitInvites {
+			s.sendWelcomeEmails(created)
+		} else {
+			go s.sendWelcomeEmails(created)
+		}
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserImported,
+		TargetType: consts.AuditTargetUser,
+		Metadata: map[string]any{
+			"total":   res.Total,
+			"created": res.Created,
+			"failed":  res.Failed,
+			"partial": reqHandler.Partial,
+		},
+	})
+
+	return res, nil
+}
+
+// importAll inserts every row in one transaction, a failing batch rolls back the whole file
+func (s *service) importAll(ctx context.Context, rows []*importRow) error {
+	users, err := s.buildImportUsers(rows)
+	if err != nil {
+		return err
+	}
+
+	// CreateInBatches has no transaction of its own when the connection skips the default one
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		return s.UserRepository.StoreBatch(ctx, users, importBatchSize)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		row.result.Status = importStatusCreated
+	}
+
+	return nil
+}
+
+// importPartial commits every batch on its own, the rows of a failing batch are reported and the next batch goes on
+func (s *service) importPartial(ctx context.Context, rows []*importRow) error {
+	for start := 0; start < len(rows); start += importBatchSize {
+		end := min(start+importBatchSize, len(rows))
+		batch := rows[start:end]
+
+		users, err := s.buildImportUsers(batch)
+		if err != nil {
+			return err
+		}
+
+		err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+			return s.UserRepository.StoreBatch(ctx, users, importBatchSize)
+		})
+
+		for _, row := range batch {
+			if err != nil {
+				log.Println("Error importing users:", err)
+				row.result.Errors = append(row.result.Errors, "failed to store user")
+				continue
+			}
+			row.result.Status = importStatusCreated
+		}
+	}
+
+	return nil
+}
+
+func (s *service) buildImportUsers(rows []*importRow) ([]*model.User, error) {
+	now := s.Clock.Now()
+	users := make([]*model.User, 0, len(rows))
+
+	for _, row := range rows {
+		user := &model.User{
+			Name:        row.name,
+			Email:       row.result.Email,
+			Role:        consts.RoleTypeUser,
+			PhoneNumber: row.phone,
+		}
+
+		if row.password == "" {
+			nonce, err := s.prepareInvite(user, now)
+			if err != nil {
+				return nil, err
+			}
+			user.Status = consts.UserStatusInvited
+			user.InvitedAt = &now
+			row.nonce = nonce
+		} else {
+			hashedPassword, err := util.HashPassword(row.password)
+			if err != nil {
+				return nil, consts.ErrorHashPassword
+			}
+			user.Password = hashedPassword
+			user.Status = consts.UserStatusActive
+			user.EmailVerifiedAt = &now
+		}
+
+		row.user = user
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// markExistingEmails flags rows whose email already belongs to an active user
+func (s *service) markExistingEmails(ctx context.Context, rows []*importRow) error {
+	for start := 0; start < len(rows); start += importBatchSize {
+		end := min(start+importBatchSize, len(rows))
+
+		emails := []string{}
+		for _, row := range rows[start:end] {
+			if row.result.Email != "" {
+				emails = append(emails, row.result.Email)
+			}
+		}
+
+		if len(emails) == 0 {
+			continue
+		}
+
+		existing, err := s.UserRepository.FindAll(ctx, "email", dbutil.Where("LOWER(email) IN ?", emails))
+		if err != nil {
+			return err
+		}
+
+		taken := map[string]bool{}
+		for _, user := range existing {
+			taken[strings.ToLower(user.Email)] = true
+		}
+
+		for _, row := range rows[start:end] {
+			if taken[row.result.Email] {
+				row.result.Errors = append(row.result.Errors, consts.EmailAlreadyExists.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateImportRow(row *importRow) []string {
+	errs := []string{}
+
+	if row.name == "" {
+		errs = append(errs, "name is required")
+	} else if len(row.name) > 255 {
+		errs = append(errs, "name is too long")
+	}
+
+	if row.result.Email == "" {
+		errs = append(errs, "email is required")
+	} else if addr, err := mail.ParseAddress(row.result.Email); err != nil || addr.Address != row.result.Email {
+		errs = append(errs, "email is not valid")
+	}
+
+	if row.phone != "" && !phonePattern.MatchString(row.phone) {
+		errs = append(errs, "phone_number must be 8 to 15 digits")
+	}
+
+	if row.password != "" && len(row.password) < 8 {
+		errs = append(errs, consts.MinimCharacterPassword.Error())
+	}
+
+	return errs
+}
+
+func cell(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *service) sendWelcomeEmails(rows []*importRow) {
+	for _, row := range rows {
+		if err := s.sendWelcomeEmail(row); err != nil {
+			log.Println("Error sending welcome email:", err)
+		}
+	}
+}
+
+/*
+sendWelcomeEmail tells an imported user about the account, the password is never sent. A user
+imported without password gets the accept invite link to set one, the others a link to sign in
+*/
+func (s *service) sendWelcomeEmail(row *importRow) error {
+	tmpl, err := template.ParseFiles(consts.TemplateEmailWelcome)
+	if err != nil {
+		return fmt.Errorf("error parsing template %s", err.Error())
+	}
+
+	url := util.GetEnv("FE_URL", "fallback") + "/auth/login"
+	expiredAt := ""
+	if row.nonce != "" {
+		token, err := crypto.SignPayload(s.SecretKey, userInvitePayload{
+			UserID:    row.user.ID,
+			Nonce:     row.nonce,
+			Purpose:   userInvitePurpose,
+			ExpiredAt: row.user.InviteExpiresAt.Unix(),
+		})
+		if err != nil {
+			return err
+		}
+
+		url = util.GetEnv("FE_URL", "fallback") + "/auth/accept-invite/" + token
+		expiredAt = row.user.InviteExpiresAt.Format(consts.TimeFormatDateTime)
+	}
+
+	data := struct {
+		AppUrl    string
+		Name      string
+		Email     string
+		ExpiredAt string
+		Url       string
+	}{
+		AppUrl:    util.GetEnv("APP_URL", "fallback") + ":" + util.GetEnv("APP_PORT", "fallback"),
+		Name:      row.name,
+		Email:     row.result.Email,
+		ExpiredAt: expiredAt,
+		Url:       url,
+	}
+
+	var tplBuffer = new(bytes.Buffer)
+	if err := tmpl.Execute(tplBuffer, data); err != nil {
+		return fmt.Errorf("error executing template %s", err.Error())
+	}
+
+	return s.Mailer.Send(row.result.Email, "Selamat Datang di "+util.GetEnv("APP_NAME", "fallback"), tplBuffer.String())
+}
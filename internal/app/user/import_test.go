@@ -0,0 +1,114 @@
+package user_test
+
+import (
+	"clean-arch/internal/app/user"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/integration"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func importRecords(count int) [][]string {
+	records := [][]string{{"name", "email", "phone_number", "password"}}
+	for i := range count {
+		records = append(records, []string{fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@example.com", i), "", "secret123"})
+	}
+	return records
+}
+
+// failSecondInsert makes the second INSERT into users fail, the second batch of an import
+func failSecondInsert(t *testing.T, db *gorm.DB) {
+	inserts := 0
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_second_insert", func(tx *gorm.DB) {
+		if tx.Statement.Table != "users" {
+			return
+		}
+
+		inserts++
+		if inserts == 2 {
+			tx.AddError(errors.New("second batch failed"))
+		}
+	})
+	assert.Nil(t, err)
+}
+
+func TestImportAllRollsBack(t *testing.T) {
+	h := integration.New(t)
+	svc := user.NewService(h.Factory)
+	failSecondInsert(t, h.DB)
+
+	// 150 rows are stored in two batches of 100 and 50
+	_, err := svc.Import(context.Background(), dto.PayloadImportUser{}, importRecords(150))
+	assert.EqualError(t, err, "second batch failed")
+
+	var count int64
+	assert.Nil(t, h.DB.Model(&model.User{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestImportPartialKeepsBatches(t *testing.T) {
+	h := integration.New(t)
+	svc := user.NewService(h.Factory)
+	failSecondInsert(t, h.DB)
+
+	res, err := svc.Import(context.Background(), dto.PayloadImportUser{Partial: true}, importRecords(150))
+	assert.Nil(t, err)
+	assert.Equal(t, 100, res.Created)
+	assert.Equal(t, 50, res.Failed)
+
+	var count int64
+	assert.Nil(t, h.DB.Model(&model.User{}).Count(&count).Error)
+	assert.Equal(t, int64(100), count)
+}
+
+func TestImportWithoutPasswordSendsLink(t *testing.T) {
+	h := integration.New(t)
+	svc := user.NewService(h.Factory)
+
+	records := [][]string{{"name", "email", "password"}, {"Budi", "budi@example.com", ""}}
+	res, err := svc.Import(context.Background(), dto.PayloadImportUser{SendInvite: true, WaitInvites: true}, records)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, res.Created)
+
+	var stored model.User
+	assert.Nil(t, h.DB.Where("email = ?", "budi@example.com").Take(&stored).Error)
+	assert.Equal(t, consts.UserStatusInvited, stored.Status)
+	assert.Empty(t, stored.Password)
+
+	// the mail has no credentials, only the link to set the password
+	msg, sent := h.Sent("budi@example.com")
+	assert.True(t, sent)
+	match := regexp.MustCompile(`/auth/accept-invite/([^"]+)"`).FindStringSubmatch(msg.Body)
+	if !assert.Len(t, match, 2) {
+		return
+	}
+
+	err = svc.AcceptInvite(context.Background(), dto.PayloadAcceptUserInvite{Token: match[1], Name: "Budi", Password: "secret123"})
+	assert.Nil(t, err)
+	h.Login(t, "budi@example.com", "secret123")
+}
+
+func TestImportExistingEmailIgnoresCase(t *testing.T) {
+	h := integration.New(t)
+	svc := user.NewService(h.Factory)
+	h.CreateUser(t, "John@Example.com", "secret123", consts.RoleTypeUser)
+
+	records := [][]string{{"name", "email", "password"}, {"John", "john@example.com", "secret123"}}
+	// the duplicate is a row error, not a failed insert
+	res, err := svc.Import(context.Background(), dto.PayloadImportUser{}, records)
+	assert.Equal(t, consts.ImportHasInvalidRow, err)
+	assert.Equal(t, 0, res.Created)
+	assert.Equal(t, []string{consts.EmailAlreadyExists.Error()}, res.Rows[0].Errors)
+
+	var count int64
+	assert.Nil(t, h.DB.Model(&model.User{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,270 @@
+package user
+
+import (
+	"bytes"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/crypto"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const userInvitePurpose = "user_invite"
+
+// userInvitePayload is signed into the link, only the hash of the nonce is stored so resending replaces the link
+type userInvitePayload struct {
+	UserID    int    `json:"uid"`
+	Nonce     string `json:"nonce"`
+	Purpose   string `json:"purpose"`
+	ExpiredAt int64  `json:"exp"`
+}
+
+/*
+Invite creates a pending user with the invited status and emails a link to accept it, the user
+can't sign in until the invite is accepted with a name and password
+
+An email that belongs to another user, deleted ones included, can't be invited, an email that is
+already invited has to be resent
+*/
+func (s *service) Invite(ctx context.Context, actorID int, reqHandler dto.PayloadInviteUser) (*dto.User, error) {
+	email := strings.ToLower(strings.TrimSpace(reqHandler.Email))
+
+	existing, err := s.UserRepository.FindOne(ctx, "id, status", dbutil.Where("email = ?", email), dbutil.WithDeleted())
+	if err == nil {
+		if existing.Status == consts.UserStatusInvited {
+			return nil, consts.UserAlreadyInvited
+		}
+		return nil, consts.EmailAlreadyExists
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	role := consts.RoleType(reqHandler.Role)
+	if role == "" {
+		role = consts.RoleTypeUser
+	}
+
+	now := s.Clock.Now()
+	insertModel := model.User{
+		Name:      strings.TrimSpace(reqHandler.Name),
+		Email:     email,
+		Role:      role,
+		Status:    consts.UserStatusInvited,
+		InvitedBy: &actorID,
+		InvitedAt: &now,
+	}
+
+	nonce, err := s.prepareInvite(&insertModel, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UserRepository.Store(ctx, &insertModel); err != nil {
+		return nil, err
+	}
+
+	if err := s.sendUserInvite(ctx, insertModel, actorID, nonce); err != nil {
+		return nil, err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserInvited,
+		ActorID:    &actorID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &insertModel.ID,
+		Metadata:   map[string]any{"email": insertModel.Email, "role": insertModel.Role},
+	})
+
+	res := s.toUser(&insertModel)
+	return &res, nil
+}
+
+// ResendInvite sends a new link with a new expiry, the links sent before stop working
+func (s *service) ResendInvite(ctx context.Context, actorID int, id int) (*dto.User, error) {
+	user, err := s.findInvited(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := s.prepareInvite(&user, s.Clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.UserRepository.UpdateColumns(ctx, user.ID, map[string]any{
+		"invite_token_hash": user.InviteTokenHash,
+		"invite_expires_at": user.InviteExpiresAt,
+	})
+	if err != nil {
+		return nil, consts.FailedUpdateUser
+	}
+
+	if err := s.sendUserInvite(ctx, user, actorID, nonce); err != nil {
+		return nil, err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditInviteResent,
+		ActorID:    &actorID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &user.ID,
+	})
+
+	res := s.toUser(&user)
+	return &res, nil
+}
+
+// RevokeInvite removes the pending user for good, it never signed in so nothing else refers to it
+func (s *service) RevokeInvite(ctx context.Context, actorID int, id int) error {
+	user, err := s.findInvited(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.UserRepository.ForceDelete(ctx, user.ID); err != nil {
+		return err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditInviteCanceled,
+		ActorID:    &actorID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &user.ID,
+		Metadata:   map[string]any{"email": user.Email},
+	})
+
+	return nil
+}
+
+// AcceptInvite activates the invited user with the name and password sent, the link proves the email so it is verified too
+func (s *service) AcceptInvite(ctx context.Context, reqHandler dto.PayloadAcceptUserInvite) error {
+	var payload userInvitePayload
+
+	err := crypto.VerifyPayload(s.SecretKey, reqHandler.Token, &payload)
+	if err != nil || payload.Purpose != userInvitePurpose || s.Clock.Now().Unix() > payload.ExpiredAt {
+		return consts.InvalidSignedLink
+	}
+
+	user, err := s.UserRepository.FindOne(ctx, "id, email, status, invite_token_hash, invite_expires_at", dbutil.Where("id = ?", payload.UserID))
+	if err != nil || user.Status != consts.UserStatusInvited || user.InviteTokenHash != crypto.EncodeSHA256(payload.Nonce) {
+		return consts.InvalidSignedLink
+	}
+
+	if user.InviteExpiresAt == nil || s.Clock.Now().After(*user.InviteExpiresAt) {
+		return consts.InvalidSignedLink
+	}
+
+	hashedPassword, err := util.HashPassword(reqHandler.Password)
+	if err != nil {
+		return err
+	}
+
+	err = s.UserRepository.UpdateColumns(ctx, user.ID, map[string]any{
+		"name":              strings.TrimSpace(reqHandler.Name),
+		"password":          hashedPassword,
+		"status":            consts.UserStatusActive,
+		"email_verified_at": s.Clock.Now(),
+		"invite_token_hash": "",
+		"invite_expires_at": nil,
+	})
+	if err != nil {
+		return consts.FailedUpdateUser
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditInviteAccepted,
+		ActorID:    &user.ID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &user.ID,
+	})
+
+	return nil
+}
+
+func (s *service) findInvited(ctx context.Context, id int) (model.User, error) {
+	user, err := s.UserRepository.FindOne(ctx, "*", dbutil.Where("id = ?", id))
+	if err != nil {
+		return user, consts.NotFoundDataUser
+	}
+
+	if user.Status != consts.UserStatusInvited {
+		return user, consts.UserNotInvited
+	}
+
+	return user, nil
+}
+
+// prepareInvite sets a new token hash and expiry on the user and returns the nonce for the link
+func (s *service) prepareInvite(user *model.User, now time.Time) (string, error) {
+	nonce, err := util.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := now.Add(config.UserInviteTTL())
+	user.InviteTokenHash = crypto.EncodeSHA256(nonce)
+	user.InviteExpiresAt = &expiresAt
+
+	return nonce, nil
+}
+
+func (s *service) sendUserInvite(ctx context.Context, user model.User, actorID int, nonce string) error {
+	token, err := crypto.SignPayload(s.SecretKey, userInvitePayload{
+		UserID:    user.ID,
+		Nonce:     nonce,
+		Purpose:   userInvitePurpose,
+		ExpiredAt: user.InviteExpiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	inviter, err := s.UserRepository.FindOne(ctx, "id, name", dbutil.Where("id = ?", actorID))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	tmpl, err := template.ParseFiles(consts.TemplateEmailUserInvite)
+	if err != nil {
+		return fmt.Errorf("error parsing template %s", err.Error())
+	}
+
+	appName := util.GetEnv("APP_NAME", "fallback")
+
+	data := struct {
+		AppUrl    string
+		AppName   string
+		Name      string
+		InvitedBy string
+		Email     string
+		ExpiredAt string
+		Url       string
+	}{
+		AppUrl:    util.GetEnv("APP_URL", "fallback") + ":" + util.GetEnv("APP_PORT", "fallback"),
+		AppName:   appName,
+		Name:      user.Name,
+		InvitedBy: inviter.Name,
+		Email:     user.Email,
+		ExpiredAt: user.InviteExpiresAt.Format(consts.TimeFormatDateTime),
+		Url:       util.GetEnv("FE_URL", "fallback") + "/auth/accept-invite/" + token,
+	}
+
+	var tplBuffer = new(bytes.Buffer)
+	if err := tmpl.Execute(tplBuffer, data); err != nil {
+		return fmt.Errorf("error executing template %s", err.Error())
+	}
+
+	go s.Mailer.Send(user.Email, "Undangan Akun "+appName, tplBuffer.String())
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package user
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/profile"
+	"clean-arch/pkg/util"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"strings"
+)
+
+// UpdateProfile changes the fields of the payload that are set, the email goes through RequestEmailChange
+func (s *service) UpdateProfile(ctx context.Context, userID int, reqHandler dto.PayloadProfile) error {
+	user, err := s.UserRepository.FindOne(ctx, "id, email, attributes", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	if reqHandler.Email != nil && !strings.EqualFold(*reqHandler.Email, user.Email) {
+		return consts.EmailChangeRequired
+	}
+
+	columns := map[string]any{}
+	fields := []string{}
+
+	if reqHandler.Name != nil {
+		columns["name"] = strings.TrimSpace(*reqHandler.Name)
+		fields = append(fields, "name")
+	}
+
+	if reqHandler.PhoneNumber != nil {
+		columns["phone_number"] = *reqHandler.PhoneNumber
+		fields = append(fields, "phone_number")
+	}
+
+	if len(reqHandler.Attributes) > 0 {
+		if err := s.ProfileSchema.Validate(reqHandler.Attributes); err != nil {
+			return fmt.Errorf("%w, %s", consts.InvalidAttributes, err.Error())
+		}
+
+		attributes, err := json.Marshal(profile.Merge(user.Attributes, reqHandler.Attributes))
+		if err != nil {
+			return err
+		}
+		columns["attributes"] = string(attributes)
+		fields = append(fields, "attributes")
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if err := s.UserRepository.UpdateColumns(ctx, userID, columns); err != nil {
+		return consts.FailedUpdateUser
+	}
+
+	// the cached session data holds the name and phone number
+	_ = s.RedisRepository.Del(ctx, fmt.Sprintf("user_session-%d", userID))
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserUpdated,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &userID,
+		Metadata:   map[string]any{"fields": fields},
+	})
+
+	return nil
+}
+
+// UpdatePreferences applies a partial update to the preferences and returns them with the defaults
+func (s *service) UpdatePreferences(ctx context.Context, userID int, reqHandler dto.PayloadPreferences) (*profile.Resolved, error) {
+	user, err := s.UserRepository.FindOne(ctx, "id, preferences", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return nil, consts.NotFoundDataUser
+	}
+
+	preferences := user.Preferences.Apply(reqHandler, s.Clock.Now())
+
+	encoded, err := json.Marshal(preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UserRepository.UpdateColumns(ctx, userID, map[string]any{"preferences": string(encoded)}); err != nil {
+		return nil, consts.FailedUpdateUser
+	}
+
+	metadata := map[string]any{"fields": []string{"preferences"}}
+	// Apply only sets a new consent time when the consent changes, the trail keeps every change
+	if preferences.MarketingConsentAt != user.Preferences.Resolve().MarketingConsentAt {
+		metadata["marketing_consent"] = *preferences.MarketingConsent
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserUpdated,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &userID,
+		Metadata:   metadata,
+	})
+
+	res := preferences.Resolve()
+	return &res, nil
+}
+
+// ProfileSchemaInfo lists what the profile API accepts with the default values
+func (s *service) ProfileSchemaInfo() dto.ResponseProfileSchema {
+	return dto.ResponseProfileSchema{
+		Locales:     profile.Locales,
+		Themes:      profile.Themes,
+		Preferences: profile.Defaults,
+		Attributes:  s.ProfileSchema,
+	}
+}
+
+// ChangePassword checks the current password and signs out every session except sessionID
+func (s *service) ChangePassword(ctx context.Context, userID int, sessionID int, reqHandler dto.PayloadChangePassword) error {
+	user, err := s.UserRepository.FindOne(ctx, "id, password", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	match, err := util.VerifyPassword(reqHandler.LastPassword, user.Password)
+	if err != nil || !match {
+		return consts.InvalidPassword
+	}
+
+	if reqHandler.NewPassword == reqHandler.LastPassword {
+		return consts.PasswordSameCurrent
+	}
+
+	hashedPassword, err := util.HashPassword(reqHandler.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.UserRepository.UpdateColumns(ctx, userID, map[string]any{"password": hashedPassword}); err != nil {
+			return consts.FailedChangePassword
+		}
+
+		return s.UserRepository.RevokeOtherSessions(ctx, userID, sessionID)
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditPasswordChanged,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &userID,
+	})
+
+	return nil
+}
+
+// UpdateAvatar replaces the avatar, the old files are removed once the new key is saved
+func (s *service) UpdateAvatar(ctx context.Context, userID int, file *multipart.FileHeader) error {
+	user, err := s.UserRepository.FindOne(ctx, "id, profile_image_url", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	key, err := s.putAvatar(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	if err := s.saveAvatar(ctx, userID, key); err != nil {
+		s.deleteAvatar(ctx, key)
+		return err
+	}
+
+	s.deleteAvatar(ctx, user.ProfileImageURL)
+
+	return nil
+}
+
+func (s *service) DeleteAvatar(ctx context.Context, userID int) error {
+	user, err := s.UserRepository.FindOne(ctx, "id, profile_image_url", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	if user.ProfileImageURL == "" {
+		return nil
+	}
+
+	if err := s.saveAvatar(ctx, userID, ""); err != nil {
+		return err
+	}
+
+	s.deleteAvatar(ctx, user.ProfileImageURL)
+
+	return nil
+}
+
+func (s *service) saveAvatar(ctx context.Context, userID int, key string) error {
+	if err := s.UserRepository.UpdateColumns(ctx, userID, map[string]any{"profile_image_url": key}); err != nil {
+		return consts.FailedUpdateUser
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserUpdated,
+		ActorID:    &userID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &userID,
+		Metadata:   map[string]any{"fields": []string{"profile_image_url"}},
+	})
+
+	return nil
+}
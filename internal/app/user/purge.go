@@ -0,0 +1,38 @@
+package user
+
+import (
+	"clean-arch/internal/factory"
+	"context"
+	"log"
+	"time"
+)
+
+const purgeBatchSize = 100
+
+// StartPurgeJob hard deletes users soft deleted longer than retention on every interval until ctx is done,
+// a retention of zero keeps deleted users forever
+func StartPurgeJob(ctx context.Context, f *factory.Factory, retention time.Duration, interval time.Duration) {
+	if retention <= 0 || interval <= 0 {
+		return
+	}
+
+	s := NewService(f)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.Purge(ctx, f.Clock.Now().Add(-retention))
+		if err != nil {
+			log.Println("Error purging deleted users:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted users\n", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
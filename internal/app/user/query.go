@@ -0,0 +1,97 @@
+package user
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/dbutil"
+	"strings"
+)
+
+// listFields is selected when the client doesn't pick fields
+const listFields = "id, name, email, profile_image_url, email_verified_at, phone_number, status, status_reason, suspended_until, invited_at, invite_expires_at, created_at, updated_at"
+
+// listQuery is what GET /user accepts in filter[...], sort, fields and search
+var listQuery = dbutil.QuerySpec{
+	Filters: map[string]dbutil.Field{
+		"id":                {Column: "id", Type: dbutil.FieldInt},
+		"name":              {Column: "name", Type: dbutil.FieldString},
+		"email":             {Column: "email", Type: dbutil.FieldString},
+		"phone_number":      {Column: "phone_number", Type: dbutil.FieldString},
+		"role":              {Column: "role", Type: dbutil.FieldString},
+		"status":            {Column: "status", Type: dbutil.FieldString},
+		"email_verified":    {Column: "email_verified_at", Type: dbutil.FieldPresence},
+		"email_verified_at": {Column: "email_verified_at", Type: dbutil.FieldTime},
+		"invited":           {Column: "invited_at", Type: dbutil.FieldPresence},
+		"created_at":        {Column: "created_at", Type: dbutil.FieldTime},
+		"updated_at":        {Column: "updated_at", Type: dbutil.FieldTime},
+	},
+	Sorts: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"email":      "email",
+		"status":     "status",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	Fields: map[string]string{
+		"id":                "id",
+		"name":              "name",
+		"email":             "email",
+		"email_verified_at": "email_verified_at",
+		"profile_image_url": "profile_image_url",
+		"phone_number":      "phone_number",
+		"status":            "status",
+		"status_reason":     "status_reason",
+		"suspended_until":   "suspended_until",
+		"invited_at":        "invited_at",
+		"invite_expires_at": "invite_expires_at",
+		"created_at":        "created_at",
+		"updated_at":        "updated_at",
+	},
+	Search:         []string{"name", "email", "phone_number"},
+	DefaultSort:    "-id",
+	RequiredFields: []string{"id"},
+	// dates are reported in the app location, so the filters read them there too
+	Location: config.AppLocation(),
+}
+
+// sortValues returns the values of the keyset columns of a user in key order
+func sortValues(keys []dbutil.SortKey, user *model.User) []any {
+	values := make([]any, 0, len(keys))
+
+	for _, key := range keys {
+		switch key.Column {
+		case "id":
+			values = append(values, user.ID)
+		case "name":
+			values = append(values, user.Name)
+		case "email":
+			values = append(values, user.Email)
+		case "status":
+			values = append(values, string(user.Status))
+		case "created_at":
+			values = append(values, user.CreatedAt)
+		case "updated_at":
+			values = append(values, user.UpdatedAt)
+		}
+	}
+
+	return values
+}
+
+// withSortColumns adds the keyset columns to a trimmed select so the next cursor can be built
+func withSortColumns(selectedFields string, keys []dbutil.SortKey) string {
+	selected := map[string]bool{}
+	for _, field := range strings.Split(selectedFields, ",") {
+		selected[strings.TrimSpace(field)] = true
+	}
+
+	for _, key := range keys {
+		if !selected[key.Column] {
+			selectedFields += "," + key.Column
+			selected[key.Column] = true
+		}
+	}
+
+	return selectedFields
+}
@@ -2,16 +2,52 @@ package user
 
 import (
 	"clean-arch/internal/middleware"
+	"clean-arch/pkg/consts"
 
 	"github.com/gin-gonic/gin"
 )
 
 // This function accepts gin.Routergroup to define a group route
 func (h *handler) Router(g *gin.RouterGroup) {
-	g.Use(middleware.Authenticate())
-	g.GET("", h.FindAll)
-	g.POST("/store", h.Store)
-	g.GET("/:id/detail", h.FindOne)
-	g.PUT("/:id/update", h.Update)
-	g.DELETE("/:id/delete", h.Delete)
+	g.Use(h.authenticate)
+	g.GET("", middleware.Authorize(consts.RoleTypeAdmin), h.FindAll)
+	g.POST("/store", middleware.Authorize(consts.RoleTypeAdmin), h.Store)
+	g.POST("/import", middleware.Authorize(consts.RoleTypeAdmin), h.Import)
+	g.GET("/export", middleware.Authorize(consts.RoleTypeAdmin), h.Export)
+	g.GET("/export/:id", middleware.Authorize(consts.RoleTypeAdmin), h.FindExport)
+	g.GET("/export/:id/download", middleware.Authorize(consts.RoleTypeAdmin), h.DownloadExport)
+	g.GET("/:id/detail", middleware.Authorize(consts.RoleTypeAdmin), h.FindOne)
+	g.PUT("/:id/update", middleware.Authorize(consts.RoleTypeAdmin), h.Update)
+	g.DELETE("/:id/delete", middleware.Authorize(consts.RoleTypeAdmin), h.Delete)
+	g.PUT("/:id/restore", middleware.Authorize(consts.RoleTypeAdmin), h.Restore)
+	g.GET("/:id/login-history", middleware.Authorize(consts.RoleTypeAdmin), h.LoginHistory)
+	g.PUT("/:id/status", middleware.Authorize(consts.RoleTypeAdmin), h.UpdateStatus)
+	g.POST("/invite", middleware.Authorize(consts.RoleTypeAdmin), h.Invite)
+	g.POST("/:id/invite/resend", middleware.Authorize(consts.RoleTypeAdmin), h.ResendInvite)
+	g.DELETE("/:id/invite", middleware.Authorize(consts.RoleTypeAdmin), h.RevokeInvite)
+	g.POST("/deactivate", h.Deactivate)
+}
+
+// This function registers the profile routes of the logged in user, they never take a user id
+func (h *handler) MeRouter(g *gin.RouterGroup) {
+	g.Use(h.authenticate)
+	g.GET("", h.Me)
+	g.PATCH("", h.UpdateMe)
+	g.GET("/profile-schema", h.ProfileSchema)
+	g.PATCH("/preferences", h.UpdatePreferences)
+	g.PATCH("/password", h.ChangePassword)
+	g.PUT("/avatar", h.UpdateAvatar)
+	g.DELETE("/avatar", h.DeleteAvatar)
+	g.POST("/email", h.RequestEmailChange)
+	g.POST("/data-export", h.DataExport)
+	g.GET("/data-export/:id", h.FindDataExport)
+	g.GET("/data-export/:id/download", h.DownloadDataExport)
+	g.POST("/erasure", h.RequestErasure)
+}
+
+// This function registers the routes of the logged in user under the auth group
+func (h *handler) AuthRouter(g *gin.RouterGroup) {
+	g.GET("/login-history", h.authenticate, h.MyLoginHistory)
+	g.POST("/confirm-email/:token", h.ConfirmEmailChange)
+	g.POST("/accept-invite", h.AcceptInvite)
 }
@@ -1,16 +1,23 @@
 package user
 
 import (
-	"clean-arch/database"
+	"clean-arch/internal/app/audit"
 	"clean-arch/internal/dto"
 	"clean-arch/internal/factory"
 	"clean-arch/internal/model"
 	"clean-arch/internal/repository"
+	"clean-arch/pkg/clock"
+	"clean-arch/pkg/config"
 	"clean-arch/pkg/consts"
 	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/mailer"
+	"clean-arch/pkg/profile"
+	"clean-arch/pkg/storage"
 	"clean-arch/pkg/util"
 	"context"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"strings"
 	"time"
 
@@ -18,27 +25,74 @@ import (
 )
 
 type service struct {
-	UserRepository repository.User
+	UserRepository         repository.User
+	OtpRepository          repository.Otp
+	AuditRepository        repository.Audit
+	RedisRepository        repository.Redis
+	OrganizationRepository repository.Organization
+	AuditService           audit.Service
+	TxManager              dbutil.TxManager
+	Storage                storage.Storage
+	Clock                  clock.Clock
+	Mailer                 mailer.Mailer
+	ProfileSchema          profile.Schema
+	SecretKey              string
 }
 
 type Service interface {
 	Store(ctx context.Context, reqHandler dto.PayloadUser) error
-	FindAll(ctx context.Context, reqHandler dto.PayloadBasicTable) (*dto.ResponseUser, error)
+	FindAll(ctx context.Context, reqHandler dto.PayloadBasicTable, query dbutil.ParsedQuery) (*dto.ResponseUser, error)
 	FindOne(ctx context.Context, id int) (dto.User, error)
 	Update(ctx context.Context, id int, reqHandler dto.PayloadUpdateUser) error
 	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, before time.Time) (int, error)
+	Import(ctx context.Context, reqHandler dto.PayloadImportUser, records [][]string) (*dto.ResponseImportUser, error)
+	QueueExport(ctx context.Context, actorID int, reqHandler dto.PayloadExportUser, query dbutil.ParsedQuery) (*dto.ExportJob, error)
+	Export(ctx context.Context, reqHandler dto.PayloadExportUser, query dbutil.ParsedQuery, w io.Writer) error
+	FindExport(ctx context.Context, actorID int, id string) (*dto.ExportJob, error)
+	ExportFile(ctx context.Context, actorID int, id string) (string, *dto.ExportJob, error)
+	LoginHistory(ctx context.Context, userID int, reqHandler dto.PayloadLoginHistory) (*dto.ResponseLoginLog, error)
+	UpdateStatus(ctx context.Context, id int, actorID int, reqHandler dto.PayloadUserStatus) error
+	Deactivate(ctx context.Context, userID int, reqHandler dto.PayloadDeactivate) error
+	RequestEmailChange(ctx context.Context, userID int, sessionID int, reqHandler dto.PayloadChangeEmail) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	UpdateProfile(ctx context.Context, userID int, reqHandler dto.PayloadProfile) error
+	ChangePassword(ctx context.Context, userID int, sessionID int, reqHandler dto.PayloadChangePassword) error
+	UpdateAvatar(ctx context.Context, userID int, file *multipart.FileHeader) error
+	DeleteAvatar(ctx context.Context, userID int) error
+	UpdatePreferences(ctx context.Context, userID int, reqHandler dto.PayloadPreferences) (*profile.Resolved, error)
+	ProfileSchemaInfo() dto.ResponseProfileSchema
+	QueueDataExport(ctx context.Context, userID int) (*dto.ExportJob, error)
+	FindDataExport(ctx context.Context, userID int, id string) (*dto.ExportJob, error)
+	DataExportFile(ctx context.Context, userID int, id string) (string, *dto.ExportJob, error)
+	RequestErasure(ctx context.Context, userID int, reqHandler dto.PayloadErasure) (*dto.ResponseErasure, error)
+	Erase(ctx context.Context, now time.Time) (int, error)
+	Invite(ctx context.Context, actorID int, reqHandler dto.PayloadInviteUser) (*dto.User, error)
+	ResendInvite(ctx context.Context, actorID int, id int) (*dto.User, error)
+	RevokeInvite(ctx context.Context, actorID int, id int) error
+	AcceptInvite(ctx context.Context, reqHandler dto.PayloadAcceptUserInvite) error
 }
 
 func NewService(f *factory.Factory) Service {
 	return &service{
-		UserRepository: f.UserRepository,
+		UserRepository:         f.UserRepository,
+		OtpRepository:          f.OtpRepository,
+		AuditRepository:        f.AuditRepository,
+		RedisRepository:        f.RedisRepository,
+		OrganizationRepository: f.OrganizationRepository,
+		AuditService:           audit.NewService(f),
+		TxManager:              f.TxManager,
+		Storage:                f.Storage,
+		Clock:                  f.Clock,
+		Mailer:                 f.Mailer,
+		ProfileSchema:          config.ProfileSchema(),
+		SecretKey:              f.SecretKey,
 	}
 }
 
 func (s *service) Store(ctx context.Context, reqHandler dto.PayloadUser) error {
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
-
-	now := time.Now()
+	now := s.Clock.Now()
 
 	hashedPassword, err := util.HashPassword(reqHandler.Password)
 	if err != nil {
@@ -50,91 +104,132 @@ func (s *service) Store(ctx context.Context, reqHandler dto.PayloadUser) error {
 		Email:           reqHandler.Email,
 		EmailVerifiedAt: &now,
 		Password:        string(hashedPassword),
+		Role:            consts.RoleTypeUser,
+		Status:          consts.UserStatusActive,
 		PhoneNumber:     reqHandler.PhoneNumber,
 	}
 
-	if reqHandler.File != nil {
-		insertModel.ProfileImageURL = reqHandler.URL
-	}
-
-	existingEmail, err := s.UserRepository.FindOne(ctx, "email", dbutil.Where("email = ?", reqHandler.Email))
-	if err != nil {
-		if err != gorm.ErrRecordNotFound {
-			tx.Rollback()
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := s.UserRepository.Exists(ctx, dbutil.Where("email = ?", reqHandler.Email))
+		if err != nil {
 			return err
 		}
-	}
 
-	if existingEmail.Email != "" {
-		return fmt.Errorf("email already exists")
-	}
+		if exists {
+			return consts.EmailAlreadyExists
+		}
 
-	if err := s.UserRepository.Store(tx, insertModel); err != nil {
-		tx.Rollback()
+		if reqHandler.File != nil {
+			key, err := s.putAvatar(ctx, reqHandler.File)
+			if err != nil {
+				return err
+			}
+			insertModel.ProfileImageURL = key
+		}
+
+		return s.UserRepository.Store(ctx, &insertModel)
+	})
+	if err != nil {
+		s.deleteAvatar(ctx, insertModel.ProfileImageURL)
 		return err
 	}
-	tx.Commit()
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserCreated,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &insertModel.ID,
+		Metadata:   map[string]any{"email": insertModel.Email},
+	})
+
 	return nil
 }
 
-func (s *service) FindAll(ctx context.Context, reqHandler dto.PayloadBasicTable) (*dto.ResponseUser, error) {
-	var (
-		total dto.ResponseTotalRow
-		res   *dto.ResponseUser
-		users []dto.User
-		query string
-		args  []interface{}
-	)
-
-	if reqHandler.Search != "" {
-		query = "name LIKE ?"
-		args = append(args, "%"+reqHandler.Search+"%")
+func (s *service) FindAll(ctx context.Context, reqHandler dto.PayloadBasicTable, query dbutil.ParsedQuery) (*dto.ResponseUser, error) {
+	res := &dto.ResponseUser{
+		Data: []dto.User{},
 	}
 
-	count, err := s.UserRepository.Count(ctx, dbutil.Where(query, args...))
-	if err != nil {
-		return nil, err
+	if !reqHandler.SkipCount {
+		count, err := s.UserRepository.Count(ctx, query.Where...)
+		if err != nil {
+			return nil, err
+		}
+		res.TotalRow = &count
 	}
 
-	fetch, err := s.UserRepository.FindAll(ctx, "id, name, email, profile_image_url, email_verified_at, phone_number, created_at, updated_at", dbutil.Where(query, args...), dbutil.Limit(reqHandler.Limit), dbutil.Offset(reqHandler.Offset))
-	if err != nil {
-		return nil, err
+	selectedFields := query.Select
+	if selectedFields == "" {
+		selectedFields = listFields
 	}
 
-	for _, user := range fetch {
-		var emailVerifiedAt *string
-		if user.EmailVerifiedAt != nil {
-			formatted := user.EmailVerifiedAt.Format(consts.TimeFormatDateTime)
-			emailVerifiedAt = &formatted
-		}
-
-		users = append(users, dto.User{
-			ID:              user.ID,
-			Name:            user.Name,
-			Email:           user.Email,
-			EmailVerifiedAt: emailVerifiedAt,
-			ProfileImageURL: user.ProfileImageURL,
-			PhoneNumber:     user.PhoneNumber,
-			CreatedAt:       user.CreatedAt.Format(consts.TimeFormatDateTime),
-			UpdatedAt:       user.UpdatedAt.Format(consts.TimeFormatDateTime),
+	var (
+		fetch  []*model.User
+		keyset dbutil.Keyset
+		err    error
+	)
+
+	if reqHandler.UseCursor {
+		keyset, err = dbutil.NewKeyset(s.SecretKey, query.Order, reqHandler.Cursor, reqHandler.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		if query.Select != "" {
+			selectedFields = withSortColumns(selectedFields, keyset.Keys)
+		}
+
+		opts := append(append([]dbutil.QueryOption{}, query.Where...), keyset.Options()...)
+		fetch, err = s.UserRepository.FindAll(ctx, selectedFields, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		var page dbutil.PageInfo
+		fetch, page, err = dbutil.Paginate(keyset, fetch, func(user *model.User) []any {
+			return sortValues(keyset.Keys, user)
 		})
-	}
+		if err != nil {
+			return nil, err
+		}
+
+		res.NextCursor = page.NextCursor
+		res.PrevCursor = page.PrevCursor
+	} else {
+		opts := append(query.Options(), dbutil.Limit(reqHandler.Limit), dbutil.Offset(reqHandler.Offset))
 
-	total = dto.ResponseTotalRow{
-		TotalRow: count,
+		fetch, err = s.UserRepository.FindAll(ctx, selectedFields, opts...)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	res = &dto.ResponseUser{
-		ResponseTotalRow: total,
-		Data:             users,
+	for _, user := range fetch {
+		res.Data = append(res.Data, s.toUser(user))
 	}
 
 	return res, nil
 }
 
-func (s *service) Update(ctx context.Context, id int, reqHandler dto.PayloadUpdateUser) error {
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
+func (s *service) toUser(user *model.User) dto.User {
+	return dto.User{
+		ID:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerifiedAt: formatOptionalTime(user.EmailVerifiedAt),
+		ProfileImageURL: s.Storage.URL(user.ProfileImageURL),
+		ProfileImages:   s.avatarURLs(user.ProfileImageURL),
+		PhoneNumber:     user.PhoneNumber,
+		Status:          string(user.Status),
+		StatusReason:    user.StatusReason,
+		SuspendedUntil:  formatOptionalTime(user.SuspendedUntil),
+		InviteStatus:    user.InviteStatus(s.Clock.Now()),
+		InviteExpiresAt: formatOptionalTime(user.InviteExpiresAt),
+		CreatedAt:       user.CreatedAt.Format(consts.TimeFormatDateTime),
+		UpdatedAt:       user.UpdatedAt.Format(consts.TimeFormatDateTime),
+	}
+}
 
+func (s *service) Update(ctx context.Context, id int, reqHandler dto.PayloadUpdateUser) error {
 	user, err := s.UserRepository.FindOne(ctx, "*", dbutil.Where("id = ?", id))
 	if err != nil {
 		if err != gorm.ErrRecordNotFound {
@@ -142,29 +237,18 @@ func (s *service) Update(ctx context.Context, id int, reqHandler dto.PayloadUpda
 		}
 	}
 
-	oldLink := user.ProfileImageURL
-	appUrl := util.GetEnv("APP_URL", "http://localhost")
-	appPort := util.GetEnv("APP_PORT", "8080")
-
-	baseURL := fmt.Sprintf("%s:%s/", appUrl, appPort)
-	sanitizedLink := strings.Replace(user.ProfileImageURL, baseURL, "", 1)
-
-	if sanitizedLink != oldLink {
-		err = util.DeleteFile(sanitizedLink)
-		if err != nil {
-			fmt.Println(err.Error())
-		}
+	// the email needs the confirmation of RequestEmailChange, it isn't updated here
+	if reqHandler.Email != "" && !strings.EqualFold(reqHandler.Email, user.Email) {
+		return consts.EmailChangeRequired
 	}
 
+	oldAvatar := user.ProfileImageURL
+
 	updatedModel := model.User{
 		Name:        reqHandler.Name,
 		PhoneNumber: reqHandler.PhoneNumber,
 	}
 
-	if reqHandler.File != nil {
-		updatedModel.ProfileImageURL = reqHandler.URL
-	}
-
 	if reqHandler.NewPassword != "" {
 		if reqHandler.LastPassword == "" {
 			return fmt.Errorf("last password is required")
@@ -196,11 +280,38 @@ func (s *service) Update(ctx context.Context, id int, reqHandler dto.PayloadUpda
 		updatedModel.Password = string(hashedPassword)
 	}
 
-	if err := s.UserRepository.UpdateOne(tx, id, updatedModel); err != nil {
-		tx.Rollback()
+	if reqHandler.File != nil {
+		key, err := s.putAvatar(ctx, reqHandler.File)
+		if err != nil {
+			return err
+		}
+		updatedModel.ProfileImageURL = key
+	}
+
+	if err := s.UserRepository.UpdateOne(ctx, id, updatedModel); err != nil {
+		s.deleteAvatar(ctx, updatedModel.ProfileImageURL)
 		return err
 	}
-	tx.Commit()
+
+	if updatedModel.ProfileImageURL != "" {
+		s.deleteAvatar(ctx, oldAvatar)
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserUpdated,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &id,
+		Metadata:   map[string]any{"fields": changedFields(reqHandler)},
+	})
+
+	if updatedModel.Password != "" {
+		_ = s.AuditService.Record(ctx, dto.AuditEntry{
+			Event:      consts.AuditPasswordChanged,
+			TargetType: consts.AuditTargetUser,
+			TargetID:   &id,
+		})
+	}
+
 	return nil
 }
 
@@ -216,6 +327,8 @@ func (s *service) FindOne(ctx context.Context, id int) (dto.User, error) {
 		}
 	}
 
+	preferences := fetch.Preferences.Resolve()
+
 	var emailVerifiedAt *string
 	if fetch.EmailVerifiedAt != nil {
 		formatted := fetch.EmailVerifiedAt.Format(consts.TimeFormatDateTime)
@@ -228,42 +341,353 @@ func (s *service) FindOne(ctx context.Context, id int) (dto.User, error) {
 		Email:           fetch.Email,
 		EmailVerifiedAt: emailVerifiedAt,
 		PhoneNumber:     fetch.PhoneNumber,
-		ProfileImageURL: fetch.ProfileImageURL,
+		ProfileImageURL: s.Storage.URL(fetch.ProfileImageURL),
+		ProfileImages:   s.avatarURLs(fetch.ProfileImageURL),
+		Status:          string(fetch.Status),
+		StatusReason:    fetch.StatusReason,
+		SuspendedUntil:  formatOptionalTime(fetch.SuspendedUntil),
+		Preferences:     &preferences,
+		Attributes:      s.ProfileSchema.Resolve(fetch.Attributes),
 		CreatedAt:       fetch.CreatedAt.Format(consts.TimeFormatDateTime),
 	}
 
 	return res, nil
 }
 
+// Delete soft deletes the user and signs them out, the row and avatar are removed later by Purge
 func (s *service) Delete(ctx context.Context, id int) error {
-	tx := database.BeginTx(ctx, factory.NewFactory().InitDB)
+	user, err := s.UserRepository.FindOne(ctx, "id, email", dbutil.Where("id = ?", id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return consts.NotFoundDataUser
+		}
+		return err
+	}
 
-	user, err := s.UserRepository.FindOne(ctx, "*", dbutil.Where("id = ?", id))
+	err = s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.UserRepository.DeleteOne(ctx, id); err != nil {
+			return consts.FailedDeleteUser
+		}
+
+		return s.UserRepository.RevokeUserSessions(ctx, id)
+	})
 	if err != nil {
-		if err != gorm.ErrRecordNotFound {
-			return err
+		return err
+	}
+
+	_ = s.RedisRepository.Del(ctx, fmt.Sprintf("user_session-%d", id))
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserDeleted,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &id,
+		Metadata:   map[string]any{"email": user.Email},
+	})
+
+	return nil
+}
+
+// Restore brings back a soft deleted user unless the email was registered again in the meantime
+func (s *service) Restore(ctx context.Context, id int) error {
+	user, err := s.UserRepository.FindOne(ctx, "id, email", dbutil.Where("id = ?", id), dbutil.OnlyDeleted())
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return consts.NotFoundDataUser
 		}
+		return err
 	}
 
-	oldLink := user.ProfileImageURL
-	appUrl := util.GetEnv("APP_URL", "http://localhost")
-	appPort := util.GetEnv("APP_PORT", "8080")
+	count, err := s.UserRepository.Count(ctx, dbutil.Where("email = ?", user.Email))
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return consts.EmailAlreadyExists
+	}
+
+	if err := s.UserRepository.Restore(ctx, id); err != nil {
+		return consts.FailedRestoreUser
+	}
 
-	baseURL := fmt.Sprintf("%s:%s/", appUrl, appPort)
-	sanitizedLink := strings.Replace(user.ProfileImageURL, baseURL, "", 1)
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserRestored,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &id,
+		Metadata:   map[string]any{"email": user.Email},
+	})
 
-	if err := s.UserRepository.DeleteOne(tx, id); err != nil {
-		tx.Rollback()
+	return nil
+}
+
+// Purge hard deletes users soft deleted before the given time and removes their avatar files
+func (s *service) Purge(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+
+	for {
+		fetch, err := s.UserRepository.FindAll(ctx, "id, email, profile_image_url", dbutil.OnlyDeleted(), dbutil.Where("deleted_at < ?", before), dbutil.Order("id asc"), dbutil.Limit(purgeBatchSize))
+		if err != nil {
+			return purged, err
+		}
+
+		for _, user := range fetch {
+			if err := s.purgeOne(ctx, user); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(fetch) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (s *service) purgeOne(ctx context.Context, user *model.User) error {
+	err := s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.OrganizationRepository.RemoveUser(ctx, user.ID); err != nil {
+			return err
+		}
+
+		return s.UserRepository.ForceDelete(ctx, user.ID)
+	})
+	if err != nil {
 		return err
 	}
 
-	if sanitizedLink != oldLink {
-		err = util.DeleteFile(sanitizedLink)
+	s.deleteAvatar(ctx, user.ProfileImageURL)
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserPurged,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &user.ID,
+	})
+
+	return nil
+}
+
+// changedFields lists the profile fields sent in an update request for the audit trail
+func changedFields(reqHandler dto.PayloadUpdateUser) []string {
+	fields := []string{}
+
+	if reqHandler.Name != "" {
+		fields = append(fields, "name")
+	}
+
+	if reqHandler.PhoneNumber != "" {
+		fields = append(fields, "phone_number")
+	}
+
+	if reqHandler.File != nil {
+		fields = append(fields, "profile_image_url")
+	}
+
+	if reqHandler.NewPassword != "" {
+		fields = append(fields, "password")
+	}
+
+	return fields
+}
+
+func (s *service) LoginHistory(ctx context.Context, userID int, reqHandler dto.PayloadLoginHistory) (*dto.ResponseLoginLog, error) {
+	opts := []dbutil.QueryOption{
+		dbutil.Where("user_id = ?", userID),
+	}
+
+	if reqHandler.IP != "" {
+		opts = append(opts, dbutil.Where("ip_address = ?", reqHandler.IP))
+	}
+
+	if reqHandler.Device != "" {
+		opts = append(opts, dbutil.Where("device = ?", reqHandler.Device))
+	}
+
+	if reqHandler.Browser != "" {
+		opts = append(opts, dbutil.Where("browser = ?", reqHandler.Browser))
+	}
+
+	if reqHandler.OS != "" {
+		opts = append(opts, dbutil.Where("os = ?", reqHandler.OS))
+	}
+
+	if reqHandler.Status != "" {
+		opts = append(opts, dbutil.Where("status = ?", reqHandler.Status))
+	}
+
+	dateOpts, err := dbutil.DateRange("created_at", reqHandler.DateFrom, reqHandler.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, dateOpts...)
+
+	res := &dto.ResponseLoginLog{
+		Data: []dto.LoginLog{},
+	}
+
+	if !reqHandler.SkipCount {
+		count, err := s.UserRepository.CountLoginLog(ctx, opts...)
 		if err != nil {
-			fmt.Println(err.Error())
+			return nil, err
 		}
+		res.TotalRow = &count
 	}
 
-	tx.Commit()
+	var fetch []*model.LoginLog
+
+	if reqHandler.UseCursor {
+		keyset, err := dbutil.NewKeyset(s.SecretKey, "created_at desc, id desc", reqHandler.Cursor, reqHandler.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		fetch, err = s.UserRepository.FindAllLoginLog(ctx, "*", append(opts, keyset.Options()...)...)
+		if err != nil {
+			return nil, err
+		}
+
+		var page dbutil.PageInfo
+		fetch, page, err = dbutil.Paginate(keyset, fetch, func(loginLog *model.LoginLog) []any {
+			return []any{loginLog.CreatedAt, loginLog.ID}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		res.NextCursor = page.NextCursor
+		res.PrevCursor = page.PrevCursor
+	} else {
+		opts = append(opts, dbutil.Order("created_at desc, id desc"), dbutil.Limit(reqHandler.Limit), dbutil.Offset(reqHandler.Offset))
+
+		fetch, err = s.UserRepository.FindAllLoginLog(ctx, "*", opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, loginLog := range fetch {
+		res.Data = append(res.Data, toLoginLog(loginLog))
+	}
+
+	return res, nil
+}
+
+func toLoginLog(loginLog *model.LoginLog) dto.LoginLog {
+	return dto.LoginLog{
+		ID:            loginLog.ID,
+		UserID:        loginLog.UserID,
+		IPAddress:     loginLog.IPAddress,
+		UserAgent:     loginLog.UserAgent,
+		Browser:       loginLog.Browser,
+		OS:            loginLog.OS,
+		Device:        loginLog.Device,
+		Country:       loginLog.Country,
+		Region:        loginLog.Region,
+		City:          loginLog.City,
+		Status:        string(loginLog.Status),
+		FailureReason: loginLog.FailureReason,
+		CreatedAt:     loginLog.CreatedAt.Format(consts.TimeFormatDateTime),
+	}
+}
+
+// UpdateStatus lets an admin suspend, ban, deactivate or reactivate a user, every status but active revokes the sessions
+func (s *service) UpdateStatus(ctx context.Context, id int, actorID int, reqHandler dto.PayloadUserStatus) error {
+	status := consts.UserStatus(reqHandler.Status)
+
+	var suspendedUntil *time.Time
+	if status == consts.UserStatusSuspended && reqHandler.SuspendedUntil != "" {
+		until, err := time.ParseInLocation(consts.TimeFormatDateTime, reqHandler.SuspendedUntil, config.AppLocation())
+		if err != nil {
+			return fmt.Errorf("invalid suspended_until, please use format yyyy-mm-dd hh:mm:ss")
+		}
+
+		if !until.After(s.Clock.Now()) {
+			return consts.SuspendedUntilPast
+		}
+		suspendedUntil = &until
+	}
+
+	user, err := s.UserRepository.FindOne(ctx, "id, status", dbutil.Where("id = ?", id))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	if user.Status == consts.UserStatusInvited {
+		return consts.InvitedUserStatus
+	}
+
+	return s.changeStatus(ctx, user, actorID, status, reqHandler.Reason, suspendedUntil)
+}
+
+// Deactivate is the user closing their own account, signing in again reactivates it
+func (s *service) Deactivate(ctx context.Context, userID int, reqHandler dto.PayloadDeactivate) error {
+	user, err := s.UserRepository.FindOne(ctx, "id, password, status", dbutil.Where("id = ?", userID))
+	if err != nil {
+		return consts.NotFoundDataUser
+	}
+
+	match, err := util.VerifyPassword(reqHandler.Password, user.Password)
+	if err != nil || !match {
+		return consts.InvalidPassword
+	}
+
+	return s.changeStatus(ctx, user, userID, consts.UserStatusDeactivated, reqHandler.Reason, nil)
+}
+
+func (s *service) changeStatus(ctx context.Context, user model.User, actorID int, status consts.UserStatus, reason string, suspendedUntil *time.Time) error {
+	now := s.Clock.Now()
+
+	err := s.TxManager.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.UserRepository.UpdateColumns(ctx, user.ID, map[string]any{
+			"status":            status,
+			"status_reason":     reason,
+			"suspended_until":   suspendedUntil,
+			"status_changed_by": actorID,
+			"status_changed_at": now,
+		})
+		if err != nil {
+			return consts.FailedUpdateUser
+		}
+
+		if status != consts.UserStatusActive {
+			if err := s.UserRepository.RevokeUserSessions(ctx, user.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the cached session data holds the status checked by Authenticate
+	_ = s.RedisRepository.Del(ctx, fmt.Sprintf("user_session-%d", user.ID))
+
+	metadata := map[string]any{
+		"from":   user.Status,
+		"to":     status,
+		"reason": reason,
+	}
+	if suspendedUntil != nil {
+		metadata["suspended_until"] = suspendedUntil.Format(consts.TimeFormatDateTime)
+	}
+
+	_ = s.AuditService.Record(ctx, dto.AuditEntry{
+		Event:      consts.AuditUserStatus,
+		ActorID:    &actorID,
+		TargetType: consts.AuditTargetUser,
+		TargetID:   &user.ID,
+		Metadata:   metadata,
+	})
+
 	return nil
 }
+
+func formatOptionalTime(val *time.Time) *string {
+	if val == nil {
+		return nil
+	}
+
+	formatted := val.Format(consts.TimeFormatDateTime)
+	return &formatted
+}
@@ -0,0 +1,308 @@
+package user_test
+
+import (
+	"clean-arch/internal/app/user"
+	"clean-arch/internal/dto"
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository/memory"
+	"clean-arch/pkg/clock"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/mailer"
+	"clean-arch/pkg/storage"
+	"clean-arch/pkg/util"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	// the email templates are read relative to the repository root
+	if err := os.Chdir("../../.."); err != nil {
+		panic(err)
+	}
+	util.SetPasswordParams(util.PasswordParams{Memory: 1024})
+
+	os.Exit(m.Run())
+}
+
+func setup(t *testing.T) (user.Service, *memory.DB, *clock.Fake) {
+	c := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	db := memory.NewDB(c)
+	store := storage.NewLocal(t.TempDir(), "http://localhost/storage", "secret")
+
+	return user.NewService(memory.NewFactory(db, &mailer.Recorder{}, store)), db, c
+}
+
+func store(t *testing.T, svc user.Service, name string, email string) {
+	err := svc.Store(context.Background(), dto.PayloadUser{
+		Name:        name,
+		Email:       email,
+		Password:    "secret123",
+		PhoneNumber: "08123456789",
+	})
+	assert.Nil(t, err)
+}
+
+func TestStore(t *testing.T) {
+	svc, db, c := setup(t)
+
+	store(t, svc, "Budi", "budi@example.com")
+
+	users := memory.Rows[model.User](db)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "Budi", users[0].Name)
+	assert.Equal(t, consts.UserStatusActive, users[0].Status)
+	assert.Equal(t, c.Now(), *users[0].EmailVerifiedAt)
+
+	match, err := util.VerifyPassword("secret123", users[0].Password)
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	events := memory.Rows[model.AuditEvent](db)
+	assert.Len(t, events, 1)
+	assert.Equal(t, consts.AuditUserCreated, events[0].Event)
+
+	err = svc.Store(context.Background(), dto.PayloadUser{Name: "Budi", Email: "budi@example.com", Password: "secret123"})
+	assert.Equal(t, consts.EmailAlreadyExists, err)
+	assert.Len(t, memory.Rows[model.User](db), 1)
+}
+
+func TestFindOne(t *testing.T) {
+	svc, db, _ := setup(t)
+	store(t, svc, "Budi", "budi@example.com")
+	id := memory.Rows[model.User](db)[0].ID
+
+	res, err := svc.FindOne(context.Background(), id)
+	assert.Nil(t, err)
+	assert.Equal(t, id, res.ID)
+	assert.Equal(t, "Budi", res.Name)
+	assert.Equal(t, "budi@example.com", res.Email)
+	assert.Equal(t, "08123456789", res.PhoneNumber)
+	assert.Equal(t, string(consts.UserStatusActive), res.Status)
+	assert.NotNil(t, res.EmailVerifiedAt)
+	assert.NotNil(t, res.Preferences)
+}
+
+func TestUpdate(t *testing.T) {
+	svc, db, c := setup(t)
+	ctx := context.Background()
+	store(t, svc, "Budi", "budi@example.com")
+	id := memory.Rows[model.User](db)[0].ID
+
+	c.Advance(time.Minute)
+	err := svc.Update(ctx, id, dto.PayloadUpdateUser{Name: "Budi Santoso"})
+	assert.Nil(t, err)
+
+	users := memory.Rows[model.User](db)
+	assert.Equal(t, "Budi Santoso", users[0].Name)
+	// the zero values of the payload are left as they were
+	assert.Equal(t, "08123456789", users[0].PhoneNumber)
+	assert.Equal(t, c.Now(), users[0].UpdatedAt)
+
+	err = svc.Update(ctx, id, dto.PayloadUpdateUser{Email: "other@example.com"})
+	assert.Equal(t, consts.EmailChangeRequired, err)
+}
+
+func TestUpdatePassword(t *testing.T) {
+	svc, db, _ := setup(t)
+	ctx := context.Background()
+	store(t, svc, "Budi", "budi@example.com")
+	id := memory.Rows[model.User](db)[0].ID
+
+	err := svc.Update(ctx, id, dto.PayloadUpdateUser{NewPassword: "newsecret123"})
+	assert.EqualError(t, err, "last password is required")
+
+	err = svc.Update(ctx, id, dto.PayloadUpdateUser{NewPassword: "newsecret123", LastPassword: "wrong"})
+	assert.EqualError(t, err, "last password not match")
+
+	err = svc.Update(ctx, id, dto.PayloadUpdateUser{NewPassword: "newsecret123", LastPassword: "secret123"})
+	assert.Nil(t, err)
+
+	match, err := util.VerifyPassword("newsecret123", memory.Rows[model.User](db)[0].Password)
+	assert.Nil(t, err)
+	assert.True(t, match)
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	svc, db, _ := setup(t)
+	ctx := context.Background()
+	store(t, svc, "Budi", "budi@example.com")
+	id := memory.Rows[model.User](db)[0].ID
+
+	err := memory.NewUserRepository(db).CreateSession(ctx, &model.UserSession{UserID: id, Revoked: consts.SessionActive})
+	assert.Nil(t, err)
+
+	assert.Nil(t, svc.Delete(ctx, id))
+	assert.True(t, memory.Rows[model.User](db)[0].DeletedAt.Valid)
+	assert.Equal(t, consts.SessionRevoked, memory.Rows[model.UserSession](db)[0].Revoked)
+
+	res, err := svc.FindOne(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, res.ID)
+
+	assert.Equal(t, consts.NotFoundDataUser, svc.Delete(ctx, id))
+
+	assert.Nil(t, svc.Restore(ctx, id))
+	assert.False(t, memory.Rows[model.User](db)[0].DeletedAt.Valid)
+
+	res, err = svc.FindOne(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, id, res.ID)
+
+	assert.Equal(t, consts.NotFoundDataUser, svc.Restore(ctx, id))
+}
+
+func TestRestoreEmailTaken(t *testing.T) {
+	svc, db, _ := setup(t)
+	ctx := context.Background()
+	store(t, svc, "Budi", "budi@example.com")
+	id := memory.Rows[model.User](db)[0].ID
+
+	assert.Nil(t, svc.Delete(ctx, id))
+
+	// the email was registered again after the delete
+	store(t, svc, "Budi Baru", "budi@example.com")
+
+	assert.Equal(t, consts.EmailAlreadyExists, svc.Restore(ctx, id))
+	assert.True(t, memory.Rows[model.User](db)[0].DeletedAt.Valid)
+}
+
+// join adds the user to a new organization
+func join(t *testing.T, db *memory.DB, userID int, slug string) {
+	ctx := context.Background()
+	orgs := memory.NewOrganizationRepository(db)
+
+	org := model.Organization{Name: slug, Slug: slug}
+	assert.Nil(t, orgs.Store(ctx, &org))
+	assert.Nil(t, orgs.StoreMember(dbutil.WithTenant(ctx, org.ID), &model.OrganizationMember{UserID: userID, Role: consts.OrgRoleMember}))
+}
+
+func TestPurge(t *testing.T) {
+	svc, db, c := setup(t)
+	ctx := context.Background()
+	store(t, svc, "Budi", "budi@example.com")
+	store(t, svc, "Andi", "andi@example.com")
+	budi, andi := memory.Rows[model.User](db)[0].ID, memory.Rows[model.User](db)[1].ID
+	join(t, db, budi, "acme")
+	join(t, db, andi, "globex")
+
+	assert.Nil(t, svc.Delete(ctx, budi))
+	c.Advance(2 * time.Hour)
+	assert.Nil(t, svc.Delete(ctx, andi))
+
+	// only the users deleted before the cut off are purged
+	purged, err := svc.Purge(ctx, c.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+
+	users := memory.Rows[model.User](db)
+	assert.Len(t, users, 1)
+	assert.Equal(t, andi, users[0].ID)
+
+	members := memory.Rows[model.OrganizationMember](db)
+	assert.Len(t, members, 1)
+	assert.Equal(t, andi, members[0].UserID)
+}
+
+func TestErase(t *testing.T) {
+	svc, db, c := setup(t)
+	ctx := context.Background()
+	store(t, svc, "Budi", "budi@example.com")
+	id := memory.Rows[model.User](db)[0].ID
+	join(t, db, id, "acme")
+
+	_, err := svc.RequestErasure(ctx, id, dto.PayloadErasure{Password: "secret123"})
+	assert.Nil(t, err)
+
+	// nothing is erased during the grace period
+	erased, err := svc.Erase(ctx, c.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, erased)
+	assert.Len(t, memory.Rows[model.User](db), 1)
+
+	c.Advance(15 * 24 * time.Hour)
+	erased, err = svc.Erase(ctx, c.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, erased)
+
+	assert.Empty(t, memory.Rows[model.User](db))
+	assert.Empty(t, memory.Rows[model.OrganizationMember](db))
+
+	receipts := memory.Rows[model.ErasureReceipt](db)
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, id, receipts[0].UserID)
+}
+
+func TestFindAll(t *testing.T) {
+	svc, db, c := setup(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Andi", "Budi", "Citra", "Dewi"} {
+		store(t, svc, name, name+"@example.com")
+		c.Advance(time.Minute)
+	}
+	assert.Nil(t, svc.Delete(ctx, memory.Rows[model.User](db)[3].ID))
+
+	res, err := svc.FindAll(ctx, dto.PayloadBasicTable{Limit: 2}, dbutil.ParsedQuery{Order: "created_at desc"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, *res.TotalRow)
+	assert.Len(t, res.Data, 2)
+	assert.Equal(t, "Citra", res.Data[0].Name)
+	assert.Equal(t, "Budi", res.Data[1].Name)
+
+	res, err = svc.FindAll(ctx, dto.PayloadBasicTable{Limit: 2, Offset: 2}, dbutil.ParsedQuery{Order: "created_at desc"})
+	assert.Nil(t, err)
+	assert.Len(t, res.Data, 1)
+	assert.Equal(t, "Andi", res.Data[0].Name)
+
+	query := dbutil.ParsedQuery{Where: []dbutil.QueryOption{dbutil.Where("LOWER(name) LIKE ?", "%i%")}, Order: "name asc"}
+	res, err = svc.FindAll(ctx, dto.PayloadBasicTable{Limit: 10}, query)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, *res.TotalRow)
+	assert.Equal(t, "Andi", res.Data[0].Name)
+
+	res, err = svc.FindAll(ctx, dto.PayloadBasicTable{Limit: 10}, dbutil.ParsedQuery{Where: []dbutil.QueryOption{dbutil.Where("name = ?", "Citra")}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, *res.TotalRow)
+	assert.Equal(t, "Citra@example.com", res.Data[0].Email)
+}
+
+func TestRequestEmailChangeIgnoresCase(t *testing.T) {
+	svc, db, _ := setup(t)
+	ctx := context.Background()
+	store(t, svc, "Budi", "budi@example.com")
+	id := memory.Rows[model.User](db)[0].ID
+	assert.Nil(t, memory.NewUserRepository(db).Store(ctx, &model.User{Name: "John", Email: "John@Example.com"}))
+
+	err := svc.RequestEmailChange(ctx, id, 0, dto.PayloadChangeEmail{Email: "john@example.com", Password: "secret123"})
+	assert.Equal(t, consts.EmailAlreadyExists, err)
+
+	// the new address is stored lower-cased
+	err = svc.RequestEmailChange(ctx, id, 0, dto.PayloadChangeEmail{Email: " New@Example.com ", Password: "secret123"})
+	assert.Nil(t, err)
+
+	events := memory.Rows[model.AuditEvent](db)
+	assert.Equal(t, consts.AuditEmailRequested, events[len(events)-1].Event)
+	assert.Contains(t, events[len(events)-1].Metadata, `"to":"new@example.com"`)
+}
+
+func TestUpdateStatusSuspendedUntil(t *testing.T) {
+	svc, db, _ := setup(t)
+	ctx := context.Background()
+	store(t, svc, "Budi", "budi@example.com")
+	id := memory.Rows[model.User](db)[0].ID
+
+	// the date is read in the zone the api reports dates in, whatever the zone of the server
+	err := svc.UpdateStatus(ctx, id, 99, dto.PayloadUserStatus{Status: string(consts.UserStatusSuspended), SuspendedUntil: "2026-01-06 08:00:00"})
+	assert.Nil(t, err)
+
+	until := memory.Rows[model.User](db)[0].SuspendedUntil
+	if assert.NotNil(t, until) {
+		assert.True(t, time.Date(2026, 1, 6, 8, 0, 0, 0, config.AppLocation()).Equal(*until))
+	}
+}
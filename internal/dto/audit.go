@@ -0,0 +1,44 @@
+package dto
+
+import "clean-arch/pkg/consts"
+
+type (
+	AuditEntry struct {
+		Event      consts.AuditEvent
+		ActorID    *int
+		TargetType string
+		TargetID   *int
+		IP         string
+		UserAgent  string
+		Metadata   map[string]any
+	}
+
+	PayloadAuditFilter struct {
+		Limit      int    `form:"limit"`
+		Offset     int    `form:"offset"`
+		Event      string `form:"event"`
+		ActorID    int    `form:"actor_id"`
+		TargetType string `form:"target_type"`
+		TargetID   int    `form:"target_id"`
+		IP         string `form:"ip"`
+		DateFrom   string `form:"date_from"`
+		DateTo     string `form:"date_to"`
+	}
+
+	AuditEvent struct {
+		ID         int            `json:"id"`
+		Event      string         `json:"event"`
+		ActorID    *int           `json:"actor_id"`
+		TargetType string         `json:"target_type"`
+		TargetID   *int           `json:"target_id"`
+		IPAddress  string         `json:"ip_address"`
+		UserAgent  string         `json:"user_agent"`
+		Metadata   map[string]any `json:"metadata"`
+		CreatedAt  string         `json:"created_at"`
+	}
+
+	ResponseAuditEvent struct {
+		Data []AuditEvent `json:"data"`
+		ResponseTotalRow
+	}
+)
@@ -13,9 +13,25 @@ type (
 		Limit  int    `json:"limit"`
 		Offset int    `json:"offset"`
 		Search string `json:"search"`
+		PayloadCursor
+	}
+
+	// PayloadCursor switches a list to keyset pagination, the cursor param may be empty for the first page
+	PayloadCursor struct {
+		Cursor    string `form:"cursor" json:"cursor"`
+		UseCursor bool   `form:"-" json:"-"`
+		SkipCount bool   `form:"-" json:"-"`
 	}
 
 	ResponseTotalRow struct {
 		TotalRow int `json:"total_row"`
 	}
+
+	// ResponsePage is a list response for offset and cursor pagination, total_row is left out when the count was skipped
+	ResponsePage[T any] struct {
+		Data       []T    `json:"data"`
+		TotalRow   *int   `json:"total_row,omitempty"`
+		NextCursor string `json:"next_cursor,omitempty"`
+		PrevCursor string `json:"prev_cursor,omitempty"`
+	}
 )
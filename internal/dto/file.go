@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"clean-arch/pkg/storage"
+	"io/fs"
+)
+
+type (
+	PayloadFile struct {
+		Expires   string `form:"expires"`
+		Signature string `form:"signature"`
+	}
+
+	// FileDownload is either an opened file or, for storages that can't be opened, a link to redirect to
+	FileDownload struct {
+		File        storage.File
+		Info        fs.FileInfo
+		RedirectURL string
+		Private     bool
+	}
+)
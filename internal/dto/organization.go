@@ -0,0 +1,66 @@
+package dto
+
+import "clean-arch/pkg/consts"
+
+type (
+	// Tenant is the organization of the request and the role of the user in it, set by middleware.Tenant
+	Tenant struct {
+		OrganizationID int            `json:"organization_id"`
+		Role           consts.OrgRole `json:"role"`
+	}
+
+	// PayloadOrganization creates an organization, the slug is made from the name when empty
+	PayloadOrganization struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	}
+
+	PayloadUpdateOrganization struct {
+		Name string `json:"name"`
+	}
+
+	// PayloadSwitchOrganization puts the organization in the access tokens of the session, 0 clears it
+	PayloadSwitchOrganization struct {
+		OrganizationID int `json:"organization_id"`
+	}
+
+	PayloadOrgInvite struct {
+		Email string         `json:"email"`
+		Role  consts.OrgRole `json:"role"`
+	}
+
+	PayloadAcceptInvite struct {
+		Token string `json:"token"`
+	}
+
+	PayloadMemberRole struct {
+		Role consts.OrgRole `json:"role"`
+	}
+
+	Organization struct {
+		ID        int            `json:"id"`
+		Name      string         `json:"name"`
+		Slug      string         `json:"slug"`
+		Role      consts.OrgRole `json:"role,omitempty"`
+		CreatedAt string         `json:"created_at"`
+	}
+
+	OrganizationMember struct {
+		ID       int            `json:"id"`
+		UserID   int            `json:"user_id"`
+		Name     string         `json:"name"`
+		Email    string         `json:"email"`
+		Role     consts.OrgRole `json:"role"`
+		JoinedAt string         `json:"joined_at"`
+	}
+
+	OrganizationInvite struct {
+		ID        int            `json:"id"`
+		Email     string         `json:"email"`
+		Role      consts.OrgRole `json:"role"`
+		InvitedBy *int           `json:"invited_by"`
+		Expired   bool           `json:"expired"`
+		ExpiresAt string         `json:"expires_at"`
+		CreatedAt string         `json:"created_at"`
+	}
+)
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"clean-arch/pkg/profile"
 	"mime/multipart"
 	"time"
 )
@@ -14,7 +15,6 @@ type (
 		Password        string                `form:"password" binding:"required"`
 		PhoneNumber     string                `form:"phone_number"`
 		File            *multipart.FileHeader `form:"file"`
-		URL             string                `form:"url"`
 	}
 
 	PayloadUpdateUser struct {
@@ -22,25 +22,125 @@ type (
 		Name         string                `form:"name"`
 		Email        string                `form:"email"`
 		File         *multipart.FileHeader `form:"file"`
-		URL          string                `form:"url"`
 		NewPassword  string                `form:"new_password"`
 		LastPassword string                `form:"last_password"`
 		PhoneNumber  string                `form:"phone_number"`
 	}
 
+	PayloadUserStatus struct {
+		Status         string `json:"status"`
+		Reason         string `json:"reason"`
+		SuspendedUntil string `json:"suspended_until"`
+	}
+
+	PayloadDeactivate struct {
+		Password string `json:"password"`
+		Reason   string `json:"reason"`
+	}
+
+	// PayloadProfile only changes the fields that are sent, an empty phone_number clears it and a null attribute removes it
+	PayloadProfile struct {
+		Name        *string        `json:"name"`
+		PhoneNumber *string        `json:"phone_number"`
+		Email       *string        `json:"email"`
+		Attributes  map[string]any `json:"attributes"`
+	}
+
+	PayloadPreferences = profile.Patch
+
+	ResponseProfileSchema struct {
+		Locales     []string         `json:"locales"`
+		Themes      []string         `json:"themes"`
+		Preferences profile.Resolved `json:"preferences"`
+		Attributes  profile.Schema   `json:"attributes"`
+	}
+
+	PayloadChangePassword struct {
+		LastPassword string `json:"last_password"`
+		NewPassword  string `json:"new_password"`
+	}
+
+	PayloadAvatar struct {
+		File *multipart.FileHeader `form:"file"`
+	}
+
+	PayloadErasure struct {
+		Password string `json:"password"`
+		Reason   string `json:"reason"`
+	}
+
+	ResponseErasure struct {
+		EraseAfter string `json:"erase_after"`
+	}
+
+	PayloadInviteUser struct {
+		Email string `json:"email"`
+		Name  string `json:"name"`
+		Role  string `json:"role"`
+	}
+
+	PayloadAcceptUserInvite struct {
+		Token    string `json:"token"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+
+	PayloadChangeEmail struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
 	User struct {
-		ID              int     `json:"id"`
-		Name            string  `json:"name" binding:"required"`
-		Email           string  `json:"email" binding:"required"`
-		EmailVerifiedAt *string `json:"email_verified_at"`
-		ProfileImageURL string  `json:"profile_image_url"`
-		PhoneNumber     string  `json:"phone_number"`
-		CreatedAt       string  `json:"created_at"`
-		UpdatedAt       string  `json:"updated_at"`
+		ID              int               `json:"id"`
+		Name            string            `json:"name" binding:"required"`
+		Email           string            `json:"email" binding:"required"`
+		EmailVerifiedAt *string           `json:"email_verified_at"`
+		ProfileImageURL string            `json:"profile_image_url"`
+		ProfileImages   map[string]string `json:"profile_images,omitempty"`
+		Preferences     *profile.Resolved `json:"preferences,omitempty"`
+		Attributes      map[string]any    `json:"attributes,omitempty"`
+		PhoneNumber     string            `json:"phone_number"`
+		Status          string            `json:"status"`
+		StatusReason    string            `json:"status_reason,omitempty"`
+		SuspendedUntil  *string           `json:"suspended_until,omitempty"`
+		InviteStatus    string            `json:"invite_status,omitempty"`
+		InviteExpiresAt *string           `json:"invite_expires_at,omitempty"`
+		CreatedAt       string            `json:"created_at"`
+		UpdatedAt       string            `json:"updated_at"`
 	}
 
-	ResponseUser struct {
-		Data []User `json:"data"`
-		ResponseTotalRow
+	ResponseUser = ResponsePage[User]
+)
+
+type (
+	PayloadLoginHistory struct {
+		Limit    int    `form:"limit"`
+		Offset   int    `form:"offset"`
+		DateFrom string `form:"date_from"`
+		DateTo   string `form:"date_to"`
+		IP       string `form:"ip"`
+		Device   string `form:"device"`
+		Browser  string `form:"browser"`
+		OS       string `form:"os"`
+		Status   string `form:"status"`
+		PayloadCursor
 	}
+
+	LoginLog struct {
+		ID            int    `json:"id"`
+		UserID        int    `json:"user_id"`
+		IPAddress     string `json:"ip_address"`
+		UserAgent     string `json:"user_agent"`
+		Browser       string `json:"browser"`
+		OS            string `json:"os"`
+		Device        string `json:"device"`
+		Country       string `json:"country"`
+		Region        string `json:"region"`
+		City          string `json:"city"`
+		Status        string `json:"status"`
+		FailureReason string `json:"failure_reason"`
+		CreatedAt     string `json:"created_at"`
+	}
+
+	ResponseLoginLog = ResponsePage[LoginLog]
 )
@@ -0,0 +1,21 @@
+package dto
+
+type (
+	PayloadExportUser struct {
+		Format string `form:"format"`
+	}
+
+	// ExportJob is an export written in the background, DownloadURL is set once the file is ready
+	ExportJob struct {
+		ID          string `json:"id"`
+		Status      string `json:"status"`
+		Format      string `json:"format"`
+		TotalRow    int    `json:"total_row"`
+		FileName    string `json:"file_name"`
+		DownloadURL string `json:"download_url,omitempty"`
+		Error       string `json:"error,omitempty"`
+		CreatedBy   int    `json:"created_by"`
+		CreatedAt   string `json:"created_at"`
+		ExpiresAt   string `json:"expires_at"`
+	}
+)
@@ -0,0 +1,25 @@
+package dto
+
+type (
+	PayloadImportUser struct {
+		// Partial inserts the valid rows even when other rows fail, otherwise one invalid row rejects the whole file
+		Partial    bool `form:"partial"`
+		SendInvite bool `form:"send_invite"`
+		// WaitInvites sends the emails before returning, for callers that exit right after like the cli
+		WaitInvites bool `form:"-"`
+	}
+
+	ImportUserRow struct {
+		Row    int      `json:"row"`
+		Email  string   `json:"email"`
+		Status string   `json:"status"`
+		Errors []string `json:"errors,omitempty"`
+	}
+
+	ResponseImportUser struct {
+		Total   int             `json:"total"`
+		Created int             `json:"created"`
+		Failed  int             `json:"failed"`
+		Rows    []ImportUserRow `json:"rows"`
+	}
+)
@@ -7,19 +7,30 @@ import (
 
 type (
 	UserSession struct {
-		UserID           int                  `json:"user_id"`
-		IPAddress        string               `json:"ip_address"`
-		RefreshTokenHash string               `json:"refresh_token_hash"`
-		ExpiresAt        string               `json:"expires_at"`
-		Revoked          consts.SessionStatus `json:"revoked"`
+		ID        int                  `json:"id"`
+		UserID    int                  `json:"user_id"`
+		IPAddress string               `json:"ip_address"`
+		UserAgent string               `json:"user_agent"`
+		Browser   string               `json:"browser"`
+		OS        string               `json:"os"`
+		Device    string               `json:"device"`
+		Country   string               `json:"country"`
+		Region    string               `json:"region"`
+		City      string               `json:"city"`
+		CreatedAt string               `json:"created_at"`
+		ExpiresAt string               `json:"expires_at"`
+		Revoked   consts.SessionStatus `json:"revoked"`
 	}
 
 	JwtSession struct {
-		ID              int        `json:"id"`
-		Name            string     `json:"name"`
-		Email           string     `json:"email"`
-		EmailVerifiedAt *time.Time `json:"email_verified_at"`
-		PhoneNumber     string     `json:"phone_number"`
-		CreatedAt       time.Time  `json:"created_at"`
+		ID              int               `json:"id"`
+		Name            string            `json:"name"`
+		Email           string            `json:"email"`
+		EmailVerifiedAt *time.Time        `json:"email_verified_at"`
+		PhoneNumber     string            `json:"phone_number"`
+		Role            consts.RoleType   `json:"role"`
+		Status          consts.UserStatus `json:"status"`
+		SuspendedUntil  *time.Time        `json:"suspended_until"`
+		CreatedAt       time.Time         `json:"created_at"`
 	}
 )
@@ -1,32 +1,87 @@
 package factory
 
 import (
-	"clean-arch/database"
 	"clean-arch/internal/repository"
+	"clean-arch/pkg/clock"
+	"clean-arch/pkg/config"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/geoip"
+	"clean-arch/pkg/mailer"
+	"clean-arch/pkg/storage"
+	"clean-arch/pkg/token"
+	"errors"
+	"fmt"
+	"log"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
+/*
+Factory is the application container, main builds it once and passes it to the handlers and
+middleware, which hand it to the services they construct. The connections, clock, mailer, token
+issuer and secret key come from here so a test can build a Factory from fakes, the services still
+read their plain settings such as durations and URLs from config
+*/
 type Factory struct {
-	RedisClient     *redis.Client
-	InitDB          *gorm.DB
-	UserRepository  repository.User
-	OtpRepository   repository.Otp
-	RedisRepository repository.Redis
+	InitDB                 *gorm.DB
+	TxManager              dbutil.TxManager
+	UserRepository         repository.User
+	OtpRepository          repository.Otp
+	RedisRepository        repository.Redis
+	AuditRepository        repository.Audit
+	OrganizationRepository repository.Organization
+	Storage                storage.Storage
+	Clock                  clock.Clock
+	Mailer                 mailer.Mailer
+	TokenIssuer            token.Issuer
+	// SecretKey signs the links, cursors and tokens the services hand out
+	SecretKey string
+	// GeoIP is nil when no database is configured, lookups then find nothing
+	GeoIP *geoip.DB
 }
 
-func NewFactory() *Factory {
-	// Check db connection
-	db := database.GetConnection()
-	rdb := database.GetRedisClient()
+func NewFactory(db *gorm.DB, rdb *redis.Client) (*Factory, error) {
+	secretKey := config.AppSecretKey()
+	if secretKey == "" {
+		return nil, errors.New("APP_SECRET_KEY is not set")
+	}
+
+	c := clock.System()
+
+	storageConfig := config.Storage()
+	storageConfig.Now = c.Now
+	store, err := storage.New(storageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init storage: %w", err)
+	}
+
+	var geoDB *geoip.DB
+	if path := config.GeoIPDatabasePath(); path != "" {
+		// a database that fails to open only disables the geo lookups
+		if geoDB, err = geoip.Open(path); err != nil {
+			log.Println("failed to open geoip database:", err)
+		}
+	}
 
 	return &Factory{
 		// Pass the db connection to repository package for database query calling
-		RedisClient:     rdb,
-		InitDB:          db,
-		UserRepository:  repository.NewUserRepository(db),
-		OtpRepository:   repository.NewOtpRepository(db),
-		RedisRepository: repository.NewRedisRepository(rdb),
-	}
+		InitDB:                 db,
+		TxManager:              dbutil.NewTxManager(db),
+		UserRepository:         repository.NewUserRepository(db, c),
+		OtpRepository:          repository.NewOtpRepository(db),
+		RedisRepository:        repository.NewRedisRepository(rdb),
+		AuditRepository:        repository.NewAuditRepository(db),
+		OrganizationRepository: repository.NewOrganizationRepository(db),
+		Storage:                store,
+		Clock:                  c,
+		Mailer:                 mailer.NewSMTP(config.Mail()),
+		TokenIssuer: token.NewJWT(token.Config{
+			Secret:   []byte(secretKey),
+			Duration: config.GetTokenDuration(),
+			Location: config.AppLocation(),
+		}, c),
+		SecretKey: secretKey,
+		GeoIP:     geoDB,
+	}, nil
 }
@@ -1,7 +1,10 @@
 package http
 
 import (
+	"clean-arch/internal/app/audit"
 	"clean-arch/internal/app/auth"
+	"clean-arch/internal/app/file"
+	"clean-arch/internal/app/organization"
 	"clean-arch/internal/app/user"
 	"clean-arch/internal/factory"
 	"clean-arch/internal/middleware"
@@ -28,6 +31,11 @@ func NewHttp(g *gin.Engine, f *factory.Factory) {
 
 	defer logger.Sync()
 
+	// Only trust X-Forwarded-For from the configured proxies so c.ClientIP can't be spoofed
+	if err := g.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		panic(err)
+	}
+
 	helper.Index(g)
 
 	// Here we use cors middleware
@@ -44,10 +52,19 @@ func NewHttp(g *gin.Engine, f *factory.Factory) {
 
 	g.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Uploaded files are linked as APP_URL:APP_PORT/uploads/<key>
+	file.NewHandler(f).Router(g.Group("/uploads"))
+
 	// Here we define a router group
 	v1 := g.Group("/api/v1")
 	// Here we register the route from user handler
-	auth.NewHandler(f).Secured(v1.Group("/auth"))
-	auth.NewHandler(f).Router(v1.Group("/auth"))
-	user.NewHandler(f).Router(v1.Group("/user"))
+	authHandler := auth.NewHandler(f)
+	authHandler.Secured(v1.Group("/auth"))
+	authHandler.Router(v1.Group("/auth"))
+	userHandler := user.NewHandler(f)
+	userHandler.Router(v1.Group("/user"))
+	userHandler.AuthRouter(v1.Group("/auth"))
+	userHandler.MeRouter(v1.Group("/me"))
+	audit.NewHandler(f).Router(v1.Group("/audit"))
+	organization.NewHandler(f).Router(v1.Group("/org"))
 }
@@ -0,0 +1,224 @@
+/*
+Package integration boots the whole gin engine of http.NewHttp for end to end tests, the database
+is an in-memory sqlite built from the sqlite base migrations and redis is the memory repository.
+Mails are recorded instead of sent and uploads go to a temporary folder
+
+The migrations and templates are read relative to the repository root, a test package using the
+harness changes to it in TestMain
+*/
+package integration
+
+import (
+	"bytes"
+	"clean-arch/database"
+	"clean-arch/database/migration"
+	"clean-arch/internal/factory"
+	apphttp "clean-arch/internal/http"
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository/memory"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/mailer"
+	"clean-arch/pkg/storage"
+	"clean-arch/pkg/util"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+type Harness struct {
+	Engine  *gin.Engine
+	Factory *factory.Factory
+	DB      *gorm.DB
+	Mailer  *mailer.Recorder
+}
+
+// Response is the envelope of util.APIResponse with the data left raw for the test to decode
+type Response struct {
+	Meta struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+		Status  string `json:"status"`
+	} `json:"meta"`
+	Data json.RawMessage `json:"data"`
+}
+
+// New builds a fresh database and engine for t, nothing is shared between two harnesses
+func New(t testing.TB) *Harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	// the settings are left at their defaults, only the logger needs a known environment and the factory a secret key
+	viper.Set("APP_ENV", "production")
+	viper.Set("APP_SECRET_KEY", "integration")
+
+	db, err := database.OpenSqlite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := migration.MigrateBase(db, "sqlite"); err != nil {
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+
+	f, err := factory.NewFactory(db, nil)
+	if err != nil {
+		t.Fatalf("build factory: %v", err)
+	}
+
+	rec := &mailer.Recorder{}
+	f.RedisRepository = memory.NewRedisRepository(f.Clock)
+	f.Mailer = rec
+	f.Storage = storage.NewLocal(t.TempDir(), "http://localhost/uploads", "integration")
+
+	g := gin.New()
+	apphttp.NewHttp(g, f)
+
+	return &Harness{
+		Engine:  g,
+		Factory: f,
+		DB:      db,
+		Mailer:  rec,
+	}
+}
+
+// CreateUser stores a verified active user straight in the database
+func (h *Harness) CreateUser(t testing.TB, email string, password string, role consts.RoleType) model.User {
+	t.Helper()
+
+	hash, err := util.HashPassword(password)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	now := h.Factory.Clock.Now()
+	user := model.User{
+		Name:            "Test",
+		Email:           email,
+		Password:        hash,
+		Role:            role,
+		Status:          consts.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := h.Factory.UserRepository.Store(context.Background(), &user); err != nil {
+		t.Fatalf("store user: %v", err)
+	}
+
+	return user
+}
+
+/*
+Do sends a request to the engine, a body that isn't nil is sent as json. The token is sent as the
+bearer token when not empty and the cookies are added as is
+*/
+func (h *Harness) Do(t testing.TB, method string, path string, body any, token string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+	return h.DoHeader(t, method, path, body, token, nil, cookies...)
+}
+
+// DoHeader is Do with extra request headers such as X-Org-ID
+func (h *Harness) DoHeader(t testing.TB, method string, path string, body any, token string, header http.Header, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	h.Engine.ServeHTTP(w, req)
+
+	return w
+}
+
+// Login signs in with the password and returns the access token and the refresh token cookie
+func (h *Harness) Login(t testing.TB, email string, password string) (string, *http.Cookie) {
+	t.Helper()
+
+	w := h.Do(t, http.MethodPost, "/api/v1/auth/login", map[string]string{"email": email, "password": password}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("login %s: %d %s", email, w.Code, w.Body.String())
+	}
+
+	var data struct {
+		TokenJwt string `json:"token_jwt"`
+	}
+	Decode(t, w, &data)
+
+	return data.TokenJwt, RefreshCookie(w)
+}
+
+// Decode reads the envelope of the response and decodes its data into dst when dst isn't nil
+func Decode(t testing.TB, w *httptest.ResponseRecorder, dst any) Response {
+	t.Helper()
+
+	var res Response
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+
+	if dst != nil {
+		if err := json.Unmarshal(res.Data, dst); err != nil {
+			t.Fatalf("decode data %q: %v", string(res.Data), err)
+		}
+	}
+
+	return res
+}
+
+// RefreshCookie returns the refresh_token cookie set by the response, nil when there is none
+func RefreshCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "refresh_token" && cookie.Value != "" {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// Sent waits a moment for a mail to the address, the services send them from goroutines
+func (h *Harness) Sent(to string) (mailer.Message, bool) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		for _, msg := range h.Mailer.Sent() {
+			if msg.To == to {
+				return msg, true
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return mailer.Message{}, false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,211 @@
+package integration_test
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/integration"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/util"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	// the migrations and email templates are read relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	util.SetPasswordParams(util.PasswordParams{Memory: 1024})
+
+	os.Exit(m.Run())
+}
+
+func TestLoginRefreshLogout(t *testing.T) {
+	h := integration.New(t)
+	user := h.CreateUser(t, "budi@example.com", "secret123", consts.RoleTypeUser)
+
+	w := h.Do(t, http.MethodPost, "/api/v1/auth/login", map[string]string{"email": user.Email, "password": "wrong"}, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, consts.InvalidPassword.Error(), integration.Decode(t, w, nil).Meta.Message)
+
+	token, refresh := h.Login(t, user.Email, "secret123")
+	assert.NotEmpty(t, token)
+	assert.NotNil(t, refresh)
+
+	var me dto.User
+	w = h.Do(t, http.MethodGet, "/api/v1/me", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	integration.Decode(t, w, &me)
+	assert.Equal(t, user.ID, me.ID)
+	assert.Equal(t, user.Email, me.Email)
+
+	w = h.Do(t, http.MethodGet, "/api/v1/me", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// the refresh token is rotated, the old cookie stops working
+	w = h.Do(t, http.MethodPost, "/api/v1/auth/refresh", nil, "", refresh)
+	assert.Equal(t, http.StatusOK, w.Code)
+	rotated := integration.RefreshCookie(w)
+	assert.NotNil(t, rotated)
+	assert.NotEqual(t, refresh.Value, rotated.Value)
+
+	w = h.Do(t, http.MethodPost, "/api/v1/auth/refresh", nil, "", refresh)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = h.Do(t, http.MethodPost, "/api/v1/auth/logout", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the session of the token is revoked with the logout
+	w = h.Do(t, http.MethodGet, "/api/v1/me", nil, token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = h.Do(t, http.MethodPost, "/api/v1/auth/refresh", nil, "", rotated)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOtpLogin(t *testing.T) {
+	h := integration.New(t)
+	user := h.CreateUser(t, "budi@example.com", "secret123", consts.RoleTypeUser)
+
+	w := h.Do(t, http.MethodPost, "/api/v1/auth/request-otp", map[string]string{"email": user.Email}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	_, sent := h.Sent(user.Email)
+	assert.True(t, sent)
+
+	var otp model.OTP
+	assert.Nil(t, h.DB.Where("user_id = ?", user.ID).Order("id desc").Take(&otp).Error)
+
+	w = h.Do(t, http.MethodPost, "/api/v1/auth/verify-otp", map[string]string{"email": user.Email, "otp": "not-it"}, "")
+	assert.NotEqual(t, http.StatusOK, w.Code)
+
+	w = h.Do(t, http.MethodPost, "/api/v1/auth/verify-otp", map[string]string{"email": user.Email, "otp": otp.OTP}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotNil(t, integration.RefreshCookie(w))
+
+	assert.Nil(t, h.DB.First(&otp, otp.ID).Error)
+	assert.Equal(t, 1, otp.Attempt)
+}
+
+func TestUserCrud(t *testing.T) {
+	h := integration.New(t)
+	h.CreateUser(t, "admin@example.com", "secret123", consts.RoleTypeAdmin)
+	h.CreateUser(t, "budi@example.com", "secret123", consts.RoleTypeUser)
+	admin, _ := h.Login(t, "admin@example.com", "secret123")
+	member, _ := h.Login(t, "budi@example.com", "secret123")
+
+	w := h.Do(t, http.MethodPost, "/api/v1/user/store", map[string]string{"name": "Citra", "email": "citra@example.com", "password": "secret123"}, admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var list dto.ResponseUser
+	w = h.Do(t, http.MethodGet, "/api/v1/user?filter[email][like]=citra&sort=-created_at", nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	integration.Decode(t, w, &list)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, 1, *list.TotalRow)
+	id := list.Data[0].ID
+
+	w = h.Do(t, http.MethodGet, fmt.Sprintf("/api/v1/user/%d/detail", id), nil, member)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var detail dto.User
+	w = h.Do(t, http.MethodGet, fmt.Sprintf("/api/v1/user/%d/detail", id), nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	integration.Decode(t, w, &detail)
+	assert.Equal(t, "Citra", detail.Name)
+
+	// the new user can sign in with the password it was stored with
+	h.Login(t, "citra@example.com", "secret123")
+
+	w = h.Do(t, http.MethodDelete, fmt.Sprintf("/api/v1/user/%d/delete", id), nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = h.Do(t, http.MethodGet, "/api/v1/user?filter[email][like]=citra", nil, admin)
+	integration.Decode(t, w, &list)
+	assert.Empty(t, list.Data)
+
+	// a deleted email can register again, the deleted user then can't be restored
+	w = h.Do(t, http.MethodPost, "/api/v1/user/store", map[string]string{"name": "Citra", "email": "citra@example.com", "password": "secret123"}, admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = h.Do(t, http.MethodPut, fmt.Sprintf("/api/v1/user/%d/restore", id), nil, admin)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+
+	var reason string
+	integration.Decode(t, w, &reason)
+	assert.Equal(t, consts.EmailAlreadyExists.Error(), reason)
+}
+
+func TestUserCursorSort(t *testing.T) {
+	h := integration.New(t)
+	h.CreateUser(t, "admin@example.com", "secret123", consts.RoleTypeAdmin)
+	h.CreateUser(t, "budi@example.com", "secret123", consts.RoleTypeUser)
+	h.CreateUser(t, "citra@example.com", "secret123", consts.RoleTypeUser)
+	admin, _ := h.Login(t, "admin@example.com", "secret123")
+
+	var page dto.ResponseUser
+	w := h.Do(t, http.MethodGet, "/api/v1/user?cursor=&limit=1&sort=email", nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	integration.Decode(t, w, &page)
+	assert.NotEmpty(t, page.NextCursor)
+
+	cursor := url.QueryEscape(page.NextCursor)
+	w = h.Do(t, http.MethodGet, "/api/v1/user?limit=1&sort=email&cursor="+cursor, nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	integration.Decode(t, w, &page)
+	assert.Equal(t, "budi@example.com", page.Data[0].Email)
+
+	// the cursor of the email order means nothing under another order
+	w = h.Do(t, http.MethodGet, "/api/v1/user?limit=1&sort=-created_at&cursor="+cursor, nil, admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func TestUserFilterLikeEscape(t *testing.T) {
+	h := integration.New(t)
+	h.CreateUser(t, "admin@example.com", "secret123", consts.RoleTypeAdmin)
+	h.CreateUser(t, "budi_santoso@example.com", "secret123", consts.RoleTypeUser)
+	h.CreateUser(t, "budisantoso@example.com", "secret123", consts.RoleTypeUser)
+	admin, _ := h.Login(t, "admin@example.com", "secret123")
+
+	var list dto.ResponseUser
+	w := h.Do(t, http.MethodGet, "/api/v1/user?filter[email][like]=%25", nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	integration.Decode(t, w, &list)
+	assert.Empty(t, list.Data)
+
+	// the _ only matches an underscore
+	w = h.Do(t, http.MethodGet, "/api/v1/user?filter[email][like]=budi_", nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	integration.Decode(t, w, &list)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, "budi_santoso@example.com", list.Data[0].Email)
+
+	w = h.Do(t, http.MethodGet, "/api/v1/user?search=budi_", nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	integration.Decode(t, w, &list)
+	assert.Len(t, list.Data, 1)
+}
+
+func TestUserRoutesNeedAdmin(t *testing.T) {
+	h := integration.New(t)
+	h.CreateUser(t, "budi@example.com", "secret123", consts.RoleTypeUser)
+	member, _ := h.Login(t, "budi@example.com", "secret123")
+
+	w := h.Do(t, http.MethodGet, "/api/v1/user", nil, member)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = h.Do(t, http.MethodGet, "/api/v1/user?filter[role]=admin&fields=email,phone_number", nil, member)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = h.Do(t, http.MethodPost, "/api/v1/user/store", map[string]string{"name": "Citra", "email": "citra@example.com", "password": "secret123"}, member)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var count int64
+	assert.Nil(t, h.DB.Model(&model.User{}).Where("email = ?", "citra@example.com").Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
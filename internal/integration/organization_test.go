@@ -0,0 +1,133 @@
+package integration_test
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/integration"
+	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tenants has two organizations, alice owns A and bob owns B where carol is a member
+type tenants struct {
+	h     *integration.Harness
+	alice string
+	bob   string
+	orgA  int
+	orgB  int
+	carol model.User
+}
+
+func setupTenants(t *testing.T) tenants {
+	h := integration.New(t)
+	h.CreateUser(t, "alice@example.com", "secret123", consts.RoleTypeUser)
+	h.CreateUser(t, "bob@example.com", "secret123", consts.RoleTypeUser)
+	carol := h.CreateUser(t, "carol@example.com", "secret123", consts.RoleTypeUser)
+
+	res := tenants{h: h, carol: carol}
+	res.alice, _ = h.Login(t, "alice@example.com", "secret123")
+	res.bob, _ = h.Login(t, "bob@example.com", "secret123")
+	res.orgA = res.create(t, res.alice, "Acme")
+	res.orgB = res.create(t, res.bob, "Globex")
+
+	ctx := dbutil.WithTenant(context.Background(), res.orgB)
+	assert.Nil(t, h.Factory.OrganizationRepository.StoreMember(ctx, &model.OrganizationMember{UserID: carol.ID, Role: consts.OrgRoleMember}))
+
+	return res
+}
+
+func (e tenants) create(t *testing.T, token string, name string) int {
+	var org dto.Organization
+	w := e.h.Do(t, http.MethodPost, "/api/v1/org", map[string]string{"name": name}, token)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	integration.Decode(t, w, &org)
+	return org.ID
+}
+
+func (e tenants) do(t *testing.T, method string, path string, body any, token string, orgID int) *httptest.ResponseRecorder {
+	return e.h.DoHeader(t, method, path, body, token, http.Header{consts.HeaderOrgID: {strconv.Itoa(orgID)}})
+}
+
+func TestTenantRejectsOtherOrganization(t *testing.T) {
+	e := setupTenants(t)
+
+	for _, path := range []string{"/api/v1/org/current", "/api/v1/org/current/members", "/api/v1/org/current/audit"} {
+		w := e.do(t, http.MethodGet, path, nil, e.alice, e.orgB)
+		assert.Equal(t, http.StatusForbidden, w.Code, path)
+		assert.Equal(t, consts.NotOrganizationMember.Error(), integration.Decode(t, w, nil).Meta.Message)
+	}
+
+	// a member id of B can't be reached from A either
+	var members []dto.OrganizationMember
+	w := e.do(t, http.MethodGet, "/api/v1/org/current/members", nil, e.bob, e.orgB)
+	integration.Decode(t, w, &members)
+	for _, member := range members {
+		w = e.do(t, http.MethodDelete, fmt.Sprintf("/api/v1/org/current/members/%d", member.ID), nil, e.alice, e.orgA)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+
+	count, err := e.h.Factory.OrganizationRepository.CountMember(dbutil.WithTenant(context.Background(), e.orgB))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestTenantMembersAndAudit(t *testing.T) {
+	e := setupTenants(t)
+
+	var members []dto.OrganizationMember
+	w := e.do(t, http.MethodGet, "/api/v1/org/current/members", nil, e.alice, e.orgA)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	integration.Decode(t, w, &members)
+	assert.Len(t, members, 1)
+	assert.Equal(t, "alice@example.com", members[0].Email)
+
+	// the repository itself only sees the rows of the tenant of the context
+	rows, err := e.h.Factory.OrganizationRepository.FindAllMember(dbutil.WithTenant(context.Background(), e.orgA), "*")
+	assert.Nil(t, err)
+	assert.Len(t, rows, 1)
+	for _, row := range rows {
+		assert.Equal(t, e.orgA, row.OrganizationID)
+	}
+
+	_, err = e.h.Factory.OrganizationRepository.FindAllMember(context.Background(), "*")
+	assert.ErrorIs(t, err, dbutil.ErrNoTenant)
+
+	w = e.do(t, http.MethodPatch, "/api/v1/org/current", map[string]string{"name": "Globex Corp"}, e.bob, e.orgB)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var events dto.ResponseAuditEvent
+	w = e.do(t, http.MethodGet, "/api/v1/org/current/audit", nil, e.alice, e.orgA)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	integration.Decode(t, w, &events)
+	assert.Equal(t, 1, events.TotalRow)
+	for _, event := range events.Data {
+		assert.Equal(t, consts.AuditTargetOrg, event.TargetType)
+		assert.Equal(t, e.orgA, *event.TargetID)
+	}
+
+	// a target filter for B in the query is replaced by the tenant
+	w = e.do(t, http.MethodGet, fmt.Sprintf("/api/v1/org/current/audit?target_id=%d", e.orgB), nil, e.alice, e.orgA)
+	integration.Decode(t, w, &events)
+	assert.Equal(t, 1, events.TotalRow)
+	assert.Equal(t, e.orgA, *events.Data[0].TargetID)
+
+	w = e.do(t, http.MethodGet, "/api/v1/org/current/audit", nil, e.bob, e.orgB)
+	integration.Decode(t, w, &events)
+	assert.Equal(t, 2, events.TotalRow)
+	for _, event := range events.Data {
+		assert.Equal(t, e.orgB, *event.TargetID)
+	}
+
+	// a plain member of the organization can't read its audit log
+	carol, _ := e.h.Login(t, e.carol.Email, "secret123")
+	w = e.do(t, http.MethodGet, "/api/v1/org/current/audit", nil, carol, e.orgB)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"clean-arch/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrentUser returns the user stored by Authenticate
+func CurrentUser(c *gin.Context) (dto.JwtSession, bool) {
+	value, exists := c.Get("user")
+	if !exists {
+		return dto.JwtSession{}, false
+	}
+
+	sess, ok := value.(dto.JwtSession)
+	return sess, ok
+}
+
+// SessionID returns the id of the session the access token belongs to
+func SessionID(c *gin.Context) (int, bool) {
+	value, exists := c.Get("session_id")
+	if !exists {
+		return 0, false
+	}
+
+	id, ok := value.(int)
+	return id, ok
+}
+
+// CurrentTenant returns the organization stored by Tenant
+func CurrentTenant(c *gin.Context) (dto.Tenant, bool) {
+	value, exists := c.Get("tenant")
+	if !exists {
+		return dto.Tenant{}, false
+	}
+
+	tenant, ok := value.(dto.Tenant)
+	return tenant, ok
+}
@@ -3,21 +3,24 @@ package middleware
 import (
 	"clean-arch/internal/dto"
 	"clean-arch/internal/factory"
+	"clean-arch/pkg/consts"
 	"clean-arch/pkg/dbutil"
 	"clean-arch/pkg/util"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
-	"strconv"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
-func Authenticate() gin.HandlerFunc {
+/*
+Authenticate checks the bearer token and the session it is bound to, the user is read from the
+cache or the database and stored in the context for CurrentUser
+*/
+func Authenticate(f *factory.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.Request.Header["Authorization"]
 
@@ -30,21 +33,19 @@ func Authenticate() gin.HandlerFunc {
 
 		rep := regexp.MustCompile(`(Bearer)\s?`)
 		bearerStr := rep.ReplaceAllString(header[0], "")
-		parsedToken, err := parseToken(bearerStr)
-		if err != nil || !parsedToken.Valid {
+		claims, err := f.TokenIssuer.Parse(bearerStr)
+		if err != nil {
 			response := util.APIResponse("Unauthorized, bearer token not valid", http.StatusUnauthorized, "failed", nil)
 			c.JSON(http.StatusUnauthorized, response)
 			c.Abort()
 			return
 		}
 
-		claims := parsedToken.Claims.(jwt.MapClaims)
-
-		f := factory.NewFactory()
-		userId, _ := strconv.Atoi(claims["user_id"].(string))
+		userId := claims.UserID
 
-		_, err = f.UserRepository.FindSession(c, bearerStr)
-		if err != nil {
+		// tokens issued before sessions were bound to the jwt carry no sid and are rejected
+		session, err := f.UserRepository.FindSessionByID(c, claims.SessionID)
+		if err != nil || session.UserID != userId {
 			response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
 			c.JSON(http.StatusUnauthorized, response)
 			c.Abort()
@@ -53,50 +54,53 @@ func Authenticate() gin.HandlerFunc {
 
 		cacheKey := fmt.Sprintf("user_session-%d", userId)
 
-		cachedData, err := f.RedisClient.Get(c, cacheKey).Result()
+		var jwtSess dto.JwtSession
+
+		cachedData, err := f.RedisRepository.Get(c, cacheKey)
 		if err == nil {
 			var cachedInfo dto.JwtSession
 			if err := json.Unmarshal([]byte(cachedData), &cachedInfo); err == nil {
+				jwtSess = cachedInfo
 				c.Set("user", cachedInfo)
 			}
 		}
 
 		if err == redis.Nil {
-			var jwtSess dto.JwtSession
 			user, _ := f.UserRepository.FindOne(c, "*", dbutil.Where("id = ?", userId))
 
 			jwtSess = dto.JwtSession{
-				ID:          user.ID,
-				Name:        user.Name,
-				Email:       user.Email,
-				PhoneNumber: user.PhoneNumber,
-				CreatedAt:   user.CreatedAt,
+				ID:             user.ID,
+				Name:           user.Name,
+				Email:          user.Email,
+				PhoneNumber:    user.PhoneNumber,
+				Role:           user.Role,
+				Status:         user.Status,
+				SuspendedUntil: user.SuspendedUntil,
+				CreatedAt:      user.CreatedAt,
 			}
 
-			jsonData, err := json.Marshal(jwtSess)
-			if err == nil {
-				f.RedisClient.Set(c, cacheKey, jsonData, time.Hour)
-			} else {
-				fmt.Println("Error marshalling data for cache:", err)
+			if err := f.RedisRepository.Set(c, cacheKey, jwtSess, time.Hour); err != nil {
+				fmt.Println("Error caching session data:", err)
 			}
 
 			c.Set("user", jwtSess)
 		}
 
-		c.Set("bearer", bearerStr)
+		if err := consts.UserStatusError(jwtSess.Status, jwtSess.SuspendedUntil, f.Clock.Now()); err != nil {
+			response := util.APIResponse(err.Error(), http.StatusForbidden, "failed", nil)
+			c.JSON(http.StatusForbidden, response)
+			c.Abort()
+			return
+		}
 
-		c.Next()
-	}
-}
+		c.Set("bearer", bearerStr)
+		c.Set("session_id", session.ID)
 
-func parseToken(tokenString string) (*jwt.Token, error) {
-	secretKey := []byte(util.GetEnv("APP_SECRET_KEY", "fallback"))
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
+		// the organization picked with /auth/switch-org, middleware.Tenant checks the membership
+		if claims.OrganizationID != nil {
+			c.Set("token_org_id", *claims.OrganizationID)
 		}
-		return secretKey, nil
-	})
 
-	return token, err
+		c.Next()
+	}
 }
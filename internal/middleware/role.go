@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize must be used after Authenticate, it only lets users with one of the given roles through
+func Authorize(roles ...consts.RoleType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sess, ok := CurrentUser(c)
+		if !ok {
+			response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			return
+		}
+
+		for _, role := range roles {
+			if sess.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		response := util.APIResponse("Forbidden, you don't have access to this resource", http.StatusForbidden, "failed", nil)
+		c.AbortWithStatusJSON(http.StatusForbidden, response)
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"clean-arch/internal/dto"
+	"clean-arch/internal/factory"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+Tenant must be used after Authenticate, it resolves the organization of the request from the
+X-Org-ID header or else from the org claim of the token, and only lets members through
+
+The organization is stored under dbutil.TenantKey so repositories given the gin context scope
+their queries to it
+*/
+func Tenant(f *factory.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sess, ok := CurrentUser(c)
+		if !ok {
+			response := util.APIResponse("Unauthorized", http.StatusUnauthorized, "failed", nil)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			return
+		}
+
+		orgID := c.GetInt("token_org_id")
+		if header := c.GetHeader(consts.HeaderOrgID); header != "" {
+			id, err := strconv.Atoi(header)
+			if err != nil || id <= 0 {
+				response := util.APIResponse("Invalid "+consts.HeaderOrgID+" header", http.StatusBadRequest, "failed", nil)
+				c.AbortWithStatusJSON(http.StatusBadRequest, response)
+				return
+			}
+			orgID = id
+		}
+
+		if orgID == 0 {
+			response := util.APIResponse(consts.OrganizationRequired.Error(), http.StatusBadRequest, "failed", nil)
+			c.AbortWithStatusJSON(http.StatusBadRequest, response)
+			return
+		}
+
+		member, err := f.OrganizationRepository.FindMember(dbutil.WithTenant(c, orgID), "id, role", dbutil.Where("user_id = ?", sess.ID))
+		if err != nil {
+			response := util.APIResponse(consts.NotOrganizationMember.Error(), http.StatusForbidden, "failed", nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, response)
+			return
+		}
+
+		c.Set(dbutil.TenantKey, orgID)
+		c.Set("tenant", dto.Tenant{
+			OrganizationID: orgID,
+			Role:           member.Role,
+		})
+
+		c.Next()
+	}
+}
+
+// AuthorizeOrg must be used after Tenant, it only lets members with one of the given roles in the organization through
+func AuthorizeOrg(roles ...consts.OrgRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant, ok := CurrentTenant(c)
+		if !ok {
+			response := util.APIResponse(consts.OrganizationRequired.Error(), http.StatusBadRequest, "failed", nil)
+			c.AbortWithStatusJSON(http.StatusBadRequest, response)
+			return
+		}
+
+		for _, role := range roles {
+			if tenant.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		response := util.APIResponse("Forbidden, your role in the organization doesn't allow this", http.StatusForbidden, "failed", nil)
+		c.AbortWithStatusJSON(http.StatusForbidden, response)
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import "clean-arch/pkg/consts"
+
+// AuditEvent is append only, rows are never updated or deleted
+type AuditEvent struct {
+	ID         int               `gorm:"primaryKey" json:"id"`
+	Event      consts.AuditEvent `gorm:"column:event" json:"event"`
+	ActorID    *int              `gorm:"column:actor_id" json:"actor_id"`
+	TargetType string            `gorm:"column:target_type" json:"target_type"`
+	TargetID   *int              `gorm:"column:target_id" json:"target_id"`
+	IPAddress  string            `gorm:"column:ip_address" json:"ip_address"`
+	UserAgent  string            `gorm:"column:user_agent" json:"user_agent"`
+	Metadata   string            `gorm:"column:metadata" json:"metadata"`
+	CreatedOnly
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
@@ -0,0 +1,11 @@
+package model
+
+// Define parsed user agent and coarse geo columns shared by login logs and sessions
+type ClientInfo struct {
+	Browser string `gorm:"column:browser" json:"browser"`
+	OS      string `gorm:"column:os" json:"os"`
+	Device  string `gorm:"column:device" json:"device"`
+	Country string `gorm:"column:country" json:"country"`
+	Region  string `gorm:"column:region" json:"region"`
+	City    string `gorm:"column:city" json:"city"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// ErasureReceipt proves an account was erased without keeping its personal data, the email is only kept hashed
+type ErasureReceipt struct {
+	ID          int        `gorm:"primaryKey" json:"id"`
+	UserID      int        `gorm:"column:user_id" json:"user_id"`
+	EmailHash   string     `gorm:"column:email_hash" json:"email_hash"`
+	RequestedAt *time.Time `gorm:"column:requested_at" json:"requested_at"`
+	ErasedAt    *time.Time `gorm:"column:erased_at" json:"erased_at"`
+	Summary     string     `gorm:"column:summary" json:"summary"`
+	CreatedOnly
+}
+
+func (ErasureReceipt) TableName() string {
+	return "erasure_receipts"
+}
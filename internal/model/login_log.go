@@ -1,10 +1,15 @@
 package model
 
+import "clean-arch/pkg/consts"
+
 type LoginLog struct {
-	ID        int    `gorm:"primaryKey" json:"id"`
-	UserID    int    `gorm:"column:user_id" json:"user_id"`
-	IPAddress string `gorm:"column:ip_address" json:"ip_address"`
-	UserAgent string `gorm:"column:user_agent" json:"user_agent"`
+	ID            int                `gorm:"primaryKey" json:"id"`
+	UserID        int                `gorm:"column:user_id" json:"user_id"`
+	IPAddress     string             `gorm:"column:ip_address" json:"ip_address"`
+	UserAgent     string             `gorm:"column:user_agent" json:"user_agent"`
+	Status        consts.LoginStatus `gorm:"column:status" json:"status"`
+	FailureReason string             `gorm:"column:failure_reason" json:"failure_reason"`
+	ClientInfo
 	Common
 }
 
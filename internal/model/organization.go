@@ -0,0 +1,49 @@
+package model
+
+import (
+	"clean-arch/pkg/consts"
+	"time"
+)
+
+type Organization struct {
+	ID        int    `gorm:"primaryKey" json:"id"`
+	Name      string `gorm:"column:name" json:"name"`
+	Slug      string `gorm:"column:slug" json:"slug"`
+	CreatedBy *int   `gorm:"column:created_by" json:"created_by"`
+	Common
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// OrganizationMember is owned by its organization, the repository scopes every query to the tenant
+type OrganizationMember struct {
+	ID             int            `gorm:"primaryKey" json:"id"`
+	OrganizationID int            `gorm:"column:organization_id" json:"organization_id"`
+	UserID         int            `gorm:"column:user_id" json:"user_id"`
+	Role           consts.OrgRole `gorm:"column:role" json:"role"`
+	User           *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Organization   *Organization  `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	Common
+}
+
+func (OrganizationMember) TableName() string {
+	return "organization_members"
+}
+
+// OrganizationInvite is a pending invite, it is deleted once accepted or revoked
+type OrganizationInvite struct {
+	ID             int            `gorm:"primaryKey" json:"id"`
+	OrganizationID int            `gorm:"column:organization_id" json:"organization_id"`
+	Email          string         `gorm:"column:email" json:"email"`
+	Role           consts.OrgRole `gorm:"column:role" json:"role"`
+	TokenHash      string         `gorm:"column:token_hash" json:"-"`
+	InvitedBy      *int           `gorm:"column:invited_by" json:"invited_by"`
+	ExpiresAt      time.Time      `gorm:"column:expires_at" json:"expires_at"`
+	Common
+}
+
+func (OrganizationInvite) TableName() string {
+	return "organization_invites"
+}
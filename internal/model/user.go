@@ -1,18 +1,57 @@
 package model
 
-import "time"
+import (
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/profile"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
-	ID              int        `gorm:"primaryKey" json:"id"`
-	Name            string     `gorm:"column:name" json:"name"`
-	Email           string     `gorm:"column:email" json:"email"`
-	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at" json:"email_verified_at"`
-	Password        string     `gorm:"column:password" json:"password"`
-	PhoneNumber     string     `gorm:"column:phone_number" json:"phone_number"`
-	ProfileImageURL string     `gorm:"column:profile_image_url" json:"profile_image_url"`
+	ID              int                  `gorm:"primaryKey" json:"id"`
+	Name            string               `gorm:"column:name" json:"name"`
+	Email           string               `gorm:"column:email" json:"email"`
+	EmailVerifiedAt *time.Time           `gorm:"column:email_verified_at" json:"email_verified_at"`
+	Password        string               `gorm:"column:password" json:"password"`
+	Role            consts.RoleType      `gorm:"column:role" json:"role"`
+	Status          consts.UserStatus    `gorm:"column:status" json:"status"`
+	StatusReason    string               `gorm:"column:status_reason" json:"status_reason"`
+	SuspendedUntil  *time.Time           `gorm:"column:suspended_until" json:"suspended_until"`
+	StatusChangedBy *int                 `gorm:"column:status_changed_by" json:"status_changed_by"`
+	StatusChangedAt *time.Time           `gorm:"column:status_changed_at" json:"status_changed_at"`
+	InvitedBy       *int                 `gorm:"column:invited_by" json:"invited_by"`
+	InvitedAt       *time.Time           `gorm:"column:invited_at" json:"invited_at"`
+	InviteExpiresAt *time.Time           `gorm:"column:invite_expires_at" json:"invite_expires_at"`
+	InviteTokenHash string               `gorm:"column:invite_token_hash" json:"-"`
+	EraseRequestAt  *time.Time           `gorm:"column:erasure_requested_at" json:"erasure_requested_at"`
+	EraseAfter      *time.Time           `gorm:"column:erase_after" json:"erase_after"`
+	PhoneNumber     string               `gorm:"column:phone_number" json:"phone_number"`
+	ProfileImageURL string               `gorm:"column:profile_image_url" json:"profile_image_url"`
+	Preferences     *profile.Preferences `gorm:"column:preferences;serializer:json" json:"preferences"`
+	Attributes      map[string]any       `gorm:"column:attributes;serializer:json" json:"attributes"`
+	DeletedAt       gorm.DeletedAt       `gorm:"column:deleted_at;index" json:"deleted_at"`
 	Common
 }
 
 func (User) TableName() string {
 	return "users"
 }
+
+// InviteStatus is empty for users who were never invited
+func (u User) InviteStatus(now time.Time) string {
+	switch {
+	case u.Status == consts.UserStatusInvited && u.InviteExpiresAt != nil && now.After(*u.InviteExpiresAt):
+		return consts.InviteStatusExpired
+	case u.Status == consts.UserStatusInvited:
+		return consts.InviteStatusPending
+	case u.InvitedAt != nil:
+		return consts.InviteStatusAccepted
+	}
+	return ""
+}
+
+// StatusError tells why the user can't use the account right now, a suspension past its date counts as active
+func (u User) StatusError(now time.Time) error {
+	return consts.UserStatusError(u.Status, u.SuspendedUntil, now)
+}
@@ -8,11 +8,14 @@ import (
 type UserSession struct {
 	ID               int                  `gorm:"primaryKey" json:"id"`
 	UserID           int                  `gorm:"column:user_id" json:"user_id"`
+	OrganizationID   *int                 `gorm:"column:organization_id" json:"organization_id"`
 	IPAddress        string               `gorm:"column:ip_address" json:"ip_address"`
+	UserAgent        string               `gorm:"column:user_agent" json:"user_agent"`
 	RefreshTokenHash string               `gorm:"column:refresh_token_hash" json:"refresh_token_hash"`
 	Revoked          consts.SessionStatus `gorm:"column:revoked" json:"revoked"`
 	ExpiresAt        time.Time            `gorm:"column:expires_at" json:"expires_at"`
 	CreatedAt        time.Time            `gorm:"column:created_at" json:"created_at"`
+	ClientInfo
 }
 
 func (UserSession) TableName() string {
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Audit events are append only, so the repository doesn't expose update or delete, Anonymize is the exception for erased accounts
+type Audit interface {
+	Store(ctx context.Context, insertModel model.AuditEvent) error
+	Anonymize(ctx context.Context, userID int) error
+	FindAll(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.AuditEvent, error)
+	Count(ctx context.Context, opts ...dbutil.QueryOption) (int, error)
+}
+
+type audit struct {
+	Db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) Audit {
+	return &audit{
+		Db: db,
+	}
+}
+
+func (r *audit) Store(ctx context.Context, insertModel model.AuditEvent) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(model.AuditEvent{}).Create(&insertModel).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Anonymize drops the request details and metadata of the events done by or to the user, the ids stay so the trail keeps its shape
+func (r *audit) Anonymize(ctx context.Context, userID int) error {
+	err := dbutil.Conn(ctx, r.Db).Model(&model.AuditEvent{}).
+		Where("actor_id = ? OR (target_type = ? AND target_id = ?)", userID, consts.AuditTargetUser, userID).
+		Updates(map[string]any{
+			"ip_address": "",
+			"user_agent": "",
+			"metadata":   "{}",
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *audit) FindAll(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.AuditEvent, error) {
+	var res []*model.AuditEvent
+
+	db := dbutil.Conn(ctx, r.Db).Model(&model.AuditEvent{})
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.ApplyScopes(opts...)).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *audit) Count(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
+	var (
+		res int64
+	)
+
+	err := dbutil.Conn(ctx, r.Db).Model(model.AuditEvent{}).Select("id").Scopes(dbutil.ApplyScopes(opts...)).Count(&res).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res), nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"context"
+)
+
+type audit struct {
+	events repository.Repository[model.AuditEvent]
+	db     *DB
+}
+
+func NewAuditRepository(db *DB) repository.Audit {
+	return &audit{
+		events: NewRepository[model.AuditEvent](db),
+		db:     db,
+	}
+}
+
+func (r *audit) Store(ctx context.Context, insertModel model.AuditEvent) error {
+	return r.events.Store(ctx, &insertModel)
+}
+
+func (r *audit) Anonymize(ctx context.Context, userID int) error {
+	events := tableOf[model.AuditEvent](r.db)
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := events.match([]dbutil.QueryOption{dbutil.Where("actor_id = ? OR (target_type = ? AND target_id = ?)", userID, consts.AuditTargetUser, userID)})
+	if err != nil {
+		return err
+	}
+
+	return events.updateColumns(rows, map[string]any{
+		"ip_address": "",
+		"user_agent": "",
+		"metadata":   "{}",
+	})
+}
+
+func (r *audit) FindAll(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.AuditEvent, error) {
+	return r.events.FindAll(ctx, selectedFields, opts...)
+}
+
+func (r *audit) Count(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
+	return r.events.Count(ctx, opts...)
+}
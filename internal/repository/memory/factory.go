@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"clean-arch/internal/factory"
+	"clean-arch/pkg/mailer"
+	"clean-arch/pkg/storage"
+	"clean-arch/pkg/token"
+	"time"
+)
+
+const secretKey = "memory"
+
+/*
+NewFactory builds the application container on the memory repositories of db, its clock is the
+clock of the services and tokens, links and cursors are signed with a fixed test key
+
+InitDB stays nil, no service reads it
+*/
+func NewFactory(db *DB, m mailer.Mailer, store storage.Storage) *factory.Factory {
+	return &factory.Factory{
+		TxManager:              NewTxManager(),
+		UserRepository:         NewUserRepository(db),
+		OtpRepository:          NewOtpRepository(db),
+		RedisRepository:        NewRedisRepository(db.clock),
+		AuditRepository:        NewAuditRepository(db),
+		OrganizationRepository: NewOrganizationRepository(db),
+		Storage:                store,
+		Clock:                  db.clock,
+		Mailer:                 m,
+		SecretKey:              secretKey,
+		TokenIssuer: token.NewJWT(token.Config{
+			Secret:   []byte(secretKey),
+			Duration: time.Hour,
+			Location: time.UTC,
+		}, db.clock),
+	}
+}
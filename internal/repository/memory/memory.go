@@ -0,0 +1,656 @@
+/*
+Package memory keeps the repositories in maps so the services can be tested without MySQL, Postgres
+or Redis. The repositories take the same dbutil options as the gorm ones, the where clauses are
+evaluated by a small parser that knows the comparisons, IN, LIKE with its ESCAPE, IS NULL, AND, OR,
+parentheses and the DATE and LOWER functions the services use, anything else is returned as an error
+
+Selected fields are ignored, a read always returns the whole row
+*/
+package memory
+
+import (
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/clock"
+	"clean-arch/pkg/dbutil"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// DB holds the tables of every memory repository built on it, like a gorm.DB the repositories share it
+type DB struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	cache  sync.Map
+	tables map[string]any
+}
+
+func NewDB(c clock.Clock) *DB {
+	return &DB{
+		clock:  c,
+		tables: map[string]any{},
+	}
+}
+
+// table is the rows of one model, ids are given in insert order starting from 1
+type table[T any] struct {
+	db     *DB
+	schema *schema.Schema
+	rows   []*T
+	nextID int
+}
+
+func tableOf[T any](db *DB) *table[T] {
+	sch, err := schema.Parse(new(T), &db.cache, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("memory: %s", err))
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if t, ok := db.tables[sch.Table]; ok {
+		return t.(*table[T])
+	}
+
+	t := &table[T]{db: db, schema: sch, nextID: 1}
+	db.tables[sch.Table] = t
+	return t
+}
+
+// Rows returns copies of every row of the model, deleted ones included, for the assertions of a test
+func Rows[T any](db *DB) []T {
+	t := tableOf[T](db)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	res := make([]T, 0, len(t.rows))
+	for _, row := range t.rows {
+		res = append(res, *row)
+	}
+	return res
+}
+
+type repo[T any] struct {
+	t *table[T]
+}
+
+// NewRepository is the memory version of repository.NewRepository
+func NewRepository[T any](db *DB) repository.Repository[T] {
+	return &repo[T]{t: tableOf[T](db)}
+}
+
+func (r *repo[T]) FindOne(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (T, error) {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	var res T
+
+	rows, err := r.t.find(opts)
+	if err != nil {
+		return res, err
+	}
+	if len(rows) == 0 {
+		return res, gorm.ErrRecordNotFound
+	}
+
+	return *rows[0], nil
+}
+
+func (r *repo[T]) FindAll(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*T, error) {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	return r.t.find(opts)
+}
+
+func (r *repo[T]) FindPage(ctx context.Context, selectedFields string, limit int, offset int, opts ...dbutil.QueryOption) ([]*T, int, error) {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	all, err := r.t.find(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, err := r.t.find(append(opts, dbutil.Limit(limit), dbutil.Offset(offset)))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, len(all), nil
+}
+
+func (r *repo[T]) Count(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	rows, err := r.t.find(opts)
+	return len(rows), err
+}
+
+func (r *repo[T]) Exists(ctx context.Context, opts ...dbutil.QueryOption) (bool, error) {
+	count, err := r.Count(ctx, opts...)
+	return count > 0, err
+}
+
+func (r *repo[T]) Store(ctx context.Context, insertModel *T) error {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	r.t.insert(insertModel)
+	return nil
+}
+
+func (r *repo[T]) StoreBatch(ctx context.Context, insertModels []*T, batchSize int) error {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	for _, insertModel := range insertModels {
+		r.t.insert(insertModel)
+	}
+	return nil
+}
+
+func (r *repo[T]) Upsert(ctx context.Context, insertModel *T, conflictColumns []string, updateColumns ...string) error {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	src := reflect.ValueOf(insertModel).Elem()
+
+	for _, row := range r.t.rows {
+		dst := reflect.ValueOf(row).Elem()
+
+		conflict := true
+		for _, column := range conflictColumns {
+			if compareValues(r.t.value(dst, column), r.t.value(src, column)) != 0 {
+				conflict = false
+				break
+			}
+		}
+		if !conflict {
+			continue
+		}
+
+		if len(updateColumns) == 0 {
+			for _, field := range r.t.schema.Fields {
+				if field.DBName != "" && !field.PrimaryKey {
+					updateColumns = append(updateColumns, field.DBName)
+				}
+			}
+		}
+
+		for _, column := range updateColumns {
+			if err := r.t.set(dst, column, r.t.value(src, column)); err != nil {
+				return err
+			}
+		}
+		r.t.touch(dst)
+		return nil
+	}
+
+	r.t.insert(insertModel)
+	return nil
+}
+
+func (r *repo[T]) UpdateOne(ctx context.Context, id int, data T) error {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	return r.t.update(r.t.byID(id), reflect.ValueOf(&data).Elem(), nil)
+}
+
+func (r *repo[T]) UpdateColumns(ctx context.Context, id int, data map[string]any) error {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	return r.t.updateColumns(r.t.byID(id), data)
+}
+
+func (r *repo[T]) UpdateAll(ctx context.Context, data T, selectedFields string, opts ...dbutil.QueryOption) error {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	rows, err := r.t.match(opts)
+	if err != nil {
+		return err
+	}
+
+	var columns []string
+	if selectedFields != "" && selectedFields != "*" {
+		for _, column := range strings.Split(selectedFields, ",") {
+			columns = append(columns, strings.TrimSpace(column))
+		}
+	}
+
+	return r.t.update(rows, reflect.ValueOf(&data).Elem(), columns)
+}
+
+func (r *repo[T]) DeleteOne(ctx context.Context, id int) error {
+	r.t.db.mu.Lock()
+	defer r.t.db.mu.Unlock()
+
+	r.t.delete(r.t.byID(id), false)
+	return nil
+}
+
+func (t *table[T]) options(opts []dbutil.QueryOption) *dbutil.QueryOptions {
+	options := new(dbutil.QueryOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// match returns the stored rows, not copies, that pass the where clauses of the options
+func (t *table[T]) match(opts []dbutil.QueryOption) ([]*T, error) {
+	options := t.options(opts)
+
+	var conditions []condition
+	for _, where := range options.Where {
+		query, ok := where.Query.(string)
+		if !ok {
+			return nil, fmt.Errorf("memory: unsupported where %T", where.Query)
+		}
+
+		cond, err := parseCondition(query, where.Args)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+	}
+
+	_, softDelete := t.schema.FieldsByDBName["deleted_at"]
+
+	var res []*T
+	for _, row := range t.rows {
+		rv := reflect.ValueOf(row).Elem()
+
+		if softDelete && !options.Unscoped && t.value(rv, "deleted_at") != nil {
+			continue
+		}
+
+		matched := true
+		for _, cond := range conditions {
+			ok, err := cond.eval(func(column string) (any, error) {
+				if _, found := t.schema.FieldsByDBName[column]; !found {
+					return nil, fmt.Errorf("memory: unknown column %s in %s", column, t.schema.Table)
+				}
+				return t.value(rv, column), nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			res = append(res, row)
+		}
+	}
+
+	return res, nil
+}
+
+// find is match with the order, offset and limit of the options applied, the rows are copies
+func (t *table[T]) find(opts []dbutil.QueryOption) ([]*T, error) {
+	rows, err := t.match(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	options := t.options(opts)
+
+	if options.Order != "" {
+		var keys [][2]string
+		for _, part := range strings.Split(options.Order, ",") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 {
+				continue
+			}
+			direction := "asc"
+			if len(fields) > 1 {
+				direction = strings.ToLower(fields[1])
+			}
+			keys = append(keys, [2]string{columnName(fields[0]), direction})
+		}
+
+		sort.SliceStable(rows, func(i, j int) bool {
+			a, b := reflect.ValueOf(rows[i]).Elem(), reflect.ValueOf(rows[j]).Elem()
+			for _, key := range keys {
+				c := compareValues(t.value(a, key[0]), t.value(b, key[0]))
+				if c == 0 {
+					continue
+				}
+				if key[1] == "desc" {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	if options.Offset > 0 {
+		if options.Offset >= len(rows) {
+			rows = nil
+		} else {
+			rows = rows[options.Offset:]
+		}
+	}
+
+	if options.Limit > 0 && options.Limit < len(rows) {
+		rows = rows[:options.Limit]
+	}
+
+	res := make([]*T, 0, len(rows))
+	for _, row := range rows {
+		copied := *row
+		res = append(res, &copied)
+	}
+
+	return res, nil
+}
+
+// byID returns the stored row with the id, a soft deleted row is skipped like gorm does
+func (t *table[T]) byID(id int) []*T {
+	rows, _ := t.match([]dbutil.QueryOption{dbutil.Where(t.primaryKey()+" = ?", id)})
+	return rows
+}
+
+func (t *table[T]) primaryKey() string {
+	return t.schema.PrioritizedPrimaryField.DBName
+}
+
+// insert gives the row the next id and the created and updated times of the clock when they are unset
+func (t *table[T]) insert(insertModel *T) {
+	rv := reflect.ValueOf(insertModel).Elem()
+
+	_ = t.set(rv, t.primaryKey(), t.nextID)
+	t.nextID++
+
+	now := t.db.clock.Now()
+	for _, column := range []string{"created_at", "updated_at"} {
+		if _, ok := t.schema.FieldsByDBName[column]; ok && t.value(rv, column) == nil {
+			_ = t.set(rv, column, now)
+		}
+	}
+
+	copied := *insertModel
+	t.rows = append(t.rows, &copied)
+}
+
+// update copies the columns of data to the rows, without columns only the non zero fields are copied like gorm Updates does
+func (t *table[T]) update(rows []*T, data reflect.Value, columns []string) error {
+	for _, row := range rows {
+		dst := reflect.ValueOf(row).Elem()
+
+		for _, field := range t.schema.Fields {
+			if field.DBName == "" || field.PrimaryKey {
+				continue
+			}
+
+			value, zero := field.ValueOf(context.Background(), data)
+			if columns == nil && zero {
+				continue
+			}
+			if columns != nil && !contains(columns, field.DBName) {
+				continue
+			}
+
+			if err := t.set(dst, field.DBName, value); err != nil {
+				return err
+			}
+		}
+		t.touch(dst)
+	}
+
+	return nil
+}
+
+func (t *table[T]) updateColumns(rows []*T, data map[string]any) error {
+	for _, row := range rows {
+		dst := reflect.ValueOf(row).Elem()
+
+		for column, value := range data {
+			if err := t.set(dst, column, value); err != nil {
+				return err
+			}
+		}
+		t.touch(dst)
+	}
+
+	return nil
+}
+
+// delete sets deleted_at on models that have it, force or a model without it removes the rows
+func (t *table[T]) delete(rows []*T, force bool) {
+	if _, ok := t.schema.FieldsByDBName["deleted_at"]; ok && !force {
+		for _, row := range rows {
+			_ = t.set(reflect.ValueOf(row).Elem(), "deleted_at", t.db.clock.Now())
+		}
+		return
+	}
+
+	kept := t.rows[:0]
+	for _, row := range t.rows {
+		if !contains(rows, row) {
+			kept = append(kept, row)
+		}
+	}
+	t.rows = kept
+}
+
+func (t *table[T]) touch(row reflect.Value) {
+	if _, ok := t.schema.FieldsByDBName["updated_at"]; ok {
+		_ = t.set(row, "updated_at", t.db.clock.Now())
+	}
+}
+
+// value reads a column of the row, a NULL, a zero time or an unset gorm.DeletedAt is nil
+func (t *table[T]) value(row reflect.Value, column string) any {
+	field, ok := t.schema.FieldsByDBName[columnName(column)]
+	if !ok {
+		return nil
+	}
+
+	return normalize(field.ReflectValueOf(context.Background(), row).Interface())
+}
+
+func (t *table[T]) set(row reflect.Value, column string, value any) error {
+	field, ok := t.schema.FieldsByDBName[columnName(column)]
+	if !ok {
+		return fmt.Errorf("memory: unknown column %s in %s", column, t.schema.Table)
+	}
+
+	return assign(field.ReflectValueOf(context.Background(), row), value)
+}
+
+// columnName drops the table of a qualified column such as users.id
+func columnName(column string) string {
+	if i := strings.LastIndex(column, "."); i >= 0 {
+		column = column[i+1:]
+	}
+	return strings.Trim(column, "`\"")
+}
+
+func contains[E comparable](list []E, item E) bool {
+	for _, e := range list {
+		if e == item {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+)
+
+// assign sets dst to value the way the database would store it, nil clears the field
+func assign(dst reflect.Value, value any) error {
+	if value == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	src := reflect.ValueOf(value)
+	for src.Kind() == reflect.Pointer {
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if src.Type().AssignableTo(dst.Type()) {
+			copied := reflect.New(src.Type().Elem())
+			copied.Elem().Set(src.Elem())
+			dst.Set(copied)
+			return nil
+		}
+		src = src.Elem()
+	}
+
+	if dst.Type() == deletedAtType {
+		if t, ok := src.Interface().(time.Time); ok {
+			dst.Set(reflect.ValueOf(gorm.DeletedAt{Time: t, Valid: true}))
+			return nil
+		}
+	}
+
+	if dst.Kind() == reflect.Pointer {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assign(elem.Elem(), src.Interface()); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	if sameKind(src.Kind(), dst.Kind()) && src.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("memory: can't store %T in %s", value, dst.Type())
+}
+
+func sameKind(a, b reflect.Kind) bool {
+	kind := func(k reflect.Kind) string {
+		switch k {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return "number"
+		}
+		return k.String()
+	}
+	return kind(a) == kind(b)
+}
+
+// normalize turns a field or argument into nil, float64, string, bool or time.Time so they can be compared
+func normalize(value any) any {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case gorm.DeletedAt:
+		if !v.Valid {
+			return nil
+		}
+		return v.Time
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Type() == timeType {
+		return normalize(rv.Interface())
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		// booleans are tinyint columns in mysql, true equals 1
+		if rv.Bool() {
+			return float64(1)
+		}
+		return float64(0)
+	}
+
+	return rv.Interface()
+}
+
+// compareValues orders two values after normalize, NULL sorts first like in mysql
+func compareValues(a, b any) int {
+	a, b = normalize(a), normalize(b)
+
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+		// a time compared with a string is compared as a date time, like mysql does
+		if y, ok := b.(string); ok {
+			return strings.Compare(x.Format(time.DateTime), y)
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
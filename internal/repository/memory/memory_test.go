@@ -0,0 +1,122 @@
+package memory_test
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository/memory"
+	"clean-arch/pkg/clock"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhere(t *testing.T) {
+	c := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	repo := memory.NewRepository[model.User](memory.NewDB(c))
+	ctx := context.Background()
+
+	for _, u := range []model.User{
+		{Name: "Andi", Email: "andi@example.com", Status: consts.UserStatusActive},
+		{Name: "Budi", Email: "BUDI@example.com", Status: consts.UserStatusSuspended},
+		{Name: "Citra", Email: "citra@example.com", Status: consts.UserStatusActive, PhoneNumber: "0812"},
+	} {
+		assert.Nil(t, repo.Store(ctx, &u))
+	}
+	assert.Nil(t, repo.DeleteOne(ctx, 3))
+
+	tests := []struct {
+		name  string
+		opts  []dbutil.QueryOption
+		count int
+	}{
+		{"equal", []dbutil.QueryOption{dbutil.Where("status = ?", consts.UserStatusActive)}, 1},
+		{"with deleted", []dbutil.QueryOption{dbutil.Where("status = ?", consts.UserStatusActive), dbutil.WithDeleted()}, 2},
+		{"only deleted", []dbutil.QueryOption{dbutil.OnlyDeleted()}, 1},
+		{"and or", []dbutil.QueryOption{dbutil.Where("(id = ? OR id = ?) AND name <> ?", 1, 2, "Andi")}, 1},
+		{"in", []dbutil.QueryOption{dbutil.Where("id IN ?", []int{1, 2, 3})}, 2},
+		{"not in", []dbutil.QueryOption{dbutil.Where("id NOT IN ?", []int{1})}, 1},
+		{"lower like", []dbutil.QueryOption{dbutil.Where("LOWER(email) LIKE ?", "budi%")}, 1},
+		{"like escape", []dbutil.QueryOption{dbutil.Where("email LIKE ? ESCAPE '!'", "%!_%")}, 0},
+		{"like escaped character", []dbutil.QueryOption{dbutil.Where("email LIKE ? ESCAPE '!'", "andi@example!.com")}, 1},
+		{"date", []dbutil.QueryOption{dbutil.Where("DATE(created_at) = ?", "2026-01-05")}, 2},
+		{"greater", []dbutil.QueryOption{dbutil.Where("created_at > ?", c.Now().Add(-time.Hour))}, 2},
+		{"is null", []dbutil.QueryOption{dbutil.Where("deleted_at IS NOT NULL"), dbutil.WithDeleted()}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := repo.Count(ctx, tt.opts...)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.count, count)
+		})
+	}
+
+	_, err := repo.Count(ctx, dbutil.Where("name REGEXP ?", "^A"))
+	assert.NotNil(t, err)
+}
+
+func TestOrderLimitOffset(t *testing.T) {
+	repo := memory.NewRepository[model.User](memory.NewDB(clock.System()))
+	ctx := context.Background()
+
+	for _, name := range []string{"Citra", "Andi", "Budi"} {
+		assert.Nil(t, repo.Store(ctx, &model.User{Name: name}))
+	}
+
+	res, total, err := repo.FindPage(ctx, "*", 2, 1, dbutil.Order("name asc"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "Budi", res[0].Name)
+	assert.Equal(t, "Citra", res[1].Name)
+
+	// the rows returned are copies, changing them doesn't change the table
+	res[0].Name = "Changed"
+	found, err := repo.FindOne(ctx, "*", dbutil.Where("id = ?", 3))
+	assert.Nil(t, err)
+	assert.Equal(t, "Budi", found.Name)
+}
+
+func TestOrganizationTenant(t *testing.T) {
+	c := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	repo := memory.NewOrganizationRepository(memory.NewDB(c))
+	ctx := context.Background()
+
+	acme, globex := model.Organization{Name: "Acme", Slug: "acme"}, model.Organization{Name: "Globex", Slug: "globex"}
+	assert.Nil(t, repo.Store(ctx, &acme))
+	assert.Nil(t, repo.Store(ctx, &globex))
+
+	inAcme, inGlobex := dbutil.WithTenant(ctx, acme.ID), dbutil.WithTenant(ctx, globex.ID)
+	assert.Nil(t, repo.StoreMember(inAcme, &model.OrganizationMember{UserID: 1, Role: consts.OrgRoleOwner}))
+	assert.Nil(t, repo.StoreMember(inGlobex, &model.OrganizationMember{UserID: 1, Role: consts.OrgRoleMember}))
+	assert.Nil(t, repo.StoreMember(inGlobex, &model.OrganizationMember{UserID: 2, Role: consts.OrgRoleOwner}))
+
+	assert.ErrorIs(t, repo.StoreMember(ctx, &model.OrganizationMember{UserID: 3}), dbutil.ErrNoTenant)
+	_, err := repo.FindAllMember(ctx, "*")
+	assert.ErrorIs(t, err, dbutil.ErrNoTenant)
+
+	count, err := repo.CountMember(inGlobex)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// the member of globex can't be reached from acme
+	assert.Nil(t, repo.DeleteMember(inAcme, 3))
+	_, err = repo.FindMember(inAcme, "*", dbutil.Where("id = ?", 3))
+	assert.NotNil(t, err)
+	count, _ = repo.CountMember(inGlobex)
+	assert.Equal(t, 2, count)
+
+	memberships, err := repo.FindMemberships(ctx, 1)
+	assert.Nil(t, err)
+	assert.Len(t, memberships, 2)
+	assert.Equal(t, "globex", memberships[1].Organization.Slug)
+
+	assert.Nil(t, repo.RemoveUser(ctx, 1))
+	memberships, _ = repo.FindMemberships(ctx, 1)
+	assert.Empty(t, memberships)
+	count, _ = repo.CountMember(inGlobex)
+	assert.Equal(t, 1, count)
+}
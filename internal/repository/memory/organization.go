@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/dbutil"
+	"context"
+)
+
+/*
+organization scopes members and invites to the tenant of the context like dbutil.TenantScope, the
+Preload options are ignored and only FindMemberships fills the organization of a member
+*/
+type organization struct {
+	repository.Repository[model.Organization]
+	members repository.Repository[model.OrganizationMember]
+	invites repository.Repository[model.OrganizationInvite]
+	db      *DB
+}
+
+func NewOrganizationRepository(db *DB) repository.Organization {
+	return &organization{
+		Repository: NewRepository[model.Organization](db),
+		members:    NewRepository[model.OrganizationMember](db),
+		invites:    NewRepository[model.OrganizationInvite](db),
+		db:         db,
+	}
+}
+
+// tenant puts the organization of the context in front of the options, it fails without one
+func tenant(ctx context.Context, opts []dbutil.QueryOption) ([]dbutil.QueryOption, error) {
+	orgID, ok := dbutil.TenantFromContext(ctx)
+	if !ok {
+		return nil, dbutil.ErrNoTenant
+	}
+
+	return append([]dbutil.QueryOption{dbutil.Where("organization_id = ?", orgID)}, opts...), nil
+}
+
+func (r *organization) FindMemberships(ctx context.Context, userID int) ([]*model.OrganizationMember, error) {
+	res, err := r.members.FindAll(ctx, "*", dbutil.Where("user_id = ?", userID), dbutil.Order("id asc"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range res {
+		org, err := r.FindOne(ctx, "*", dbutil.Where("id = ?", member.OrganizationID))
+		if err != nil {
+			continue
+		}
+		member.Organization = &org
+	}
+
+	return res, nil
+}
+
+func (r *organization) RemoveUser(ctx context.Context, userID int) error {
+	members := tableOf[model.OrganizationMember](r.db)
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := members.match([]dbutil.QueryOption{dbutil.Where("user_id = ?", userID)})
+	if err != nil {
+		return err
+	}
+	members.delete(rows, true)
+
+	return nil
+}
+
+func (r *organization) StoreMember(ctx context.Context, insertModel *model.OrganizationMember) error {
+	orgID, ok := dbutil.TenantFromContext(ctx)
+	if !ok {
+		return dbutil.ErrNoTenant
+	}
+	insertModel.OrganizationID = orgID
+
+	return r.members.Store(ctx, insertModel)
+}
+
+func (r *organization) FindMember(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OrganizationMember, error) {
+	opts, err := tenant(ctx, opts)
+	if err != nil {
+		return model.OrganizationMember{}, err
+	}
+
+	return r.members.FindOne(ctx, selectedFields, opts...)
+}
+
+func (r *organization) FindAllMember(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.OrganizationMember, error) {
+	opts, err := tenant(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.members.FindAll(ctx, selectedFields, opts...)
+}
+
+func (r *organization) CountMember(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
+	opts, err := tenant(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.members.Count(ctx, opts...)
+}
+
+func (r *organization) UpdateMember(ctx context.Context, id int, data map[string]any) error {
+	members := tableOf[model.OrganizationMember](r.db)
+
+	opts, err := tenant(ctx, []dbutil.QueryOption{dbutil.Where("id = ?", id)})
+	if err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := members.match(opts)
+	if err != nil {
+		return err
+	}
+
+	return members.updateColumns(rows, data)
+}
+
+func (r *organization) DeleteMember(ctx context.Context, id int) error {
+	members := tableOf[model.OrganizationMember](r.db)
+
+	opts, err := tenant(ctx, []dbutil.QueryOption{dbutil.Where("id = ?", id)})
+	if err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := members.match(opts)
+	if err != nil {
+		return err
+	}
+	members.delete(rows, true)
+
+	return nil
+}
+
+func (r *organization) StoreInvite(ctx context.Context, insertModel *model.OrganizationInvite) error {
+	orgID, ok := dbutil.TenantFromContext(ctx)
+	if !ok {
+		return dbutil.ErrNoTenant
+	}
+	insertModel.OrganizationID = orgID
+
+	return r.invites.Store(ctx, insertModel)
+}
+
+func (r *organization) FindInvite(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OrganizationInvite, error) {
+	opts, err := tenant(ctx, opts)
+	if err != nil {
+		return model.OrganizationInvite{}, err
+	}
+
+	return r.invites.FindOne(ctx, selectedFields, opts...)
+}
+
+func (r *organization) FindAllInvite(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.OrganizationInvite, error) {
+	opts, err := tenant(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.invites.FindAll(ctx, selectedFields, opts...)
+}
+
+func (r *organization) UpdateInvite(ctx context.Context, id int, data map[string]any) error {
+	invites := tableOf[model.OrganizationInvite](r.db)
+
+	opts, err := tenant(ctx, []dbutil.QueryOption{dbutil.Where("id = ?", id)})
+	if err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := invites.match(opts)
+	if err != nil {
+		return err
+	}
+
+	return invites.updateColumns(rows, data)
+}
+
+func (r *organization) DeleteInvite(ctx context.Context, id int) error {
+	invites := tableOf[model.OrganizationInvite](r.db)
+
+	opts, err := tenant(ctx, []dbutil.QueryOption{dbutil.Where("id = ?", id)})
+	if err != nil {
+		return err
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := invites.match(opts)
+	if err != nil {
+		return err
+	}
+	invites.delete(rows, true)
+
+	return nil
+}
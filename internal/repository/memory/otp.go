@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/dbutil"
+	"context"
+)
+
+type otp struct {
+	repository.Repository[model.OTP]
+}
+
+func NewOtpRepository(db *DB) repository.Otp {
+	return &otp{
+		Repository: NewRepository[model.OTP](db),
+	}
+}
+
+// FindLatest breaks ties on created_at with the id, a test clock gives many rows the same time
+func (r *otp) FindLatest(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OTP, error) {
+	return r.FindOne(ctx, selectedFields, append([]dbutil.QueryOption{dbutil.Order("created_at desc, id desc")}, opts...)...)
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/clock"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type redisEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+type redisRepository struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	entries map[string]redisEntry
+}
+
+// NewRedisRepository stores the values as json like the redis repository, a key past its duration on the clock is gone
+func NewRedisRepository(c clock.Clock) repository.Redis {
+	return &redisRepository{
+		clock:   c,
+		entries: map[string]redisEntry{},
+	}
+}
+
+func (r *redisRepository) Set(ctx context.Context, key string, value interface{}, duration time.Duration) error {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := redisEntry{value: string(jsonData)}
+	if duration > 0 {
+		entry.expiresAt = r.clock.Now().Add(duration)
+	}
+	r.entries[key] = entry
+
+	return nil
+}
+
+// Get returns redis.Nil for a missing key, the callers check for it
+func (r *redisRepository) Get(ctx context.Context, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok || (!entry.expiresAt.IsZero() && !r.clock.Now().Before(entry.expiresAt)) {
+		delete(r.entries, key)
+		return "", redis.Nil
+	}
+
+	return entry.value, nil
+}
+
+func (r *redisRepository) Del(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, key)
+	return nil
+}
@@ -0,0 +1,17 @@
+package memory
+
+import (
+	"clean-arch/pkg/dbutil"
+	"context"
+)
+
+type txManager struct{}
+
+// NewTxManager runs the function without a transaction, the writes made before an error are kept
+func NewTxManager() dbutil.TxManager {
+	return txManager{}
+}
+
+func (txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
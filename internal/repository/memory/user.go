@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/internal/repository"
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/dbutil"
+	"context"
+)
+
+type user struct {
+	repository.Repository[model.User]
+	db *DB
+}
+
+func NewUserRepository(db *DB) repository.User {
+	return &user{
+		Repository: NewRepository[model.User](db),
+		db:         db,
+	}
+}
+
+func (r *user) Restore(ctx context.Context, id int) error {
+	users := tableOf[model.User](r.db)
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := users.match([]dbutil.QueryOption{dbutil.Where("id = ?", id), dbutil.OnlyDeleted()})
+	if err != nil {
+		return err
+	}
+
+	return users.updateColumns(rows, map[string]any{"deleted_at": nil})
+}
+
+// ForceDelete removes the user row together with the login logs, otps and sessions like the gorm repository
+func (r *user) ForceDelete(ctx context.Context, id int) error {
+	logs := tableOf[model.LoginLog](r.db)
+	otps := tableOf[model.OTP](r.db)
+	sessions := tableOf[model.UserSession](r.db)
+	users := tableOf[model.User](r.db)
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	byUser := []dbutil.QueryOption{dbutil.Where("user_id = ?", id)}
+
+	rows, err := logs.match(byUser)
+	if err != nil {
+		return err
+	}
+	logs.delete(rows, true)
+
+	otpRows, err := otps.match(byUser)
+	if err != nil {
+		return err
+	}
+	otps.delete(otpRows, true)
+
+	sessionRows, err := sessions.match(byUser)
+	if err != nil {
+		return err
+	}
+	sessions.delete(sessionRows, true)
+
+	userRows, err := users.match([]dbutil.QueryOption{dbutil.Where("id = ?", id), dbutil.WithDeleted()})
+	if err != nil {
+		return err
+	}
+	users.delete(userRows, true)
+
+	return nil
+}
+
+func (r *user) FindSession(ctx context.Context, token string) (model.UserSession, error) {
+	return NewRepository[model.UserSession](r.db).FindOne(ctx, "*", dbutil.Where("refresh_token_hash = ? AND revoked = ?", token, consts.SessionActive))
+}
+
+func (r *user) FindSessionByID(ctx context.Context, id int) (model.UserSession, error) {
+	return NewRepository[model.UserSession](r.db).FindOne(ctx, "*", dbutil.Where("id = ? AND revoked = ? AND expires_at > ?", id, consts.SessionActive, r.db.clock.Now()))
+}
+
+func (r *user) FindAllSession(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.UserSession, error) {
+	return NewRepository[model.UserSession](r.db).FindAll(ctx, selectedFields, opts...)
+}
+
+func (r *user) CreateSession(ctx context.Context, sessionData *model.UserSession) error {
+	return NewRepository[model.UserSession](r.db).Store(ctx, sessionData)
+}
+
+func (r *user) FindLoginLog(ctx context.Context, opts ...dbutil.QueryOption) (model.LoginLog, error) {
+	return NewRepository[model.LoginLog](r.db).FindOne(ctx, "*", opts...)
+}
+
+func (r *user) FindAllLoginLog(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.LoginLog, error) {
+	return NewRepository[model.LoginLog](r.db).FindAll(ctx, selectedFields, opts...)
+}
+
+func (r *user) StoreLoginLog(ctx context.Context, insertModel model.LoginLog) error {
+	return NewRepository[model.LoginLog](r.db).Store(ctx, &insertModel)
+}
+
+func (r *user) CountLoginLog(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
+	return NewRepository[model.LoginLog](r.db).Count(ctx, opts...)
+}
+
+func (r *user) RevokeSession(ctx context.Context, id int) error {
+	return NewRepository[model.UserSession](r.db).UpdateOne(ctx, id, model.UserSession{Revoked: consts.SessionRevoked})
+}
+
+func (r *user) RevokeUserSessions(ctx context.Context, userID int) error {
+	return r.revokeSessions(dbutil.Where("user_id = ? AND revoked = ?", userID, consts.SessionActive))
+}
+
+func (r *user) RevokeOtherSessions(ctx context.Context, userID int, sessionID int) error {
+	return r.revokeSessions(dbutil.Where("user_id = ? AND id <> ? AND revoked = ?", userID, sessionID, consts.SessionActive))
+}
+
+func (r *user) revokeSessions(opts ...dbutil.QueryOption) error {
+	sessions := tableOf[model.UserSession](r.db)
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := sessions.match(opts)
+	if err != nil {
+		return err
+	}
+
+	return sessions.updateColumns(rows, map[string]any{"revoked": consts.SessionRevoked})
+}
+
+func (r *user) UpdateSession(ctx context.Context, id int, data model.UserSession) error {
+	return NewRepository[model.UserSession](r.db).UpdateOne(ctx, id, data)
+}
+
+func (r *user) SetSessionOrganization(ctx context.Context, id int, orgID *int) error {
+	return NewRepository[model.UserSession](r.db).UpdateColumns(ctx, id, map[string]any{"organization_id": orgID})
+}
+
+func (r *user) AnonymizeLoginLogs(ctx context.Context, userID int) error {
+	logs := tableOf[model.LoginLog](r.db)
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rows, err := logs.match([]dbutil.QueryOption{dbutil.Where("user_id = ?", userID)})
+	if err != nil {
+		return err
+	}
+
+	return logs.updateColumns(rows, map[string]any{
+		"user_id":    0,
+		"ip_address": "",
+		"user_agent": "",
+		"region":     "",
+		"city":       "",
+	})
+}
+
+func (r *user) StoreErasureReceipt(ctx context.Context, insertModel *model.ErasureReceipt) error {
+	return NewRepository[model.ErasureReceipt](r.db).Store(ctx, insertModel)
+}
@@ -0,0 +1,336 @@
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// condition is a parsed where clause with its arguments bound, lookup reads a column of the row
+type condition interface {
+	eval(lookup func(column string) (any, error)) (bool, error)
+}
+
+type andCondition []condition
+
+type orCondition []condition
+
+// comparison is one column compared with one argument, fn is DATE or LOWER applied to the column
+type comparison struct {
+	column string
+	fn     string
+	op     string
+	arg    any
+	// escape is the character of a LIKE ... ESCAPE 'c'
+	escape string
+}
+
+var tokenPattern = regexp.MustCompile(`\s*(<>|!=|>=|<=|=|<|>|\(|\)|\?|'[^']*'|[\w.` + "`" + `"]+)`)
+
+type parser struct {
+	tokens []string
+	pos    int
+	args   []any
+	argPos int
+	query  string
+}
+
+func parseCondition(query string, args []any) (condition, error) {
+	p := &parser{args: args, query: query}
+
+	rest := query
+	for strings.TrimSpace(rest) != "" {
+		loc := tokenPattern.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[0] != 0 {
+			return nil, p.unsupported()
+		}
+		p.tokens = append(p.tokens, rest[loc[2]:loc[3]])
+		rest = rest[loc[1]:]
+	}
+
+	cond, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.tokens) || p.argPos != len(p.args) {
+		return nil, p.unsupported()
+	}
+
+	return cond, nil
+}
+
+func (p *parser) unsupported() error {
+	return fmt.Errorf("memory: unsupported condition %q", p.query)
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return strings.ToUpper(p.tokens[p.pos])
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *parser) expect(tokens ...string) error {
+	for _, token := range tokens {
+		if p.next() != token {
+			return p.unsupported()
+		}
+	}
+	return nil
+}
+
+func (p *parser) arg() (any, error) {
+	if err := p.expect("?"); err != nil {
+		return nil, err
+	}
+	if p.argPos >= len(p.args) {
+		return nil, p.unsupported()
+	}
+	arg := p.args[p.argPos]
+	p.argPos++
+	return arg, nil
+}
+
+func (p *parser) or() (condition, error) {
+	var res orCondition
+	for {
+		cond, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cond)
+
+		if p.peek() != "OR" {
+			break
+		}
+		p.next()
+	}
+
+	if len(res) == 1 {
+		return res[0], nil
+	}
+	return res, nil
+}
+
+func (p *parser) and() (condition, error) {
+	var res andCondition
+	for {
+		cond, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cond)
+
+		if p.peek() != "AND" {
+			break
+		}
+		p.next()
+	}
+
+	if len(res) == 1 {
+		return res[0], nil
+	}
+	return res, nil
+}
+
+func (p *parser) primary() (condition, error) {
+	if p.peek() == "(" {
+		p.next()
+		cond, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	}
+
+	return p.comparison()
+}
+
+func (p *parser) comparison() (condition, error) {
+	var res comparison
+
+	res.column = p.next()
+	if res.column == "DATE" || res.column == "LOWER" {
+		res.fn = res.column
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		res.column = p.next()
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	res.column = columnName(strings.ToLower(res.column))
+
+	switch op := p.next(); op {
+	case "=", "!=", "<>", ">", ">=", "<", "<=", "IN", "LIKE":
+		res.op = op
+	case "NOT":
+		res.op = "NOT " + p.next()
+		if res.op != "NOT IN" && res.op != "NOT LIKE" {
+			return nil, p.unsupported()
+		}
+	case "IS":
+		res.op = "IS NULL"
+		if p.peek() == "NOT" {
+			p.next()
+			res.op = "IS NOT NULL"
+		}
+		if err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return res, nil
+	default:
+		return nil, p.unsupported()
+	}
+
+	arg, err := p.arg()
+	if err != nil {
+		return nil, err
+	}
+	res.arg = arg
+
+	if strings.HasSuffix(res.op, "LIKE") && p.peek() == "ESCAPE" {
+		p.next()
+		literal := p.next()
+		if len(literal) != 3 || literal[0] != '\'' || literal[2] != '\'' {
+			return nil, p.unsupported()
+		}
+		res.escape = literal[1:2]
+	}
+
+	return res, nil
+}
+
+func (c andCondition) eval(lookup func(column string) (any, error)) (bool, error) {
+	for _, cond := range c {
+		ok, err := cond.eval(lookup)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (c orCondition) eval(lookup func(column string) (any, error)) (bool, error) {
+	for _, cond := range c {
+		ok, err := cond.eval(lookup)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (c comparison) eval(lookup func(column string) (any, error)) (bool, error) {
+	value, err := lookup(c.column)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.fn {
+	case "DATE":
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(time.DateOnly)
+		}
+	case "LOWER":
+		if s, ok := value.(string); ok {
+			value = strings.ToLower(s)
+		}
+	}
+
+	switch c.op {
+	case "IS NULL":
+		return value == nil, nil
+	case "IS NOT NULL":
+		return value != nil, nil
+	}
+
+	// a comparison with NULL is never true
+	if value == nil {
+		return false, nil
+	}
+
+	switch c.op {
+	case "IN", "NOT IN":
+		list := reflect.ValueOf(c.arg)
+		if list.Kind() != reflect.Slice {
+			return false, fmt.Errorf("memory: %s needs a slice, got %T", c.op, c.arg)
+		}
+
+		found := false
+		for i := 0; i < list.Len(); i++ {
+			if compareValues(value, list.Index(i).Interface()) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (c.op == "IN"), nil
+	case "LIKE", "NOT LIKE":
+		matched, err := regexp.MatchString(likePattern(fmt.Sprint(normalize(c.arg)), c.escape), fmt.Sprint(value))
+		if err != nil {
+			return false, err
+		}
+		return matched == (c.op == "LIKE"), nil
+	}
+
+	if normalize(c.arg) == nil {
+		return false, nil
+	}
+
+	cmp := compareValues(value, c.arg)
+	switch c.op {
+	case "=":
+		return cmp == 0, nil
+	case "!=", "<>":
+		return cmp != 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	}
+
+	return false, fmt.Errorf("memory: unsupported operator %s", c.op)
+}
+
+// likePattern turns a LIKE pattern into a regexp, a character after the escape matches itself
+func likePattern(pattern string, escape string) string {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case escape != "" && string(r) == escape:
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+	return b.String()
+}
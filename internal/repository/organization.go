@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"clean-arch/internal/model"
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+/*
+Organization members and invites are owned by a tenant, their methods only see the organization
+of the context and fail with dbutil.ErrNoTenant without one, see dbutil.TenantScope
+
+FindMemberships and RemoveUser work across organizations on purpose, they are keyed by the user
+*/
+type Organization interface {
+	Store(ctx context.Context, insertModel *model.Organization) error
+	UpdateColumns(ctx context.Context, id int, data map[string]any) error
+	FindOne(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.Organization, error)
+	FindMemberships(ctx context.Context, userID int) ([]*model.OrganizationMember, error)
+	RemoveUser(ctx context.Context, userID int) error
+
+	StoreMember(ctx context.Context, insertModel *model.OrganizationMember) error
+	FindMember(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OrganizationMember, error)
+	FindAllMember(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.OrganizationMember, error)
+	CountMember(ctx context.Context, opts ...dbutil.QueryOption) (int, error)
+	UpdateMember(ctx context.Context, id int, data map[string]any) error
+	DeleteMember(ctx context.Context, id int) error
+
+	StoreInvite(ctx context.Context, insertModel *model.OrganizationInvite) error
+	FindInvite(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OrganizationInvite, error)
+	FindAllInvite(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.OrganizationInvite, error)
+	UpdateInvite(ctx context.Context, id int, data map[string]any) error
+	DeleteInvite(ctx context.Context, id int) error
+}
+
+type organization struct {
+	Db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) Organization {
+	return &organization{
+		Db: db,
+	}
+}
+
+func (r *organization) Store(ctx context.Context, insertModel *model.Organization) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(model.Organization{}).Create(insertModel).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *organization) UpdateColumns(ctx context.Context, id int, data map[string]any) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(&model.Organization{}).Where("id = ?", id).Updates(data).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *organization) FindOne(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.Organization, error) {
+	var res model.Organization
+
+	db := dbutil.Conn(ctx, r.Db).Model(model.Organization{})
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.ApplyScopes(opts...)).Take(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// FindMemberships returns every organization the user belongs to with the role, it is the only read across tenants
+func (r *organization) FindMemberships(ctx context.Context, userID int) ([]*model.OrganizationMember, error) {
+	var res []*model.OrganizationMember
+
+	err := dbutil.Conn(ctx, r.Db).Model(&model.OrganizationMember{}).
+		Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("id asc").
+		Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// RemoveUser deletes the memberships of the user in every organization, used once the account is erased
+func (r *organization) RemoveUser(ctx context.Context, userID int) error {
+	if err := dbutil.Conn(ctx, r.Db).Where("user_id = ?", userID).Delete(&model.OrganizationMember{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// StoreMember adds the member to the organization of the context, OrganizationID is always overwritten
+func (r *organization) StoreMember(ctx context.Context, insertModel *model.OrganizationMember) error {
+	orgID, ok := dbutil.TenantFromContext(ctx)
+	if !ok {
+		return dbutil.ErrNoTenant
+	}
+	insertModel.OrganizationID = orgID
+
+	if err := dbutil.Conn(ctx, r.Db).Model(model.OrganizationMember{}).Create(insertModel).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *organization) FindMember(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OrganizationMember, error) {
+	var res model.OrganizationMember
+
+	db := dbutil.Conn(ctx, r.Db).Model(model.OrganizationMember{})
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.TenantScope, dbutil.ApplyScopes(opts...)).Take(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *organization) FindAllMember(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.OrganizationMember, error) {
+	var res []*model.OrganizationMember
+
+	db := dbutil.Conn(ctx, r.Db).Model(&model.OrganizationMember{})
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.TenantScope, dbutil.ApplyScopes(opts...)).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *organization) CountMember(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
+	var (
+		res int64
+	)
+
+	err := dbutil.Conn(ctx, r.Db).Model(model.OrganizationMember{}).Select("id").Scopes(dbutil.TenantScope, dbutil.ApplyScopes(opts...)).Count(&res).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res), nil
+}
+
+func (r *organization) UpdateMember(ctx context.Context, id int, data map[string]any) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(&model.OrganizationMember{}).Scopes(dbutil.TenantScope).Where("id = ?", id).Updates(data).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *organization) DeleteMember(ctx context.Context, id int) error {
+	if err := dbutil.Conn(ctx, r.Db).Scopes(dbutil.TenantScope).Where("id = ?", id).Delete(&model.OrganizationMember{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// StoreInvite adds the invite to the organization of the context, OrganizationID is always overwritten
+func (r *organization) StoreInvite(ctx context.Context, insertModel *model.OrganizationInvite) error {
+	orgID, ok := dbutil.TenantFromContext(ctx)
+	if !ok {
+		return dbutil.ErrNoTenant
+	}
+	insertModel.OrganizationID = orgID
+
+	if err := dbutil.Conn(ctx, r.Db).Model(model.OrganizationInvite{}).Create(insertModel).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *organization) FindInvite(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OrganizationInvite, error) {
+	var res model.OrganizationInvite
+
+	db := dbutil.Conn(ctx, r.Db).Model(model.OrganizationInvite{})
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.TenantScope, dbutil.ApplyScopes(opts...)).Take(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *organization) FindAllInvite(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.OrganizationInvite, error) {
+	var res []*model.OrganizationInvite
+
+	db := dbutil.Conn(ctx, r.Db).Model(&model.OrganizationInvite{})
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.TenantScope, dbutil.ApplyScopes(opts...)).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *organization) UpdateInvite(ctx context.Context, id int, data map[string]any) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(&model.OrganizationInvite{}).Scopes(dbutil.TenantScope).Where("id = ?", id).Updates(data).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *organization) DeleteInvite(ctx context.Context, id int) error {
+	if err := dbutil.Conn(ctx, r.Db).Scopes(dbutil.TenantScope).Where("id = ?", id).Delete(&model.OrganizationInvite{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -2,71 +2,30 @@ package repository
 
 import (
 	"clean-arch/internal/model"
-	"clean-arch/pkg/util"
+	"clean-arch/pkg/dbutil"
 	"context"
 
 	"gorm.io/gorm"
 )
 
 type Otp interface {
-	StoreOTP(db *gorm.DB, insertModel model.OTP) error
-	CountOTP(ctx context.Context, query string, args ...any) (int, error)
-	FindOne(ctx context.Context, lastest bool, selectedFields string, query string, args ...any) (model.OTP, error)
-	UpdateOne(db *gorm.DB, updateModel model.OTP, query string, args ...interface{}) error
+	Repository[model.OTP]
+	FindLatest(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OTP, error)
 }
 
 type otp struct {
+	Repository[model.OTP]
 	Db *gorm.DB
 }
 
 func NewOtpRepository(db *gorm.DB) Otp {
 	return &otp{
-		Db: db,
+		Repository: NewRepository[model.OTP](db),
+		Db:         db,
 	}
 }
 
-func (r *otp) UpdateOne(db *gorm.DB, updateModel model.OTP, query string, args ...interface{}) error {
-	if err := db.Model(&model.OTP{}).Where(query, args...).Updates(updateModel).Error; err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (r *otp) CountOTP(ctx context.Context, query string, args ...any) (int, error) {
-	var (
-		res int64
-	)
-
-	err := r.Db.WithContext(ctx).Model(model.OTP{}).Select("id").Where(query, args...).Count(&res).Error
-	if err != nil {
-		return 0, err
-	}
-
-	return int(res), nil
-}
-
-func (r *otp) StoreOTP(db *gorm.DB, insertModel model.OTP) error {
-	if err := db.Model(model.OTP{}).Create(&insertModel).Error; err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (r *otp) FindOne(ctx context.Context, lastest bool, selectedFields string, query string, args ...any) (model.OTP, error) {
-	var res model.OTP
-
-	db := r.Db.WithContext(ctx).Model(model.OTP{})
-	db = util.SetSelectFields(db, selectedFields)
-
-	if lastest {
-		db = db.Order("created_at desc")
-	}
-
-	if err := db.Where(query, args...).Take(&res).Error; err != nil {
-		return res, err
-	}
-
-	return res, nil
+// FindLatest returns the newest otp that matches the options
+func (r *otp) FindLatest(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.OTP, error) {
+	return r.FindOne(ctx, selectedFields, append([]dbutil.QueryOption{dbutil.Order("created_at desc")}, opts...)...)
 }
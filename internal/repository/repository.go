@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"clean-arch/pkg/dbutil"
+	"clean-arch/pkg/util"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+Repository holds the queries every entity needs, the entity repositories embed it and only write
+the queries specific to them. T is the model, its table comes from T.TableName and its primary
+key is id
+
+Every method starts its query from dbutil.Conn, inside a TxManager.WithinTx the context carries
+the transaction and reads and writes join it, outside one they run on the repository connection.
+Reads and UpdateAll take dbutil options, the same ones the handlers build from a query
+*/
+type Repository[T any] interface {
+	FindOne(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (T, error)
+	FindAll(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*T, error)
+	FindPage(ctx context.Context, selectedFields string, limit int, offset int, opts ...dbutil.QueryOption) ([]*T, int, error)
+	Count(ctx context.Context, opts ...dbutil.QueryOption) (int, error)
+	Exists(ctx context.Context, opts ...dbutil.QueryOption) (bool, error)
+	Store(ctx context.Context, insertModel *T) error
+	StoreBatch(ctx context.Context, insertModels []*T, batchSize int) error
+	Upsert(ctx context.Context, insertModel *T, conflictColumns []string, updateColumns ...string) error
+	UpdateOne(ctx context.Context, id int, data T) error
+	UpdateColumns(ctx context.Context, id int, data map[string]any) error
+	UpdateAll(ctx context.Context, data T, selectedFields string, opts ...dbutil.QueryOption) error
+	DeleteOne(ctx context.Context, id int) error
+}
+
+type repository[T any] struct {
+	Db *gorm.DB
+}
+
+func NewRepository[T any](db *gorm.DB) Repository[T] {
+	return &repository[T]{
+		Db: db,
+	}
+}
+
+func (r *repository[T]) FindOne(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (T, error) {
+	var res T
+
+	db := dbutil.Conn(ctx, r.Db).Model(new(T))
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.ApplyScopes(opts...)).Take(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *repository[T]) FindAll(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*T, error) {
+	var res []*T
+
+	db := dbutil.Conn(ctx, r.Db).Model(new(T))
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.ApplyScopes(opts...)).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// FindPage returns a page of the rows with the total that match the options, the options must not set a limit or offset
+func (r *repository[T]) FindPage(ctx context.Context, selectedFields string, limit int, offset int, opts ...dbutil.QueryOption) ([]*T, int, error) {
+	total, err := r.Count(ctx, opts...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 || offset >= total {
+		return []*T{}, total, nil
+	}
+
+	res, err := r.FindAll(ctx, selectedFields, append(opts, dbutil.Limit(limit), dbutil.Offset(offset))...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, total, nil
+}
+
+func (r *repository[T]) Count(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
+	var (
+		res int64
+	)
+
+	err := dbutil.Conn(ctx, r.Db).Model(new(T)).Select("id").Scopes(dbutil.ApplyScopes(opts...)).Count(&res).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res), nil
+}
+
+// Exists stops at the first matching row, use it instead of Count when the number doesn't matter
+func (r *repository[T]) Exists(ctx context.Context, opts ...dbutil.QueryOption) (bool, error) {
+	var res []int
+
+	err := dbutil.Conn(ctx, r.Db).Model(new(T)).Scopes(dbutil.ApplyScopes(opts...)).Limit(1).Pluck("id", &res).Error
+	if err != nil {
+		return false, err
+	}
+
+	return len(res) > 0, nil
+}
+
+func (r *repository[T]) Store(ctx context.Context, insertModel *T) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(new(T)).Create(insertModel).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository[T]) StoreBatch(ctx context.Context, insertModels []*T, batchSize int) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(new(T)).CreateInBatches(insertModels, batchSize).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Upsert inserts the row or updates it when the conflict columns already exist, without update columns every column is updated
+func (r *repository[T]) Upsert(ctx context.Context, insertModel *T, conflictColumns []string, updateColumns ...string) error {
+	onConflict := clause.OnConflict{}
+	for _, column := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+
+	if len(updateColumns) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(updateColumns)
+	} else {
+		onConflict.UpdateAll = true
+	}
+
+	if err := dbutil.Conn(ctx, r.Db).Model(new(T)).Clauses(onConflict).Create(insertModel).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateOne skips the zero values of data, use UpdateColumns to write them
+func (r *repository[T]) UpdateOne(ctx context.Context, id int, data T) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(new(T)).Where("id = ?", id).Updates(data).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateColumns updates the given columns as is, unlike UpdateOne zero values such as NULL are written too
+func (r *repository[T]) UpdateColumns(ctx context.Context, id int, data map[string]any) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(new(T)).Where("id = ?", id).Updates(data).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository[T]) UpdateAll(ctx context.Context, data T, selectedFields string, opts ...dbutil.QueryOption) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(new(T)).Select(selectedFields).Scopes(dbutil.ApplyScopes(opts...)).Updates(data).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteOne soft deletes the row when T has a gorm.DeletedAt, otherwise the row is removed
+func (r *repository[T]) DeleteOne(ctx context.Context, id int) error {
+	if err := dbutil.Conn(ctx, r.Db).Delete(new(T), id).Error; err != nil {
+		return err
+	}
+	return nil
+}
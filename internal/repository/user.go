@@ -2,6 +2,7 @@ package repository
 
 import (
 	"clean-arch/internal/model"
+	"clean-arch/pkg/clock"
 	"clean-arch/pkg/consts"
 	"clean-arch/pkg/dbutil"
 	"clean-arch/pkg/util"
@@ -10,46 +11,65 @@ import (
 	"gorm.io/gorm"
 )
 
+// User gets the queries on users from Repository, DeleteOne soft deletes and ForceDelete removes the row
 type User interface {
-	FindAll(ctx context.Context, selectedFields string, otps ...dbutil.QueryOption) ([]*model.User, error)
-	FindOne(ctx context.Context, selectedFields string, otps ...dbutil.QueryOption) (model.User, error)
-	Store(db *gorm.DB, insertModel model.User) error
-	UpdateOne(db *gorm.DB, id int, data model.User) error
-	UpdateAll(db *gorm.DB, data model.User, selectedFields string, otps ...dbutil.QueryOption) error
-	DeleteOne(db *gorm.DB, id int) error
-	Count(ctx context.Context, otps ...dbutil.QueryOption) (int, error)
+	Repository[model.User]
+	Restore(ctx context.Context, id int) error
+	ForceDelete(ctx context.Context, id int) error
 
 	FindSession(ctx context.Context, token string) (model.UserSession, error)
-	CreateSession(db *gorm.DB, sessionData model.UserSession) error
+	FindSessionByID(ctx context.Context, id int) (model.UserSession, error)
+	FindAllSession(ctx context.Context, selectedFields string, otps ...dbutil.QueryOption) ([]*model.UserSession, error)
+	CreateSession(ctx context.Context, sessionData *model.UserSession) error
 	FindLoginLog(ctx context.Context, otps ...dbutil.QueryOption) (model.LoginLog, error)
-	StoreLoginLog(db *gorm.DB, insertModel model.LoginLog) error
-	RevokeSession(db *gorm.DB, bearer string) error
-	UpdateSession(db *gorm.DB, id int, data model.UserSession) error
+	FindAllLoginLog(ctx context.Context, selectedFields string, otps ...dbutil.QueryOption) ([]*model.LoginLog, error)
+	StoreLoginLog(ctx context.Context, insertModel model.LoginLog) error
+	CountLoginLog(ctx context.Context, otps ...dbutil.QueryOption) (int, error)
+	RevokeSession(ctx context.Context, id int) error
+	RevokeUserSessions(ctx context.Context, userID int) error
+	RevokeOtherSessions(ctx context.Context, userID int, sessionID int) error
+	UpdateSession(ctx context.Context, id int, data model.UserSession) error
+	SetSessionOrganization(ctx context.Context, id int, orgID *int) error
+	AnonymizeLoginLogs(ctx context.Context, userID int) error
+	StoreErasureReceipt(ctx context.Context, insertModel *model.ErasureReceipt) error
 }
 
 type user struct {
-	Db *gorm.DB
+	Repository[model.User]
+	Db    *gorm.DB
+	Clock clock.Clock
 }
 
-func NewUserRepository(db *gorm.DB) User {
+// NewUserRepository takes the clock sessions expire on
+func NewUserRepository(db *gorm.DB, c clock.Clock) User {
 	return &user{
-		Db: db,
+		Repository: NewRepository[model.User](db),
+		Db:         db,
+		Clock:      c,
 	}
 }
 
-func (r *user) UpdateSession(db *gorm.DB, id int, data model.UserSession) error {
-	if err := db.Model(&model.UserSession{}).Where("id = ?", id).Updates(data).Error; err != nil {
+func (r *user) UpdateSession(ctx context.Context, id int, data model.UserSession) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(&model.UserSession{}).Where("id = ?", id).Updates(data).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *user) RevokeSession(db *gorm.DB, bearer string) error {
+// SetSessionOrganization changes the organization put in the access tokens of the session, nil clears it
+func (r *user) SetSessionOrganization(ctx context.Context, id int, orgID *int) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(&model.UserSession{}).Where("id = ?", id).Update("organization_id", orgID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *user) RevokeSession(ctx context.Context, id int) error {
 	modelUpdate := model.UserSession{
-		Revoked: 1,
+		Revoked: consts.SessionRevoked,
 	}
 
-	err := db.Model(model.UserSession{}).Where("refresh_token_hash = ?", bearer).Updates(modelUpdate).Error
+	err := dbutil.Conn(ctx, r.Db).Model(model.UserSession{}).Where("id = ?", id).Updates(modelUpdate).Error
 	if err != nil {
 		return err
 	}
@@ -57,64 +77,105 @@ func (r *user) RevokeSession(db *gorm.DB, bearer string) error {
 	return nil
 }
 
-func (r *user) StoreLoginLog(db *gorm.DB, insertModel model.LoginLog) error {
-	if err := db.Model(model.LoginLog{}).Create(&insertModel).Error; err != nil {
+// AnonymizeLoginLogs unlinks the login logs from the user and drops what identifies them, the coarse client info stays for statistics
+func (r *user) AnonymizeLoginLogs(ctx context.Context, userID int) error {
+	err := dbutil.Conn(ctx, r.Db).Model(&model.LoginLog{}).Where("user_id = ?", userID).Updates(map[string]any{
+		"user_id":    0,
+		"ip_address": "",
+		"user_agent": "",
+		"region":     "",
+		"city":       "",
+	}).Error
+	if err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (r *user) FindLoginLog(ctx context.Context, opts ...dbutil.QueryOption) (model.LoginLog, error) {
-	var (
-		res model.LoginLog
-	)
+func (r *user) StoreErasureReceipt(ctx context.Context, insertModel *model.ErasureReceipt) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(model.ErasureReceipt{}).Create(insertModel).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *user) RevokeOtherSessions(ctx context.Context, userID int, sessionID int) error {
+	modelUpdate := model.UserSession{
+		Revoked: consts.SessionRevoked,
+	}
 
-	err := r.Db.WithContext(ctx).Model(&model.LoginLog{}).Scopes(dbutil.ApplyScopes(opts...)).Take(&res).Error
+	err := dbutil.Conn(ctx, r.Db).Model(model.UserSession{}).Where("user_id = ? AND id <> ? AND revoked = ?", userID, sessionID, consts.SessionActive).Updates(modelUpdate).Error
 	if err != nil {
-		return res, err
+		return err
 	}
 
-	return res, nil
+	return nil
 }
 
-func (r *user) Store(db *gorm.DB, insertModel model.User) error {
-	if err := db.Model(model.User{}).Create(&insertModel).Error; err != nil {
+func (r *user) RevokeUserSessions(ctx context.Context, userID int) error {
+	modelUpdate := model.UserSession{
+		Revoked: consts.SessionRevoked,
+	}
+
+	err := dbutil.Conn(ctx, r.Db).Model(model.UserSession{}).Where("user_id = ? AND revoked = ?", userID, consts.SessionActive).Updates(modelUpdate).Error
+	if err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (r *user) FindAll(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.User, error) {
-	var res []*model.User
-	db := r.Db.Model(&model.User{})
-	db = util.SetSelectFields(db, selectedFields)
-	db = db.Scopes(dbutil.ApplyScopes(opts...)).Debug()
+func (r *user) StoreLoginLog(ctx context.Context, insertModel model.LoginLog) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(model.LoginLog{}).Create(&insertModel).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *user) FindLoginLog(ctx context.Context, opts ...dbutil.QueryOption) (model.LoginLog, error) {
+	var (
+		res model.LoginLog
+	)
 
-	err := db.Find(&res).Error
+	err := dbutil.Conn(ctx, r.Db).Model(&model.LoginLog{}).Scopes(dbutil.ApplyScopes(opts...)).Take(&res).Error
 	if err != nil {
-		return nil, err
+		return res, err
 	}
 
 	return res, nil
 }
 
-func (r *user) FindOne(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) (model.User, error) {
-	var res model.User
+func (r *user) FindAllLoginLog(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.LoginLog, error) {
+	var res []*model.LoginLog
 
-	db := r.Db.WithContext(ctx).Model(model.User{})
+	db := dbutil.Conn(ctx, r.Db).Model(&model.LoginLog{})
 	db = util.SetSelectFields(db, selectedFields)
 
-	if err := db.Scopes(dbutil.ApplyScopes(opts...)).Take(&res).Error; err != nil {
-		return res, err
+	if err := db.Scopes(dbutil.ApplyScopes(opts...)).Find(&res).Error; err != nil {
+		return nil, err
 	}
 
 	return res, nil
 }
 
-func (r *user) CreateSession(db *gorm.DB, sessionData model.UserSession) error {
-	if err := db.Model(model.UserSession{}).Create(&sessionData).Error; err != nil {
+func (r *user) CountLoginLog(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
+	var (
+		res int64
+	)
+
+	err := dbutil.Conn(ctx, r.Db).Model(model.LoginLog{}).Select("id").Scopes(dbutil.ApplyScopes(opts...)).Count(&res).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res), nil
+}
+
+func (r *user) CreateSession(ctx context.Context, sessionData *model.UserSession) error {
+	if err := dbutil.Conn(ctx, r.Db).Model(model.UserSession{}).Create(sessionData).Error; err != nil {
 		return err
 	}
 
@@ -124,7 +185,7 @@ func (r *user) CreateSession(db *gorm.DB, sessionData model.UserSession) error {
 func (r *user) FindSession(ctx context.Context, token string) (model.UserSession, error) {
 	var res model.UserSession
 
-	db := r.Db.WithContext(ctx).Model(model.UserSession{})
+	db := dbutil.Conn(ctx, r.Db).Model(model.UserSession{})
 	if err := db.Where("refresh_token_hash = ? AND revoked = ?", token, consts.SessionActive).Take(&res).Error; err != nil {
 		return model.UserSession{}, err
 	}
@@ -132,35 +193,55 @@ func (r *user) FindSession(ctx context.Context, token string) (model.UserSession
 	return res, nil
 }
 
-func (r *user) UpdateOne(db *gorm.DB, id int, data model.User) error {
-	if err := db.Model(&model.User{}).Where("id = ?", id).Updates(data).Error; err != nil {
-		return err
+// FindSessionByID only returns the session while it is neither revoked nor expired
+func (r *user) FindSessionByID(ctx context.Context, id int) (model.UserSession, error) {
+	var res model.UserSession
+
+	db := dbutil.Conn(ctx, r.Db).Model(model.UserSession{})
+	if err := db.Where("id = ? AND revoked = ? AND expires_at > ?", id, consts.SessionActive, r.Clock.Now()).Take(&res).Error; err != nil {
+		return model.UserSession{}, err
 	}
-	return nil
+
+	return res, nil
+}
+
+func (r *user) FindAllSession(ctx context.Context, selectedFields string, opts ...dbutil.QueryOption) ([]*model.UserSession, error) {
+	var res []*model.UserSession
+
+	db := dbutil.Conn(ctx, r.Db).Model(&model.UserSession{})
+	db = util.SetSelectFields(db, selectedFields)
+
+	if err := db.Scopes(dbutil.ApplyScopes(opts...)).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
-func (r *user) UpdateAll(db *gorm.DB, data model.User, selectedFields string, opts ...dbutil.QueryOption) error {
-	if err := db.Model(&model.User{}).Select(selectedFields).Scopes(dbutil.ApplyScopes(opts...)).Debug().Updates(data).Error; err != nil {
+func (r *user) Restore(ctx context.Context, id int) error {
+	if err := dbutil.Conn(ctx, r.Db).Unscoped().Model(&model.User{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *user) Count(ctx context.Context, opts ...dbutil.QueryOption) (int, error) {
-	var (
-		res int64
-	)
+// ForceDelete removes the user row together with the login logs and otps that have no foreign key
+func (r *user) ForceDelete(ctx context.Context, id int) error {
+	db := dbutil.Conn(ctx, r.Db)
 
-	err := r.Db.WithContext(ctx).Model(model.User{}).Select("id").Scopes(dbutil.ApplyScopes(opts...)).Count(&res).Error
-	if err != nil {
-		return 0, err
+	if err := db.Where("user_id = ?", id).Delete(&model.LoginLog{}).Error; err != nil {
+		return err
 	}
 
-	return int(res), nil
-}
+	if err := db.Where("user_id = ?", id).Delete(&model.OTP{}).Error; err != nil {
+		return err
+	}
+
+	if err := db.Where("user_id = ?", id).Delete(&model.UserSession{}).Error; err != nil {
+		return err
+	}
 
-func (r *user) DeleteOne(db *gorm.DB, id int) error {
-	if err := db.Delete(&model.User{}, id).Error; err != nil {
+	if err := db.Unscoped().Delete(&model.User{}, id).Error; err != nil {
 		return err
 	}
 	return nil
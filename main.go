@@ -13,13 +13,20 @@ import (
 	"clean-arch/database"
 	"clean-arch/database/migration"
 	"clean-arch/database/seeder"
+	"clean-arch/internal/app/user"
+	"clean-arch/internal/dto"
 	"clean-arch/internal/factory"
 	"clean-arch/internal/http"
 	"clean-arch/pkg/config"
 	"clean-arch/pkg/genx"
+	"clean-arch/pkg/sheet"
+	"clean-arch/pkg/util"
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/text/cases"
@@ -28,6 +35,11 @@ import (
 
 func main() {
 	config.LoadEnv(".env")
+	passwordParams, err := config.PasswordParams()
+	if err != nil {
+		log.Fatalf("invalid password config: %v", err)
+	}
+	util.SetPasswordParams(passwordParams)
 
 	var (
 		m   string
@@ -35,6 +47,10 @@ func main() {
 		i   bool
 		mmf string
 		gen string
+		imp string
+
+		impPartial bool
+		impInvite  bool
 	)
 
 	database.CreateConnection()
@@ -74,6 +90,27 @@ func main() {
 		`This flag is used for generating app file`,
 	)
 
+	flag.StringVar(
+		&imp,
+		"import",
+		"",
+		`This flag is used for importing users from a csv or xlsx file`,
+	)
+
+	flag.BoolVar(
+		&impPartial,
+		"import-partial",
+		false,
+		`This flag is used for importing the valid rows even when other rows fail`,
+	)
+
+	flag.BoolVar(
+		&impInvite,
+		"import-invite",
+		false,
+		`This flag is used for sending welcome emails to imported users`,
+	)
+
 	flag.Parse()
 
 	if i {
@@ -110,6 +147,20 @@ func main() {
 		return
 	}
 
+	// The factory is the only place the connections are handed to the app
+	f, err := factory.NewFactory(database.GetConnection(), database.GetRedisClient())
+	if err != nil {
+		log.Fatalf("failed to build factory: %v", err)
+	}
+
+	if imp != "" {
+		err := importUsers(f, imp, dto.PayloadImportUser{Partial: impPartial, SendInvite: impInvite, WaitInvites: true})
+		if err != nil {
+			log.Fatalf("failed to import users: %v", err)
+		}
+		return
+	}
+
 	if gen != "" {
 		if gen == "all" {
 			tmplData := genx.GetData()
@@ -121,12 +172,37 @@ func main() {
 		return
 	}
 
-	f := factory.NewFactory() // Database instance initialization
 	g := gin.New()
 
 	http.NewHttp(g, f)
 
+	go user.StartPurgeJob(context.Background(), f, config.UserPurgeRetention(), config.UserPurgeInterval())
+	go user.StartErasureJob(context.Background(), f, config.UserErasureInterval())
+
 	if err := g.Run(fmt.Sprintf(":%d", config.AppPort())); err != nil {
 		log.Fatal("Can't start server.")
 	}
 }
+
+func importUsers(f *factory.Factory, path string, payload dto.PayloadImportUser) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	records, err := sheet.Read(file, path)
+	if err != nil {
+		return err
+	}
+
+	res, err := user.NewService(f).Import(context.Background(), payload, records)
+	if res != nil {
+		for _, row := range res.Rows {
+			fmt.Printf("row %d\t%s\t%s\t%s\n", row.Row, row.Status, row.Email, strings.Join(row.Errors, "; "))
+		}
+		fmt.Printf("total %d, created %d, failed %d\n", res.Total, res.Created, res.Failed)
+	}
+
+	return err
+}
@@ -0,0 +1,19 @@
+package clock
+
+import "time"
+
+// Clock tells the current time, services read it from here instead of time.Now so tests can set it
+type Clock interface {
+	Now() time.Time
+}
+
+type system struct{}
+
+// System is the clock of the machine
+func System() Clock {
+	return system{}
+}
+
+func (system) Now() time.Time {
+	return time.Now()
+}
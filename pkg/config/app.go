@@ -1,6 +1,11 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 func AppEnv() string {
 	return viper.GetString("APP_ENV")
@@ -21,3 +26,23 @@ func AppApiKey() string {
 func TwoFactor() bool {
 	return viper.GetBool("ENABLE_OTP")
 }
+
+// TrustedProxies lists the proxy ips or cidrs allowed to set X-Forwarded-For, empty trusts none
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(viper.GetString("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// AppLocation is the time zone of the expiry times in token responses, the local zone when the zone database is missing
+func AppLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
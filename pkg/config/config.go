@@ -4,6 +4,7 @@ import (
 	"clean-arch/pkg/consts"
 	"clean-arch/pkg/util"
 	"log"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -25,3 +26,11 @@ func GetRefreshDuration() int {
 	}
 	return refreshDuration
 }
+
+// GetTokenDuration is how long an access token is valid
+func GetTokenDuration() time.Duration {
+	if util.GetEnv("JWT_MODE", "fallback") == "release" {
+		return consts.TokenDurationRelease
+	}
+	return consts.TokenDurationDev
+}
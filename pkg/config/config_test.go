@@ -4,6 +4,7 @@ import (
 	"clean-arch/pkg/config"
 	"testing"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,3 +25,39 @@ func TestMyDB(t *testing.T) {
 	assert.NotEmpty(t, config.DbPass())
 	assert.NotEmpty(t, config.DbName())
 }
+
+func TestPasswordParamsThreads(t *testing.T) {
+	defer viper.Set("PASSWORD_ARGON_THREADS", nil)
+
+	for _, threads := range []string{"0", "256", "-1"} {
+		viper.Set("PASSWORD_ARGON_THREADS", threads)
+		_, err := config.PasswordParams()
+		assert.NotNil(t, err, threads)
+	}
+
+	viper.Set("PASSWORD_ARGON_THREADS", "255")
+	params, err := config.PasswordParams()
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(255), params.Threads)
+}
+
+func TestPasswordParamsPepperID(t *testing.T) {
+	defer viper.Set("PASSWORD_PEPPERS", nil)
+	defer viper.Set("PASSWORD_PEPPER_ID", nil)
+
+	viper.Set("PASSWORD_PEPPERS", "v1:first,v2:second")
+	viper.Set("PASSWORD_PEPPER_ID", "v3")
+	_, err := config.PasswordParams()
+	assert.NotNil(t, err)
+
+	viper.Set("PASSWORD_PEPPER_ID", "v2")
+	params, err := config.PasswordParams()
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", params.PepperID)
+	assert.Equal(t, "second", params.Peppers["v2"])
+
+	// without an id new hashes aren't peppered
+	viper.Set("PASSWORD_PEPPER_ID", "")
+	_, err = config.PasswordParams()
+	assert.Nil(t, err)
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"clean-arch/pkg/mailer"
+
+	"github.com/spf13/viper"
+)
+
+// Mail is the smtp server emails are sent through, gmail unless MAIL_HOST is set
+func Mail() mailer.Config {
+	host := viper.GetString("MAIL_HOST")
+	if host == "" {
+		host = "smtp.gmail.com"
+	}
+
+	port := viper.GetInt("MAIL_PORT")
+	if port <= 0 {
+		port = 587
+	}
+
+	return mailer.Config{
+		Host:     host,
+		Port:     port,
+		Username: viper.GetString("MAIL_USERNAME"),
+		Password: viper.GetString("MAIL_PASSWORD"),
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"clean-arch/pkg/util"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// PasswordParams builds the argon2id parameters and pepper set from env,
+// PASSWORD_PEPPERS is a comma separated list of id:secret pairs
+func PasswordParams() (util.PasswordParams, error) {
+	peppers := map[string]string{}
+	for _, pair := range strings.Split(viper.GetString("PASSWORD_PEPPERS"), ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" || secret == "" {
+			continue
+		}
+		peppers[id] = secret
+	}
+
+	// argon2 takes the threads as an uint8 and panics on 0, an unset value keeps the default
+	threads := viper.GetUint("PASSWORD_ARGON_THREADS")
+	if viper.IsSet("PASSWORD_ARGON_THREADS") && (threads < 1 || threads > math.MaxUint8) {
+		return util.PasswordParams{}, fmt.Errorf("PASSWORD_ARGON_THREADS must be between 1 and %d, got %q", math.MaxUint8, viper.GetString("PASSWORD_ARGON_THREADS"))
+	}
+
+	// every new hash is peppered with PASSWORD_PEPPER_ID, an unknown id would fail all of them
+	pepperID := viper.GetString("PASSWORD_PEPPER_ID")
+	if _, ok := peppers[pepperID]; pepperID != "" && !ok {
+		return util.PasswordParams{}, fmt.Errorf("PASSWORD_PEPPER_ID %q is not in PASSWORD_PEPPERS", pepperID)
+	}
+
+	return util.PasswordParams{
+		Time:     viper.GetUint32("PASSWORD_ARGON_TIME"),
+		Memory:   viper.GetUint32("PASSWORD_ARGON_MEMORY"),
+		Threads:  uint8(threads),
+		PepperID: pepperID,
+		Peppers:  peppers,
+	}, nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"clean-arch/pkg/profile"
+
+	"github.com/spf13/viper"
+)
+
+// ProfileSchema is the schema of the custom profile attributes, PROFILE_SCHEMA_PATH points to a JSON file that replaces the default one
+func ProfileSchema() profile.Schema {
+	path := viper.GetString("PROFILE_SCHEMA_PATH")
+	if path == "" {
+		return profile.DefaultSchema
+	}
+
+	// a broken schema file is a startup error like a wrong storage driver
+	schema, err := profile.LoadSchema(path)
+	if err != nil {
+		panic(err)
+	}
+
+	return schema
+}
@@ -0,0 +1,21 @@
+package config
+
+import "github.com/spf13/viper"
+
+func GeoIPDatabasePath() string {
+	return viper.GetString("GEOIP_DB_PATH")
+}
+
+// RiskForceOTP forces the OTP step for risky logins even when ENABLE_OTP is false
+func RiskForceOTP() bool {
+	return viper.GetBool("RISK_FORCE_OTP")
+}
+
+// RiskMaxTravelSpeed is the speed in km/h above which two logins are treated as impossible travel
+func RiskMaxTravelSpeed() float64 {
+	speed := viper.GetFloat64("RISK_MAX_TRAVEL_KMH")
+	if speed <= 0 {
+		return 900
+	}
+	return speed
+}
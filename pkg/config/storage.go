@@ -0,0 +1,73 @@
+package config
+
+import (
+	"clean-arch/pkg/storage"
+	"clean-arch/pkg/util"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Storage reads the STORAGE_* env, local files default to ./uploads served under APP_URL:APP_PORT/uploads
+func Storage() storage.Config {
+	root := viper.GetString("STORAGE_LOCAL_ROOT")
+	if root == "" {
+		root = "uploads"
+	}
+
+	baseURL := viper.GetString("STORAGE_BASE_URL")
+	if baseURL == "" {
+		baseURL = util.GetEnv("APP_URL", "http://localhost") + ":" + util.GetEnv("APP_PORT", "8080") + "/uploads"
+	}
+
+	return storage.Config{
+		Driver:    viper.GetString("STORAGE_DRIVER"),
+		LocalRoot: root,
+		BaseURL:   baseURL,
+		SignKey:   AppSecretKey(),
+		S3: storage.S3Config{
+			Endpoint:  viper.GetString("STORAGE_S3_ENDPOINT"),
+			Region:    viper.GetString("STORAGE_S3_REGION"),
+			Bucket:    viper.GetString("STORAGE_S3_BUCKET"),
+			AccessKey: viper.GetString("STORAGE_S3_ACCESS_KEY"),
+			SecretKey: viper.GetString("STORAGE_S3_SECRET_KEY"),
+			PathStyle: viper.GetBool("STORAGE_S3_PATH_STYLE"),
+			PublicURL: viper.GetString("STORAGE_S3_PUBLIC_URL"),
+		},
+	}
+}
+
+// StorageCacheControl is sent with public files served from /uploads
+func StorageCacheControl() string {
+	cacheControl := viper.GetString("STORAGE_CACHE_CONTROL")
+	if cacheControl == "" {
+		return "public, max-age=86400"
+	}
+	return cacheControl
+}
+
+// StoragePrivatePrefixes lists the key prefixes only served with a valid signature
+func StoragePrivatePrefixes() []string {
+	value := viper.GetString("STORAGE_PRIVATE_PREFIXES")
+	if value == "" {
+		return []string{"private/"}
+	}
+
+	var prefixes []string
+	for _, prefix := range strings.Split(value, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// StorageSignedURLExpiry is how long signed links stay valid, also used when redirecting to a remote storage
+func StorageSignedURLExpiry() time.Duration {
+	minutes := viper.GetInt("STORAGE_SIGNED_URL_MINUTES")
+	if minutes <= 0 {
+		return time.Minute * 15
+	}
+	return time.Minute * time.Duration(minutes)
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// UserPurgeRetention is how long soft deleted users are kept before the purge job removes them, 0 disables the job
+func UserPurgeRetention() time.Duration {
+	if !viper.IsSet("USER_PURGE_RETENTION_DAYS") {
+		return time.Hour * 24 * 30
+	}
+	return time.Hour * 24 * time.Duration(viper.GetInt("USER_PURGE_RETENTION_DAYS"))
+}
+
+func UserPurgeInterval() time.Duration {
+	minutes := viper.GetInt("USER_PURGE_INTERVAL_MINUTES")
+	if minutes <= 0 {
+		return time.Hour
+	}
+	return time.Minute * time.Duration(minutes)
+}
+
+// UserExportAsyncRows is the row count above which an export is written in the background instead of streamed
+func UserExportAsyncRows() int {
+	rows := viper.GetInt("USER_EXPORT_ASYNC_ROWS")
+	if rows <= 0 {
+		return 10000
+	}
+	return rows
+}
+
+// UserExportTTL is how long a background export can be downloaded
+func UserExportTTL() time.Duration {
+	hours := viper.GetInt("USER_EXPORT_TTL_HOURS")
+	if hours <= 0 {
+		return time.Hour * 24
+	}
+	return time.Hour * time.Duration(hours)
+}
+
+// UserInviteTTL is how long an invite link can be accepted, resending the invite starts it again
+func UserInviteTTL() time.Duration {
+	hours := viper.GetInt("USER_INVITE_TTL_HOURS")
+	if hours <= 0 {
+		return time.Hour * 72
+	}
+	return time.Hour * time.Duration(hours)
+}
+
+// UserErasureGrace is how long an erasure request waits before the account is erased, signing in during it cancels the request
+func UserErasureGrace() time.Duration {
+	if !viper.IsSet("USER_ERASURE_GRACE_DAYS") {
+		return time.Hour * 24 * 14
+	}
+	return time.Hour * 24 * time.Duration(viper.GetInt("USER_ERASURE_GRACE_DAYS"))
+}
+
+func UserErasureInterval() time.Duration {
+	minutes := viper.GetInt("USER_ERASURE_INTERVAL_MINUTES")
+	if minutes <= 0 {
+		return time.Hour
+	}
+	return time.Minute * time.Duration(minutes)
+}
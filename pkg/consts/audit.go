@@ -0,0 +1,45 @@
+package consts
+
+type (
+	AuditEvent string
+)
+
+const (
+	AuditLoginSuccess    AuditEvent = "login.success"
+	AuditLoginFailed     AuditEvent = "login.failed"
+	AuditLoginRisk       AuditEvent = "login.risk"
+	AuditOtpRequested    AuditEvent = "otp.requested"
+	AuditOtpFailed       AuditEvent = "otp.failed"
+	AuditPasswordChanged AuditEvent = "password.changed"
+	AuditSessionRevoked  AuditEvent = "session.revoked"
+	AuditUserCreated     AuditEvent = "user.created"
+	AuditUserUpdated     AuditEvent = "user.updated"
+	AuditUserDeleted     AuditEvent = "user.deleted"
+	AuditUserStatus      AuditEvent = "user.status_changed"
+	AuditUserRestored    AuditEvent = "user.restored"
+	AuditUserPurged      AuditEvent = "user.purged"
+	AuditUserImported    AuditEvent = "user.imported"
+	AuditUserExported    AuditEvent = "user.exported"
+	AuditEmailRequested  AuditEvent = "email.change_requested"
+	AuditEmailChanged    AuditEvent = "email.changed"
+	AuditDataExported    AuditEvent = "user.data_exported"
+	AuditErasureRequest  AuditEvent = "user.erasure_requested"
+	AuditErasureCanceled AuditEvent = "user.erasure_canceled"
+	AuditUserErased      AuditEvent = "user.erased"
+	AuditUserInvited     AuditEvent = "user.invited"
+	AuditInviteResent    AuditEvent = "user.invite_resent"
+	AuditInviteCanceled  AuditEvent = "user.invite_revoked"
+	AuditInviteAccepted  AuditEvent = "user.invite_accepted"
+	AuditOrgCreated      AuditEvent = "organization.created"
+	AuditOrgUpdated      AuditEvent = "organization.updated"
+	AuditOrgSwitched     AuditEvent = "organization.switched"
+	AuditMemberInvited   AuditEvent = "organization.member_invited"
+	AuditInviteRevoked   AuditEvent = "organization.invite_revoked"
+	AuditMemberJoined    AuditEvent = "organization.member_joined"
+	AuditMemberRole      AuditEvent = "organization.member_role_changed"
+	AuditMemberRemoved   AuditEvent = "organization.member_removed"
+
+	AuditTargetUser    = "user"
+	AuditTargetSession = "session"
+	AuditTargetOrg     = "organization"
+)
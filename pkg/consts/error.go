@@ -17,14 +17,50 @@ var (
 	DuplicateStoreUser = errors.New("Duplicate store data user")
 	ErrorHashPassword  = errors.New("Error hash password")
 
-	NotFoundDataUser = errors.New("Not found data user")
-	FailedUpdateUser = errors.New("Failed update user")
-	FailedDeleteUser = errors.New("Failed delete user")
+	NotFoundDataUser    = errors.New("Not found data user")
+	FailedUpdateUser    = errors.New("Failed update user")
+	FailedDeleteUser    = errors.New("Failed delete user")
+	FailedRestoreUser   = errors.New("Failed restore user")
+	EmailAlreadyExists  = errors.New("email already exists")
+	ImportHeaderInvalid = errors.New("the file must have a header row with name and email columns")
+	ImportTooManyRows   = errors.New("the file has too many rows")
+	ImportHasInvalidRow = errors.New("some rows are invalid, nothing was imported")
+	ExportJobNotFound   = errors.New("export not found or expired")
+	ExportNotReady      = errors.New("export is not ready yet")
+	FileNotFound        = errors.New("file not found")
+	FileLinkInvalid     = errors.New("file link is invalid or expired")
+	EmailSameCurrent    = errors.New("the email is the same as the current one")
+	EmailChangeRequired = errors.New("the email can only be changed with a change email request")
+	InvalidAttributes   = errors.New("invalid profile attributes")
+
+	OrganizationNotFound     = errors.New("organization not found")
+	OrganizationRequired     = errors.New("select an organization with the X-Org-ID header or switch to one")
+	NotOrganizationMember    = errors.New("you are not a member of this organization")
+	OrganizationSlugTaken    = errors.New("the organization slug is already taken")
+	OrganizationSlugRequired = errors.New("the name has no letters or digits, please send a slug")
+	MemberNotFound           = errors.New("member not found")
+	MemberAlreadyExists      = errors.New("the user is already a member of this organization")
+	LastOrganizationOwner    = errors.New("an organization needs at least one owner")
+	OwnerRoleRequired        = errors.New("only an owner can change or remove an owner")
+	InviteNotFound           = errors.New("invite not found")
+	InviteEmailMismatch      = errors.New("the invite was sent to another email")
+	UserAlreadyInvited       = errors.New("the email is already invited, resend the invite instead")
+	UserNotInvited           = errors.New("the user has no pending invite")
+	InvitedUserStatus        = errors.New("the status of an invited user can't be changed, revoke the invite instead")
 
 	Required2FA   = errors.New("new login detected, please verify 2FA")
 	ErrorLimitOtp = errors.New("reached limit request otp")
 	OtpNotValid   = errors.New("invalid otp")
 
+	InvalidSignedLink = errors.New("link is invalid or already expired")
+
+	UserBanned         = errors.New("Your account has been banned")
+	UserSuspended      = errors.New("Your account is suspended")
+	UserDeactivated    = errors.New("Your account is deactivated")
+	UserInvitePending  = errors.New("Please accept the invite sent to your email first")
+	InvalidUserStatus  = errors.New("Invalid user status")
+	SuspendedUntilPast = errors.New("Suspended until must be a future date")
+
 	FailedChangePassword   = errors.New("Failed change password")
 	FailedNotSamePassword  = errors.New("Please confirm the same password")
 	MinimCharacterPassword = errors.New("Minimum password is 8 characters")
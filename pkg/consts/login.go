@@ -0,0 +1,10 @@
+package consts
+
+type (
+	LoginStatus string
+)
+
+const (
+	LoginStatusSuccess LoginStatus = "success"
+	LoginStatusFailed  LoginStatus = "failed"
+)
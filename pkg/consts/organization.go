@@ -0,0 +1,13 @@
+package consts
+
+type OrgRole string
+
+// roles inside an organization, they are separate from the global RoleType of the user
+const (
+	OrgRoleOwner  OrgRole = "owner"
+	OrgRoleAdmin  OrgRole = "admin"
+	OrgRoleMember OrgRole = "member"
+)
+
+// HeaderOrgID selects the organization of a request, it wins over the organization of the token
+const HeaderOrgID = "X-Org-ID"
@@ -1,6 +1,12 @@
 package consts
 
 const (
-	TemplateEmailVerify = "pkg/resource/email_verify.html"
-	TemplateEmailOtp    = "pkg/resource/email_otp.html"
+	TemplateEmailVerify       = "pkg/resource/email_verify.html"
+	TemplateEmailOtp          = "pkg/resource/email_otp.html"
+	TemplateEmailNewSignIn    = "pkg/resource/email_new_signin.html"
+	TemplateEmailWelcome      = "pkg/resource/email_welcome.html"
+	TemplateEmailChangeVerify = "pkg/resource/email_change_verify.html"
+	TemplateEmailChangeNotice = "pkg/resource/email_change_notice.html"
+	TemplateEmailOrgInvite    = "pkg/resource/email_org_invite.html"
+	TemplateEmailUserInvite   = "pkg/resource/email_user_invite.html"
 )
@@ -1,10 +1,43 @@
 package consts
 
+import "time"
+
 type (
 	UserStatus string
 )
 
 const (
-	UserStatusActive UserStatus = "active"
-	UserStatusBan    UserStatus = "ban"
+	UserStatusActive      UserStatus = "active"
+	UserStatusSuspended   UserStatus = "suspended"
+	UserStatusBan         UserStatus = "ban"
+	UserStatusDeactivated UserStatus = "deactivated"
+	UserStatusInvited     UserStatus = "invited"
 )
+
+// UserStatuses are the statuses an admin can set, invited is only set by an invite and left by accepting it
+var UserStatuses = []UserStatus{UserStatusActive, UserStatusSuspended, UserStatusBan, UserStatusDeactivated}
+
+// invite status of a user, pending and expired while the user is invited and accepted afterwards
+const (
+	InviteStatusPending  = "pending"
+	InviteStatusExpired  = "expired"
+	InviteStatusAccepted = "accepted"
+)
+
+// UserStatusError maps a status to the error returned to the user, an empty status is treated as active
+func UserStatusError(status UserStatus, suspendedUntil *time.Time, now time.Time) error {
+	switch status {
+	case UserStatusBan:
+		return UserBanned
+	case UserStatusDeactivated:
+		return UserDeactivated
+	case UserStatusInvited:
+		return UserInvitePending
+	case UserStatusSuspended:
+		if suspendedUntil == nil || suspendedUntil.After(now) {
+			return UserSuspended
+		}
+	}
+
+	return nil
+}
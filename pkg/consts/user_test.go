@@ -0,0 +1,26 @@
+package consts_test
+
+import (
+	"clean-arch/pkg/consts"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserStatusError(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.Nil(t, consts.UserStatusError(consts.UserStatusActive, nil, now))
+	assert.Nil(t, consts.UserStatusError("", nil, now))
+	assert.Equal(t, consts.UserBanned, consts.UserStatusError(consts.UserStatusBan, nil, now))
+	assert.Equal(t, consts.UserDeactivated, consts.UserStatusError(consts.UserStatusDeactivated, nil, now))
+	assert.Equal(t, consts.UserInvitePending, consts.UserStatusError(consts.UserStatusInvited, nil, now))
+
+	// a suspension without an end date lasts until an admin lifts it
+	assert.Equal(t, consts.UserSuspended, consts.UserStatusError(consts.UserStatusSuspended, nil, now))
+	assert.Equal(t, consts.UserSuspended, consts.UserStatusError(consts.UserStatusSuspended, &future, now))
+	assert.Nil(t, consts.UserStatusError(consts.UserStatusSuspended, &past, now))
+}
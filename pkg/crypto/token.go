@@ -0,0 +1,48 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidSignedToken = errors.New("invalid signed token")
+
+// SignPayload : encode payload to JSON and append its SHA256HMAC. Output to String in "payload.signature" Base64URL format
+func SignPayload(key string, payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(data)
+	signature := base64.RawURLEncoding.EncodeToString([]byte(ComputeSHA256HMAC(key, body)))
+
+	return body + "." + signature, nil
+}
+
+// VerifyPayload : check the signature of a token made by SignPayload and decode its payload into out
+func VerifyPayload(key string, token string, out any) error {
+	body, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidSignedToken
+	}
+
+	expected := base64.RawURLEncoding.EncodeToString([]byte(ComputeSHA256HMAC(key, body)))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignedToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return ErrInvalidSignedToken
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return ErrInvalidSignedToken
+	}
+
+	return nil
+}
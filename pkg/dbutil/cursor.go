@@ -0,0 +1,300 @@
+package dbutil
+
+import (
+	"clean-arch/pkg/crypto"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SortKey is one column of a keyset order, columns must not be nullable
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+/*
+Cursor points at the row a page starts after, Backward cursors walk to the previous page. Sort is
+the order the cursor was issued for, its values mean nothing under another order
+*/
+type Cursor struct {
+	Values   []any
+	Backward bool
+	Sort     string
+}
+
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+type cursorPayload struct {
+	Values   []cursorValue `json:"v"`
+	Backward bool          `json:"b,omitempty"`
+	Sort     string        `json:"s"`
+}
+
+// Keyset paginates on the sort columns and the id instead of an offset, so pages stay fast and stable while rows are added
+type Keyset struct {
+	Keys   []SortKey
+	Cursor *Cursor
+	Limit  int
+	// key signs the cursors of the pages
+	key string
+}
+
+/*
+NewKeyset builds the keyset of an order such as "created_at desc, name asc", the id column is appended
+as a tie breaker when missing so every row has a unique position. key verifies the token and signs
+the cursors Paginate makes
+
+	k, _ := dbutil.NewKeyset(secretKey, query.Order, c.Query("cursor"), 20)
+	rows, _ := repo.FindAll(ctx, "*", append(query.Where, k.Options()...)...)
+	rows, page, _ := dbutil.Paginate(k, rows, func(u *model.User) []any { return []any{u.CreatedAt, u.Name, u.ID} })
+*/
+func NewKeyset(key string, order string, token string, limit int) (Keyset, error) {
+	k := Keyset{
+		Keys:  ParseOrder(order),
+		Limit: limit,
+		key:   key,
+	}
+
+	hasID := false
+	for _, key := range k.Keys {
+		if key.Column == "id" {
+			hasID = true
+		}
+	}
+
+	if !hasID {
+		desc := len(k.Keys) > 0 && k.Keys[len(k.Keys)-1].Desc
+		k.Keys = append(k.Keys, SortKey{Column: "id", Desc: desc})
+	}
+
+	if token != "" {
+		cursor, err := DecodeCursor(key, token)
+		if err != nil {
+			return k, err
+		}
+
+		// a cursor replayed with another sort would page on the wrong columns or direction
+		if cursor.Sort != k.Sort() || len(cursor.Values) != len(k.Keys) {
+			return k, ErrInvalidCursor
+		}
+		k.Cursor = &cursor
+	}
+
+	return k, nil
+}
+
+// ParseOrder splits an order clause into sort keys, "asc" is the default direction
+func ParseOrder(order string) []SortKey {
+	var keys []SortKey
+
+	for _, part := range strings.Split(order, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		keys = append(keys, SortKey{
+			Column: fields[0],
+			Desc:   len(fields) > 1 && strings.EqualFold(fields[1], "desc"),
+		})
+	}
+
+	return keys
+}
+
+// Sort is the order of the keys such as "created_at desc, id desc", the cursors of the keyset carry it
+func (k Keyset) Sort() string {
+	var orders []string
+	for _, key := range k.Keys {
+		direction := "asc"
+		if key.Desc {
+			direction = "desc"
+		}
+		orders = append(orders, key.Column+" "+direction)
+	}
+
+	return strings.Join(orders, ", ")
+}
+
+// Options returns the where, order and limit of the page, one extra row is fetched to know whether another page exists
+func (k Keyset) Options() []QueryOption {
+	backward := k.Cursor != nil && k.Cursor.Backward
+
+	var orders []string
+	for _, key := range k.Keys {
+		desc := key.Desc != backward
+		direction := "asc"
+		if desc {
+			direction = "desc"
+		}
+		orders = append(orders, key.Column+" "+direction)
+	}
+
+	opts := []QueryOption{
+		Order(strings.Join(orders, ", ")),
+		Limit(k.Limit + 1),
+	}
+
+	if k.Cursor == nil {
+		return opts
+	}
+
+	// (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?)
+	var (
+		conditions []string
+		args       []any
+	)
+
+	for i, key := range k.Keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, k.Keys[j].Column+" = ?")
+			args = append(args, k.Cursor.Values[j])
+		}
+
+		comparison := ">"
+		if key.Desc != backward {
+			comparison = "<"
+		}
+		parts = append(parts, key.Column+" "+comparison+" ?")
+		args = append(args, k.Cursor.Values[i])
+
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return append(opts, Where("("+strings.Join(conditions, " OR ")+")", args...))
+}
+
+// PageInfo holds the cursors of the neighbour pages, an empty cursor means there is no such page
+type PageInfo struct {
+	NextCursor string
+	PrevCursor string
+}
+
+// Paginate trims the extra row fetched by Options and builds the cursors, values must return the sort values of a row in key order
+func Paginate[T any](k Keyset, rows []T, values func(T) []any) ([]T, PageInfo, error) {
+	var page PageInfo
+
+	backward := k.Cursor != nil && k.Cursor.Backward
+	hasMore := len(rows) > k.Limit
+	if hasMore {
+		rows = rows[:k.Limit]
+	}
+
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if len(rows) == 0 {
+		return rows, page, nil
+	}
+
+	if hasMore || backward {
+		next, err := EncodeCursor(k.key, Cursor{Values: values(rows[len(rows)-1]), Sort: k.Sort()})
+		if err != nil {
+			return rows, page, err
+		}
+		page.NextCursor = next
+	}
+
+	if (k.Cursor != nil && !backward) || (backward && hasMore) {
+		prev, err := EncodeCursor(k.key, Cursor{Values: values(rows[0]), Backward: true, Sort: k.Sort()})
+		if err != nil {
+			return rows, page, err
+		}
+		page.PrevCursor = prev
+	}
+
+	return rows, page, nil
+}
+
+// EncodeCursor signs the cursor so clients can't forge positions, values keep their type for the next query
+func EncodeCursor(key string, cursor Cursor) (string, error) {
+	payload := cursorPayload{
+		Backward: cursor.Backward,
+		Sort:     cursor.Sort,
+	}
+
+	for _, value := range cursor.Values {
+		var encoded cursorValue
+
+		switch v := value.(type) {
+		case int:
+			encoded = cursorValue{"i", strconv.Itoa(v)}
+		case int64:
+			encoded = cursorValue{"i", strconv.FormatInt(v, 10)}
+		case float64:
+			encoded = cursorValue{"f", strconv.FormatFloat(v, 'g', -1, 64)}
+		case string:
+			encoded = cursorValue{"s", v}
+		case time.Time:
+			encoded = cursorValue{"t", v.Format(time.RFC3339Nano)}
+		case *time.Time:
+			if v == nil {
+				return "", fmt.Errorf("cursor value can't be nil")
+			}
+			encoded = cursorValue{"t", v.Format(time.RFC3339Nano)}
+		default:
+			encoded = cursorValue{"s", fmt.Sprint(v)}
+		}
+
+		payload.Values = append(payload.Values, encoded)
+	}
+
+	return crypto.SignPayload(cursorKey(key), payload)
+}
+
+func DecodeCursor(key string, token string) (Cursor, error) {
+	var (
+		payload cursorPayload
+		res     Cursor
+	)
+
+	if err := crypto.VerifyPayload(cursorKey(key), token, &payload); err != nil {
+		return res, ErrInvalidCursor
+	}
+
+	res.Backward = payload.Backward
+	res.Sort = payload.Sort
+	for _, encoded := range payload.Values {
+		var (
+			value any
+			err   error
+		)
+
+		switch encoded.Type {
+		case "i":
+			value, err = strconv.Atoi(encoded.Value)
+		case "f":
+			value, err = strconv.ParseFloat(encoded.Value, 64)
+		case "t":
+			value, err = time.Parse(time.RFC3339Nano, encoded.Value)
+		case "s":
+			value = encoded.Value
+		default:
+			err = ErrInvalidCursor
+		}
+
+		if err != nil {
+			return Cursor{}, ErrInvalidCursor
+		}
+		res.Values = append(res.Values, value)
+	}
+
+	return res, nil
+}
+
+// cursorKey keeps a cursor from passing as another payload signed with the same secret
+func cursorKey(key string) string {
+	return key + ":cursor"
+}
@@ -0,0 +1,119 @@
+package dbutil_test
+
+import (
+	"clean-arch/pkg/dbutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const key = "secret"
+
+type row struct {
+	ID        int
+	CreatedAt time.Time
+}
+
+func rowValues(r row) []any {
+	return []any{r.CreatedAt, r.ID}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 10, 19, 9, 30, 15, 123456000, time.UTC)
+
+	token, err := dbutil.EncodeCursor(key, dbutil.Cursor{Values: []any{createdAt, "john", 42}, Backward: true, Sort: "created_at desc, name asc, id desc"})
+	assert.Nil(t, err)
+
+	cursor, err := dbutil.DecodeCursor(key, token)
+	assert.Nil(t, err)
+	assert.True(t, cursor.Backward)
+	assert.Equal(t, "created_at desc, name asc, id desc", cursor.Sort)
+	assert.True(t, createdAt.Equal(cursor.Values[0].(time.Time)))
+	assert.Equal(t, "john", cursor.Values[1])
+	assert.Equal(t, 42, cursor.Values[2])
+
+	_, err = dbutil.DecodeCursor(key, token+"x")
+	assert.ErrorIs(t, err, dbutil.ErrInvalidCursor)
+
+	// a cursor signed with another key is rejected
+	_, err = dbutil.DecodeCursor("other", token)
+	assert.ErrorIs(t, err, dbutil.ErrInvalidCursor)
+}
+
+func TestKeysetOptions(t *testing.T) {
+	k, err := dbutil.NewKeyset(key, "created_at desc", "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []dbutil.SortKey{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}, k.Keys)
+
+	options := new(dbutil.QueryOptions)
+	for _, opt := range k.Options() {
+		opt(options)
+	}
+	assert.Equal(t, "created_at desc, id desc", options.Order)
+	assert.Equal(t, 3, options.Limit)
+	assert.Empty(t, options.Where)
+
+	token, _ := dbutil.EncodeCursor(key, dbutil.Cursor{Values: []any{time.Now(), 7}, Backward: true, Sort: "created_at desc, id desc"})
+	k, err = dbutil.NewKeyset(key, "created_at desc", token, 2)
+	assert.Nil(t, err)
+
+	options = new(dbutil.QueryOptions)
+	for _, opt := range k.Options() {
+		opt(options)
+	}
+	assert.Equal(t, "created_at asc, id asc", options.Order)
+	assert.Equal(t, "((created_at > ?) OR (created_at = ? AND id > ?))", options.Where[0].Query)
+	assert.Len(t, options.Where[0].Args, 3)
+
+	_, err = dbutil.NewKeyset(key, "name asc, created_at desc", token, 2)
+	assert.ErrorIs(t, err, dbutil.ErrInvalidCursor)
+}
+
+func TestKeysetSortMismatch(t *testing.T) {
+	k, _ := dbutil.NewKeyset(key, "name asc", "", 2)
+	assert.Equal(t, "name asc, id asc", k.Sort())
+
+	token, _ := dbutil.EncodeCursor(key, dbutil.Cursor{Values: []any{"john", 7}, Sort: k.Sort()})
+
+	_, err := dbutil.NewKeyset(key, "name asc", token, 2)
+	assert.Nil(t, err)
+
+	// the same number of keys on another column or in another direction is rejected
+	for _, order := range []string{"created_at desc", "name desc", "email asc"} {
+		_, err = dbutil.NewKeyset(key, order, token, 2)
+		assert.ErrorIs(t, err, dbutil.ErrInvalidCursor, order)
+	}
+
+	// a cursor without a sort is from before cursors carried one
+	token, _ = dbutil.EncodeCursor(key, dbutil.Cursor{Values: []any{"john", 7}})
+	_, err = dbutil.NewKeyset(key, "name asc", token, 2)
+	assert.ErrorIs(t, err, dbutil.ErrInvalidCursor)
+}
+
+func TestPaginate(t *testing.T) {
+	now := time.Now()
+	rows := []row{{3, now}, {2, now}, {1, now}}
+
+	k, _ := dbutil.NewKeyset(key, "created_at desc", "", 2)
+	page, info, err := dbutil.Paginate(k, rows, rowValues)
+	assert.Nil(t, err)
+	assert.Equal(t, []row{{3, now}, {2, now}}, page)
+	assert.NotEmpty(t, info.NextCursor)
+	assert.Empty(t, info.PrevCursor)
+
+	next, _ := dbutil.NewKeyset(key, "created_at desc", info.NextCursor, 2)
+	page, info, err = dbutil.Paginate(next, []row{{1, now}}, rowValues)
+	assert.Nil(t, err)
+	assert.Equal(t, []row{{1, now}}, page)
+	assert.Empty(t, info.NextCursor)
+	assert.NotEmpty(t, info.PrevCursor)
+
+	// walking back returns the rows reversed by the flipped order
+	prev, _ := dbutil.NewKeyset(key, "created_at desc", info.PrevCursor, 2)
+	page, info, err = dbutil.Paginate(prev, []row{{2, now}, {3, now}}, rowValues)
+	assert.Nil(t, err)
+	assert.Equal(t, []row{{3, now}, {2, now}}, page)
+	assert.NotEmpty(t, info.NextCursor)
+	assert.Empty(t, info.PrevCursor)
+}
@@ -0,0 +1,30 @@
+package dbutil
+
+import (
+	"clean-arch/pkg/consts"
+	"fmt"
+	"time"
+)
+
+// DateRange filters column between two yyyy-mm-dd dates, both ends are inclusive and optional
+func DateRange(column string, from string, to string) ([]QueryOption, error) {
+	var opts []QueryOption
+
+	if from != "" {
+		fromDate, err := time.ParseInLocation(consts.TimeFormatDate, from, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date_from, please use format yyyy-mm-dd")
+		}
+		opts = append(opts, Where(column+" >= ?", fromDate))
+	}
+
+	if to != "" {
+		toDate, err := time.ParseInLocation(consts.TimeFormatDate, to, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date_to, please use format yyyy-mm-dd")
+		}
+		opts = append(opts, Where(column+" < ?", toDate.AddDate(0, 0, 1)))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,313 @@
+package dbutil
+
+import (
+	"clean-arch/pkg/consts"
+	"clean-arch/pkg/util"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldInt
+	FieldBool
+	FieldTime
+	// FieldPresence filters a nullable column by whether it is set, e.g. email_verified=true
+	FieldPresence
+)
+
+// Field maps a public filter name to its column
+type Field struct {
+	Column string
+	Type   FieldType
+}
+
+// QuerySpec whitelists what a resource lets clients filter, sort, select and search on
+type QuerySpec struct {
+	Filters map[string]Field
+	// Sorts and Fields map the public name to the column
+	Sorts  map[string]string
+	Fields map[string]string
+	// Search columns are matched with LIKE through the search param
+	Search      []string
+	DefaultSort string
+	// RequiredFields are always selected, e.g. the primary key used for paging
+	RequiredFields []string
+	// Location is the zone time values without an offset are read in, nil means time.Local
+	Location *time.Location
+}
+
+// ParsedQuery is the result of QuerySpec.Parse, Where is meant for both the count and the list query
+type ParsedQuery struct {
+	Where  []QueryOption
+	Order  string
+	Fields []string
+	Select string
+}
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+var (
+	filterKeyPattern   = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+	operatorKeyPattern = regexp.MustCompile(`^(\w+)\[(\w+)\]$`)
+)
+
+var operators = map[FieldType][]string{
+	FieldString:   {"eq", "ne", "like", "in", "null"},
+	FieldInt:      {"eq", "ne", "gt", "gte", "lt", "lte", "in", "null"},
+	FieldBool:     {"eq", "ne"},
+	FieldTime:     {"eq", "gt", "gte", "lt", "lte", "null"},
+	FieldPresence: {"eq"},
+}
+
+/*
+Parse turns the query string into query options, anything outside the whitelist is an error
+
+	filter[status]=active            status = 'active'
+	filter[email_verified]=true      email_verified_at IS NOT NULL
+	created_at[gte]=2026-01-01       created_at >= '2026-01-01'
+	filter[id][in]=1,2,3             id IN (1,2,3)
+	sort=-created_at,name            created_at desc, name asc
+	fields=id,name                   SELECT id, name
+	search=john                      LOWER(name) LIKE '%john%' OR ...
+*/
+func (spec QuerySpec) Parse(values url.Values) (ParsedQuery, error) {
+	var res ParsedQuery
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, op, ok := parseFilterKey(key)
+		if !ok {
+			continue
+		}
+
+		for _, value := range values[key] {
+			opt, err := spec.filter(name, op, value)
+			if err != nil {
+				return res, err
+			}
+			res.Where = append(res.Where, opt)
+		}
+	}
+
+	if search := strings.TrimSpace(values.Get("search")); search != "" && len(spec.Search) > 0 {
+		clause, args := util.BuildLikeClause(spec.Search, search)
+		res.Where = append(res.Where, Where("("+clause+")", args...))
+	}
+
+	order, err := spec.order(values.Get("sort"))
+	if err != nil {
+		return res, err
+	}
+	res.Order = order
+
+	fields, selectFields, err := spec.fields(values.Get("fields"))
+	if err != nil {
+		return res, err
+	}
+	res.Fields = fields
+	res.Select = selectFields
+
+	return res, nil
+}
+
+// Options returns the where clauses followed by the order
+func (q ParsedQuery) Options() []QueryOption {
+	opts := append([]QueryOption{}, q.Where...)
+	if q.Order != "" {
+		opts = append(opts, Order(q.Order))
+	}
+	return opts
+}
+
+func parseFilterKey(key string) (string, string, bool) {
+	if m := filterKeyPattern.FindStringSubmatch(key); m != nil {
+		op := m[2]
+		if op == "" {
+			op = "eq"
+		}
+		return m[1], op, true
+	}
+
+	if m := operatorKeyPattern.FindStringSubmatch(key); m != nil {
+		return m[1], m[2], true
+	}
+
+	return "", "", false
+}
+
+func (spec QuerySpec) filter(name string, op string, value string) (QueryOption, error) {
+	field, ok := spec.Filters[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown filter field %s", ErrInvalidQuery, name)
+	}
+
+	if !allowedOperator(field.Type, op) {
+		return nil, fmt.Errorf("%w: operator %s is not supported on %s", ErrInvalidQuery, op, name)
+	}
+
+	column := field.Column
+
+	if field.Type == FieldPresence || op == "null" {
+		set, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s expects true or false", ErrInvalidQuery, name)
+		}
+
+		// filter[x]=true means "x is set", x[null]=true means "x is not set"
+		if (field.Type == FieldPresence) == set {
+			return Where(column + " IS NOT NULL"), nil
+		}
+		return Where(column + " IS NULL"), nil
+	}
+
+	if op == "in" {
+		var args []any
+		for _, part := range strings.Split(value, ",") {
+			arg, err := spec.parseValue(field.Type, strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %s", ErrInvalidQuery, name, err.Error())
+			}
+			args = append(args, arg)
+		}
+		return Where(column+" IN ?", args), nil
+	}
+
+	if op == "like" {
+		return Where("LOWER("+column+") LIKE ? "+util.LikeEscape, "%"+util.EscapeLike(strings.ToLower(value))+"%"), nil
+	}
+
+	arg, err := spec.parseValue(field.Type, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrInvalidQuery, name, err.Error())
+	}
+
+	comparison := map[string]string{
+		"eq":  "=",
+		"ne":  "<>",
+		"gt":  ">",
+		"gte": ">=",
+		"lt":  "<",
+		"lte": "<=",
+	}[op]
+
+	return Where(column+" "+comparison+" ?", arg), nil
+}
+
+func allowedOperator(fieldType FieldType, op string) bool {
+	for _, allowed := range operators[fieldType] {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (spec QuerySpec) parseValue(fieldType FieldType, value string) (any, error) {
+	switch fieldType {
+	case FieldInt:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("expects a number")
+		}
+		return parsed, nil
+	case FieldBool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expects true or false")
+		}
+		return parsed, nil
+	case FieldTime:
+		loc := spec.Location
+		if loc == nil {
+			loc = time.Local
+		}
+
+		for _, layout := range []string{consts.TimeFormatDateTime, consts.TimeFormatDate, time.RFC3339} {
+			if parsed, err := time.ParseInLocation(layout, value, loc); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("expects format yyyy-mm-dd or yyyy-mm-dd hh:mm:ss")
+	}
+
+	return value, nil
+}
+
+func (spec QuerySpec) order(sortParam string) (string, error) {
+	if strings.TrimSpace(sortParam) == "" {
+		sortParam = spec.DefaultSort
+	}
+
+	var orders []string
+	for _, part := range strings.Split(sortParam, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		direction := "asc"
+		if strings.HasPrefix(part, "-") {
+			direction = "desc"
+			part = part[1:]
+		}
+
+		column, ok := spec.Sorts[part]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown sort field %s", ErrInvalidQuery, part)
+		}
+		orders = append(orders, column+" "+direction)
+	}
+
+	return strings.Join(orders, ", "), nil
+}
+
+func (spec QuerySpec) fields(fieldsParam string) ([]string, string, error) {
+	if strings.TrimSpace(fieldsParam) == "" {
+		return nil, "", nil
+	}
+
+	var (
+		fields  []string
+		columns []string
+		seen    = map[string]bool{}
+	)
+
+	for _, name := range spec.RequiredFields {
+		columns = append(columns, spec.Fields[name])
+		seen[name] = true
+	}
+
+	for _, name := range strings.Split(fieldsParam, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		column, ok := spec.Fields[name]
+		if !ok {
+			return nil, "", fmt.Errorf("%w: unknown field %s", ErrInvalidQuery, name)
+		}
+
+		fields = append(fields, name)
+		if !seen[name] {
+			columns = append(columns, column)
+			seen[name] = true
+		}
+	}
+
+	return fields, strings.Join(columns, ","), nil
+}
@@ -0,0 +1,114 @@
+package dbutil_test
+
+import (
+	"clean-arch/pkg/dbutil"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var spec = dbutil.QuerySpec{
+	Filters: map[string]dbutil.Field{
+		"id":             {Column: "id", Type: dbutil.FieldInt},
+		"status":         {Column: "status", Type: dbutil.FieldString},
+		"email_verified": {Column: "email_verified_at", Type: dbutil.FieldPresence},
+		"created_at":     {Column: "created_at", Type: dbutil.FieldTime},
+	},
+	Sorts:          map[string]string{"id": "id", "name": "name", "created_at": "created_at"},
+	Fields:         map[string]string{"id": "id", "name": "name", "email": "email"},
+	Search:         []string{"name", "email"},
+	DefaultSort:    "-id",
+	RequiredFields: []string{"id"},
+}
+
+func whereClauses(opts []dbutil.QueryOption) []any {
+	options := new(dbutil.QueryOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var res []any
+	for _, where := range options.Where {
+		res = append(res, where.Query)
+	}
+	return res
+}
+
+func TestQuerySpecParse(t *testing.T) {
+	values, _ := url.ParseQuery("filter[status]=active&filter[email_verified]=true&created_at[gte]=2026-01-01&filter[id][in]=1,2&search=john&sort=-created_at,name&fields=name,email")
+
+	res, err := spec.Parse(values)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []any{
+		"created_at >= ?",
+		"email_verified_at IS NOT NULL",
+		"id IN ?",
+		"status = ?",
+		"(LOWER(name) LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!')",
+	}, whereClauses(res.Where))
+	assert.Equal(t, "created_at desc, name asc", res.Order)
+	assert.Equal(t, []string{"name", "email"}, res.Fields)
+	assert.Equal(t, "id,name,email", res.Select)
+}
+
+func TestQuerySpecDefaults(t *testing.T) {
+	res, err := spec.Parse(url.Values{"limit": {"10"}})
+	assert.Nil(t, err)
+
+	assert.Empty(t, res.Where)
+	assert.Equal(t, "id desc", res.Order)
+	assert.Equal(t, "", res.Select)
+}
+
+func TestQuerySpecInvalid(t *testing.T) {
+	invalid := []string{
+		"filter[password]=x",
+		"filter[status][gt]=a",
+		"filter[id]=abc",
+		"created_at[gte]=yesterday",
+		"filter[email_verified]=maybe",
+		"sort=password",
+		"fields=id,password",
+	}
+
+	for _, query := range invalid {
+		values, _ := url.ParseQuery(query)
+		_, err := spec.Parse(values)
+		assert.ErrorIs(t, err, dbutil.ErrInvalidQuery, query)
+	}
+}
+
+func TestQuerySpecLikeEscape(t *testing.T) {
+	values, _ := url.ParseQuery("filter[status][like]=50%25_off!&search=100%25")
+
+	res, err := spec.Parse(values)
+	assert.Nil(t, err)
+
+	options := new(dbutil.QueryOptions)
+	for _, opt := range res.Where {
+		opt(options)
+	}
+
+	assert.Equal(t, "LOWER(status) LIKE ? ESCAPE '!'", options.Where[0].Query)
+	assert.Equal(t, []any{"%50!%!_off!!%"}, options.Where[0].Args)
+	assert.Equal(t, []any{"%100!%%", "%100!%%"}, options.Where[1].Args)
+}
+
+func TestQuerySpecTimeLocation(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*60*60)
+	located := spec
+	located.Location = loc
+
+	values, _ := url.ParseQuery("created_at[gte]=2026-01-01 08:00:00")
+	res, err := located.Parse(values)
+	assert.Nil(t, err)
+
+	options := new(dbutil.QueryOptions)
+	for _, opt := range res.Where {
+		opt(options)
+	}
+	assert.Equal(t, []any{time.Date(2026, 1, 1, 8, 0, 0, 0, loc)}, options.Where[0].Args)
+}
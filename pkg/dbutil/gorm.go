@@ -12,6 +12,10 @@ func ApplyScopes(opts ...QueryOption) func(*gorm.DB) *gorm.DB {
 			opt(options)
 		}
 
+		if options.Unscoped {
+			db.Unscoped()
+		}
+
 		if options.Group != "" {
 			db.Group(options.Group)
 		}
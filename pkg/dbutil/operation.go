@@ -12,6 +12,7 @@ type QueryOptions struct {
 	Omit     string
 	Limit    int
 	Offset   int
+	Unscoped bool
 }
 
 type preload struct {
@@ -87,3 +88,18 @@ func Offset(offset int) QueryOption {
 		opt.Offset = offset
 	}
 }
+
+// WithDeleted includes soft deleted rows, models with gorm.DeletedAt skip them by default
+func WithDeleted() QueryOption {
+	return func(opt *QueryOptions) {
+		opt.Unscoped = true
+	}
+}
+
+// OnlyDeleted limits the query to soft deleted rows
+func OnlyDeleted() QueryOption {
+	return func(opt *QueryOptions) {
+		opt.Unscoped = true
+		opt.Where = append(opt.Where, whereClause{"deleted_at IS NOT NULL", nil})
+	}
+}
@@ -0,0 +1,58 @@
+package dbutil
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantKey is where the tenant middleware stores the organization id in the gin context
+const TenantKey = "tenant_id"
+
+// ErrNoTenant is returned by queries on tenant owned tables when the context has no organization
+var ErrNoTenant = errors.New("query on a tenant table without an organization")
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose queries on tenant owned tables only see rows of the organization
+func WithTenant(ctx context.Context, orgID int) context.Context {
+	return context.WithValue(ctx, tenantKey{}, orgID)
+}
+
+// TenantFromContext returns the organization set by WithTenant or by the tenant middleware
+func TenantFromContext(ctx context.Context) (int, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+
+	if orgID, ok := ctx.Value(tenantKey{}).(int); ok && orgID > 0 {
+		return orgID, true
+	}
+
+	// a gin.Context looks string keys up in the values set with c.Set
+	orgID, ok := ctx.Value(TenantKey).(int)
+	return orgID, ok && orgID > 0
+}
+
+/*
+TenantScope limits a query to the organization of its context, repositories of tenant owned
+tables add it to every read and write so a service can't forget it
+
+The organization is read from db.Statement.Context, which Conn sets for reads and writes inside or
+outside a WithinTx. Without an organization the query fails with ErrNoTenant instead of running
+over every tenant
+*/
+func TenantScope(db *gorm.DB) *gorm.DB {
+	orgID, ok := TenantFromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(ErrNoTenant)
+		return db
+	}
+
+	return db.Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"},
+		Value:  orgID,
+	})
+}
@@ -0,0 +1,98 @@
+package dbutil_test
+
+import (
+	"clean-arch/pkg/dbutil"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type tenantRow struct {
+	ID             int
+	OrganizationID int
+	Name           string
+}
+
+func dryRun(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:secret@tcp(127.0.0.1:3306)/app",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.Nil(t, err)
+
+	return db
+}
+
+func TestTenantFromContext(t *testing.T) {
+	_, ok := dbutil.TenantFromContext(context.Background())
+	assert.False(t, ok)
+
+	orgID, ok := dbutil.TenantFromContext(dbutil.WithTenant(context.Background(), 7))
+	assert.True(t, ok)
+	assert.Equal(t, 7, orgID)
+
+	// the organization of a nested context wins, a job can switch tenant for one query
+	orgID, _ = dbutil.TenantFromContext(dbutil.WithTenant(dbutil.WithTenant(context.Background(), 7), 8))
+	assert.Equal(t, 8, orgID)
+
+	_, ok = dbutil.TenantFromContext(dbutil.WithTenant(context.Background(), 0))
+	assert.False(t, ok)
+}
+
+func TestTenantScopeRead(t *testing.T) {
+	db := dryRun(t)
+
+	var rows []tenantRow
+	stmt := db.WithContext(dbutil.WithTenant(context.Background(), 1)).
+		Model(&tenantRow{}).
+		Scopes(dbutil.TenantScope, dbutil.ApplyScopes(dbutil.Where("name = ?", "a"))).
+		Find(&rows).Statement
+
+	assert.Nil(t, stmt.Error)
+	assert.Equal(t, "SELECT * FROM `tenant_rows` WHERE `tenant_rows`.`organization_id` = ? AND name = ?", stmt.SQL.String())
+	assert.Equal(t, []any{1, "a"}, stmt.Vars)
+}
+
+// a filter naming another organization is ANDed with the tenant, so it can only match nothing
+func TestTenantScopeCannotReadOtherTenant(t *testing.T) {
+	db := dryRun(t)
+
+	var rows []tenantRow
+	stmt := db.WithContext(dbutil.WithTenant(context.Background(), 1)).
+		Model(&tenantRow{}).
+		Scopes(dbutil.TenantScope, dbutil.ApplyScopes(dbutil.Where("organization_id = ? OR 1 = 1", 2))).
+		Find(&rows).Statement
+
+	assert.Nil(t, stmt.Error)
+	assert.Equal(t, "SELECT * FROM `tenant_rows` WHERE `tenant_rows`.`organization_id` = ? AND (organization_id = ? OR 1 = 1)", stmt.SQL.String())
+	assert.Equal(t, []any{1, 2}, stmt.Vars)
+}
+
+func TestTenantScopeWrite(t *testing.T) {
+	db := dryRun(t)
+	ctx := dbutil.WithTenant(context.Background(), 1)
+
+	stmt := db.WithContext(ctx).Model(&tenantRow{}).Scopes(dbutil.TenantScope).Where("id = ?", 5).Update("name", "b").Statement
+	assert.Nil(t, stmt.Error)
+	assert.Equal(t, "UPDATE `tenant_rows` SET `name`=? WHERE id = ? AND `tenant_rows`.`organization_id` = ?", stmt.SQL.String())
+	assert.Equal(t, []any{"b", 5, 1}, stmt.Vars)
+
+	stmt = db.WithContext(ctx).Scopes(dbutil.TenantScope).Where("id = ?", 5).Delete(&tenantRow{}).Statement
+	assert.Nil(t, stmt.Error)
+	assert.Equal(t, "DELETE FROM `tenant_rows` WHERE id = ? AND `tenant_rows`.`organization_id` = ?", stmt.SQL.String())
+	assert.Equal(t, []any{5, 1}, stmt.Vars)
+}
+
+func TestTenantScopeWithoutTenant(t *testing.T) {
+	db := dryRun(t)
+
+	var rows []tenantRow
+	err := db.WithContext(context.Background()).Model(&tenantRow{}).Scopes(dbutil.TenantScope).Find(&rows).Error
+	assert.ErrorIs(t, err, dbutil.ErrNoTenant)
+
+	err = db.Model(&tenantRow{}).Scopes(dbutil.TenantScope).Where("id = ?", 5).Update("name", "b").Error
+	assert.ErrorIs(t, err, dbutil.ErrNoTenant)
+}
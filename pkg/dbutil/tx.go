@@ -0,0 +1,107 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// txState is the open transaction of a context, depth counts the WithinTx calls nested in it
+type txState struct {
+	tx    *gorm.DB
+	depth int
+}
+
+/*
+TxManager runs a unit of work in one transaction, the transaction travels in the context so every
+repository called with that context joins it through Conn
+
+A WithinTx inside another one runs in a savepoint, its error only rolls back its own writes and the
+outer function decides what happens to the transaction
+*/
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txManager struct {
+	db *gorm.DB
+}
+
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{
+		db: db,
+	}
+}
+
+/*
+WithinTx commits when fn returns nil and rolls back when fn returns an error or panics, the panic
+is raised again after the rollback. The error of the commit is returned, a failed commit is a
+failed unit of work
+*/
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if state, ok := ctx.Value(txKey{}).(txState); ok {
+		return withinSavePoint(ctx, state, fn)
+	}
+
+	tx := m.db.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, txState{tx: tx})); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func withinSavePoint(ctx context.Context, state txState, fn func(ctx context.Context) error) error {
+	state.depth++
+	name := fmt.Sprintf("sp%d", state.depth)
+
+	if err := state.tx.SavePoint(name).Error; err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			state.tx.RollbackTo(name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		if rbErr := state.tx.RollbackTo(name).Error; rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	return nil
+}
+
+// InTx tells if the context carries a transaction opened by WithinTx
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(txState)
+	return ok
+}
+
+// Conn returns the transaction of the context or db outside one, repositories start every query from it
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := ctx.Value(txKey{}).(txState); ok {
+		return state.tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,197 @@
+package dbutil_test
+
+import (
+	"clean-arch/pkg/dbutil"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// recordPool stands in for the connection, it writes down the statements and how the transactions end
+type recordPool struct {
+	log       []string
+	commitErr error
+}
+
+type recordTx struct {
+	pool *recordPool
+}
+
+type recordResult struct{}
+
+func (recordResult) LastInsertId() (int64, error) { return 0, nil }
+func (recordResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (p *recordPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	p.log = append(p.log, "BEGIN")
+	return &recordTx{pool: p}, nil
+}
+
+func (p *recordPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *recordPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	p.log = append(p.log, "db: "+query)
+	return recordResult{}, nil
+}
+
+func (p *recordPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *recordPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+func (t *recordTx) Commit() error {
+	t.pool.log = append(t.pool.log, "COMMIT")
+	return t.pool.commitErr
+}
+
+func (t *recordTx) Rollback() error {
+	t.pool.log = append(t.pool.log, "ROLLBACK")
+	return nil
+}
+
+func (t *recordTx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (t *recordTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	t.pool.log = append(t.pool.log, "tx: "+query)
+	return recordResult{}, nil
+}
+
+func (t *recordTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (t *recordTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+func recordDB(t *testing.T) (*gorm.DB, *recordPool) {
+	pool := &recordPool{}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      pool,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.Nil(t, err)
+
+	return db, pool
+}
+
+func exec(ctx context.Context, db *gorm.DB, query string) error {
+	return dbutil.Conn(ctx, db).Exec(query).Error
+}
+
+func TestWithinTxCommit(t *testing.T) {
+	db, pool := recordDB(t)
+	txm := dbutil.NewTxManager(db)
+
+	err := txm.WithinTx(context.Background(), func(ctx context.Context) error {
+		assert.True(t, dbutil.InTx(ctx))
+		return exec(ctx, db, "UPDATE users SET name = 'a'")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"BEGIN", "tx: UPDATE users SET name = 'a'", "COMMIT"}, pool.log)
+
+	// outside WithinTx the repositories use the connection
+	pool.log = nil
+	assert.False(t, dbutil.InTx(context.Background()))
+	assert.Nil(t, exec(context.Background(), db, "UPDATE users SET name = 'b'"))
+	assert.Equal(t, []string{"db: UPDATE users SET name = 'b'"}, pool.log)
+}
+
+func TestWithinTxRollback(t *testing.T) {
+	db, pool := recordDB(t)
+	txm := dbutil.NewTxManager(db)
+	failed := errors.New("failed")
+
+	err := txm.WithinTx(context.Background(), func(ctx context.Context) error {
+		_ = exec(ctx, db, "DELETE FROM users")
+		return failed
+	})
+	assert.Equal(t, failed, err)
+	assert.Equal(t, []string{"BEGIN", "tx: DELETE FROM users", "ROLLBACK"}, pool.log)
+}
+
+func TestWithinTxPanic(t *testing.T) {
+	db, pool := recordDB(t)
+	txm := dbutil.NewTxManager(db)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = txm.WithinTx(context.Background(), func(ctx context.Context) error {
+			_ = exec(ctx, db, "DELETE FROM users")
+			panic("boom")
+		})
+	})
+	assert.Equal(t, []string{"BEGIN", "tx: DELETE FROM users", "ROLLBACK"}, pool.log)
+}
+
+func TestWithinTxCommitError(t *testing.T) {
+	db, pool := recordDB(t)
+	txm := dbutil.NewTxManager(db)
+	pool.commitErr = errors.New("commit failed")
+
+	err := txm.WithinTx(context.Background(), func(ctx context.Context) error {
+		return exec(ctx, db, "UPDATE users SET name = 'a'")
+	})
+	assert.Equal(t, pool.commitErr, err)
+}
+
+func TestWithinTxNested(t *testing.T) {
+	db, pool := recordDB(t)
+	txm := dbutil.NewTxManager(db)
+	failed := errors.New("failed")
+
+	err := txm.WithinTx(context.Background(), func(ctx context.Context) error {
+		_ = exec(ctx, db, "UPDATE users SET name = 'a'")
+
+		// the inner error only undoes the inner writes, the outer function carries on
+		innerErr := txm.WithinTx(ctx, func(ctx context.Context) error {
+			_ = exec(ctx, db, "DELETE FROM otps")
+			return failed
+		})
+		assert.Equal(t, failed, innerErr)
+
+		return txm.WithinTx(ctx, func(ctx context.Context) error {
+			return txm.WithinTx(ctx, func(ctx context.Context) error {
+				return exec(ctx, db, "UPDATE users SET name = 'b'")
+			})
+		})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"tx: UPDATE users SET name = 'a'",
+		"tx: SAVEPOINT sp1",
+		"tx: DELETE FROM otps",
+		"tx: ROLLBACK TO SAVEPOINT sp1",
+		"tx: SAVEPOINT sp1",
+		"tx: SAVEPOINT sp2",
+		"tx: UPDATE users SET name = 'b'",
+		"COMMIT",
+	}, pool.log)
+}
+
+func TestWithinTxNestedPanic(t *testing.T) {
+	db, pool := recordDB(t)
+	txm := dbutil.NewTxManager(db)
+
+	assert.Panics(t, func() {
+		_ = txm.WithinTx(context.Background(), func(ctx context.Context) error {
+			return txm.WithinTx(ctx, func(ctx context.Context) error {
+				panic("boom")
+			})
+		})
+	})
+	assert.Equal(t, []string{"BEGIN", "tx: SAVEPOINT sp1", "tx: ROLLBACK TO SAVEPOINT sp1", "ROLLBACK"}, pool.log)
+}
@@ -46,6 +46,11 @@ func GenerateAll(data TemplateData) error {
 		return err
 	}
 
+	err = generateModel(data)
+	if err != nil {
+		return err
+	}
+
 	err = generateRepository(data)
 	if err != nil {
 		return err
@@ -158,6 +163,35 @@ func generateRouter(data TemplateData) error {
 	return nil
 }
 
+// generateModel writes a model with only the id and timestamps, the repository is generic over it so it has to exist
+func generateModel(data TemplateData) error {
+	fName := fmt.Sprintf("internal/model/%s.go", data.StructName)
+
+	if _, err := os.Stat(fName); err == nil {
+		log.Println("file already exists:", fName)
+		return nil
+	}
+
+	eFile, err := os.Create(fName)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.ParseFiles(fmt.Sprintf("./pkg/genx/%s", "model.tpl"))
+	if err != nil {
+		return err
+	}
+
+	err = tmpl.Execute(eFile, data)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Success generate model")
+
+	return nil
+}
+
 func generateRepository(data TemplateData) error {
 	fName := fmt.Sprintf("internal/repository/%s.go", data.StructName)
 
@@ -0,0 +1,11 @@
+package model
+
+// Define the table columns, the generic repository expects an id primary key
+type {{.EntityName}} struct {
+	ID int `gorm:"primaryKey" json:"id"`
+	Common
+}
+
+func ({{.EntityName}}) TableName() string {
+	return "{{.StructName}}s"
+}
@@ -1,23 +1,30 @@
 package repository
 
 import (
+	"{{.ModuleName}}/internal/model"
+
 	"gorm.io/gorm"
 )
 
 // Define the interface for method call
+// Repository gives FindOne, FindAll, FindPage, Count, Exists, Store, StoreBatch, Upsert and the updates and delete
+// Only add the queries specific to {{.EntityName}} here
 type {{.EntityName}} interface {
+	Repository[model.{{.EntityName}}]
 }
 
 // Define the scoped type
 type {{.StructName}} struct {
+	Repository[model.{{.EntityName}}]
 	Db *gorm.DB
 }
 
 // Here the function is to get the database connection and to allow running the query
 // This function will be called inside factory package
 // gorm.DB contain database connection
-func New{{.EntityName}}Repository(db *gorm.DB) *{{.StructName}} {
+func New{{.EntityName}}Repository(db *gorm.DB) {{.EntityName}} {
 	return &{{.StructName}}{
-		db,
+		Repository: NewRepository[model.{{.EntityName}}](db),
+		Db:         db,
 	}
 }
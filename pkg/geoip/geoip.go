@@ -0,0 +1,150 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Location is the coarse position of an ip address
+type Location struct {
+	Country   string  `json:"country"`
+	Region    string  `json:"region"`
+	City      string  `json:"city"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type ipRange struct {
+	start netip.Addr
+	end   netip.Addr
+	loc   Location
+}
+
+// DB is an in memory copy of an offline ip to location database
+type DB struct {
+	ranges []ipRange
+}
+
+/*
+Open loads a CSV database in the DB-IP lite layout, the city columns are optional
+
+	ip_start,ip_end,continent,country,region,city,latitude,longitude
+*/
+func Open(path string) (*DB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+func Load(r io.Reader) (*DB, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	db := &DB{}
+	line := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("geoip line %d: %w", line, err)
+		}
+
+		if len(record) < 4 {
+			return nil, fmt.Errorf("geoip line %d: expected at least 4 columns", line)
+		}
+
+		start, err := netip.ParseAddr(strings.TrimSpace(record[0]))
+		if err != nil {
+			// allow a header row
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("geoip line %d: %w", line, err)
+		}
+
+		end, err := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf("geoip line %d: %w", line, err)
+		}
+
+		loc := Location{
+			Country: strings.TrimSpace(record[3]),
+		}
+
+		if len(record) >= 6 {
+			loc.Region = strings.TrimSpace(record[4])
+			loc.City = strings.TrimSpace(record[5])
+		}
+
+		if len(record) >= 8 {
+			loc.Latitude, _ = strconv.ParseFloat(strings.TrimSpace(record[6]), 64)
+			loc.Longitude, _ = strconv.ParseFloat(strings.TrimSpace(record[7]), 64)
+		}
+
+		db.ranges = append(db.ranges, ipRange{start: start.Unmap(), end: end.Unmap(), loc: loc})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].start.Less(db.ranges[j].start)
+	})
+
+	return db, nil
+}
+
+// Lookup finds the location of the ip, a nil DB never finds anything
+func (db *DB) Lookup(ip string) (Location, bool) {
+	if db == nil {
+		return Location{}, false
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return Location{}, false
+	}
+	addr = addr.Unmap()
+
+	// first range starting after the address, the candidate is the one before it
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].start)
+	})
+	if i == 0 {
+		return Location{}, false
+	}
+
+	r := db.ranges[i-1]
+	if r.start.Is4() != addr.Is4() || r.end.Less(addr) {
+		return Location{}, false
+	}
+
+	return r.loc, true
+}
+
+// DistanceKm is the great circle distance between two locations
+func DistanceKm(a, b Location) float64 {
+	const earthRadiusKm = 6371.0
+
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,51 @@
+package geoip_test
+
+import (
+	"clean-arch/pkg/geoip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample = `ip_start,ip_end,continent,country,region,city,latitude,longitude
+1.0.0.0,1.0.0.255,OC,AU,Queensland,Brisbane,-27.4679,153.028
+36.64.0.0,36.95.255.255,AS,ID,Jakarta,Jakarta,-6.2146,106.845
+2001:4860::,2001:4860:ffff:ffff:ffff:ffff:ffff:ffff,NA,US,California,Mountain View,37.4223,-122.085
+`
+
+func TestLookup(t *testing.T) {
+	db, err := geoip.Load(strings.NewReader(sample))
+	assert.Nil(t, err)
+
+	loc, ok := db.Lookup("36.70.1.1")
+	assert.True(t, ok)
+	assert.Equal(t, "ID", loc.Country)
+	assert.Equal(t, "Jakarta", loc.City)
+
+	loc, ok = db.Lookup("2001:4860:4860::8888")
+	assert.True(t, ok)
+	assert.Equal(t, "US", loc.Country)
+
+	loc, ok = db.Lookup("::ffff:1.0.0.1")
+	assert.True(t, ok)
+	assert.Equal(t, "AU", loc.Country)
+
+	_, ok = db.Lookup("8.8.8.8")
+	assert.False(t, ok)
+
+	_, ok = db.Lookup("not-an-ip")
+	assert.False(t, ok)
+
+	var empty *geoip.DB
+	_, ok = empty.Lookup("36.70.1.1")
+	assert.False(t, ok)
+}
+
+func TestDistanceKm(t *testing.T) {
+	jakarta := geoip.Location{Latitude: -6.2146, Longitude: 106.845}
+	brisbane := geoip.Location{Latitude: -27.4679, Longitude: 153.028}
+
+	distance := geoip.DistanceKm(jakarta, brisbane)
+	assert.InDelta(t, 5410, distance, 50)
+}
@@ -0,0 +1,190 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/bmp"
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatBMP  = "bmp"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image, please use jpg, png or bmp")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// Options of Process, MaxPixels guards against decompression bombs, a tiny file can claim a huge canvas
+type Options struct {
+	MaxPixels int
+	Sizes     []int
+	Quality   int
+}
+
+// Variant is one square jpeg made by Process
+type Variant struct {
+	Size int
+	Data []byte
+}
+
+var signatures = []struct {
+	format string
+	magic  []byte
+}{
+	{FormatJPEG, []byte{0xFF, 0xD8, 0xFF}},
+	{FormatPNG, []byte("\x89PNG\r\n\x1a\n")},
+	{FormatBMP, []byte("BM")},
+}
+
+// Sniff returns the format from the magic bytes at the start of the file, an empty string means it isn't a supported image
+func Sniff(head []byte) string {
+	for _, signature := range signatures {
+		if bytes.HasPrefix(head, signature.magic) {
+			return signature.format
+		}
+	}
+	return ""
+}
+
+/*
+Process turns an uploaded image into square jpegs of every size, centre cropped
+
+The dimensions are read from the header before anything is decoded, the output is re-encoded
+from pixels so EXIF and any other metadata is dropped, the EXIF orientation is applied first
+*/
+func Process(data []byte, opts Options) ([]Variant, error) {
+	format := Sniff(data)
+	if format == "" {
+		return nil, ErrUnsupportedImage
+	}
+
+	config, err := decodeConfig(format, data)
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > opts.MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, err := decode(format, data)
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	orientation := 1
+	if format == FormatJPEG {
+		orientation = exifOrientation(data)
+	}
+
+	variants := make([]Variant, 0, len(opts.Sizes))
+	for _, size := range opts.Sizes {
+		// a centre square looks the same after any orientation, so only the small result is rotated
+		thumb := Orient(Thumbnail(src, size), orientation)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, flatten(thumb), &jpeg.Options{Quality: opts.Quality}); err != nil {
+			return nil, err
+		}
+
+		variants = append(variants, Variant{Size: size, Data: buf.Bytes()})
+	}
+
+	return variants, nil
+}
+
+func decodeConfig(format string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case FormatJPEG:
+		return jpeg.DecodeConfig(r)
+	case FormatPNG:
+		return png.DecodeConfig(r)
+	}
+	return bmp.DecodeConfig(r)
+}
+
+func decode(format string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case FormatJPEG:
+		return jpeg.Decode(r)
+	case FormatPNG:
+		return png.Decode(r)
+	}
+	return bmp.Decode(r)
+}
+
+// Thumbnail crops the centre square of src and scales it to size x size
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, xdraw.Src, nil)
+	return dst
+}
+
+// Orient applies an EXIF orientation (1-8) to img, 1 and unknown values return img as is
+func Orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, img.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return dst
+}
+
+// flatten draws img on white so transparent pixels don't turn black in the jpeg
+func flatten(img image.Image) *image.RGBA {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
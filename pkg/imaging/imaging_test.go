@@ -0,0 +1,107 @@
+package imaging_test
+
+import (
+	"bytes"
+	"clean-arch/pkg/imaging"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var options = imaging.Options{
+	MaxPixels: 1000 * 1000,
+	Sizes:     []int{16, 64},
+	Quality:   90,
+}
+
+// quadrants returns an image whose top left quarter is red and the rest blue
+func quadrants(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{0, 0, 255, 255}
+			if x < w/2 && y < h/2 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF segment with the orientation tag right after the jpeg SOI marker
+func withOrientation(data []byte, orientation byte) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, 0, 0, 0, 0, 0, 0}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestSniff(t *testing.T) {
+	assert.Equal(t, imaging.FormatJPEG, imaging.Sniff([]byte{0xFF, 0xD8, 0xFF, 0xE0}))
+	assert.Equal(t, imaging.FormatPNG, imaging.Sniff([]byte("\x89PNG\r\n\x1a\nrest")))
+	assert.Equal(t, imaging.FormatBMP, imaging.Sniff([]byte("BM....")))
+	assert.Equal(t, "", imaging.Sniff([]byte("GIF89a")))
+	assert.Equal(t, "", imaging.Sniff([]byte("<?php echo 1; ?>")))
+}
+
+func TestProcess(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, quadrants(120, 80)))
+
+	variants, err := imaging.Process(buf.Bytes(), options)
+	assert.Nil(t, err)
+	assert.Len(t, variants, 2)
+
+	for i, size := range options.Sizes {
+		assert.Equal(t, size, variants[i].Size)
+		assert.Equal(t, imaging.FormatJPEG, imaging.Sniff(variants[i].Data))
+
+		img, err := jpeg.Decode(bytes.NewReader(variants[i].Data))
+		assert.Nil(t, err)
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+	}
+}
+
+func TestProcessAppliesAndStripsOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&buf, quadrants(64, 64), &jpeg.Options{Quality: 100}))
+
+	// 6 means the picture has to be turned clockwise, so the red corner ends at the top right
+	variants, err := imaging.Process(withOrientation(buf.Bytes(), 6), options)
+	assert.Nil(t, err)
+
+	out := variants[1].Data
+	assert.False(t, bytes.Contains(out, []byte("Exif")))
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	assert.Nil(t, err)
+	assert.True(t, isRed(img.At(56, 8)))
+	assert.False(t, isRed(img.At(8, 8)))
+}
+
+func TestProcessRejects(t *testing.T) {
+	_, err := imaging.Process([]byte("GIF89a...."), options)
+	assert.ErrorIs(t, err, imaging.ErrUnsupportedImage)
+
+	_, err = imaging.Process([]byte{0xFF, 0xD8, 0xFF, 0x00, 0x01}, options)
+	assert.ErrorIs(t, err, imaging.ErrUnsupportedImage)
+
+	// a flat canvas compresses to a few kilobytes but would need far more memory once decoded
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2000, 1000))))
+	assert.Less(t, buf.Len(), 20*1024)
+
+	_, err = imaging.Process(buf.Bytes(), options)
+	assert.ErrorIs(t, err, imaging.ErrImageTooLarge)
+}
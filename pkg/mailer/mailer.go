@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"gopkg.in/gomail.v2"
+)
+
+// Mailer sends an html email, Send returns once the server accepted or refused it
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+// Config is the smtp server, Username is also the sender address
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+type smtpMailer struct {
+	from   string
+	dialer *gomail.Dialer
+}
+
+func NewSMTP(cfg Config) Mailer {
+	return &smtpMailer{
+		from:   cfg.Username,
+		dialer: gomail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password),
+	}
+}
+
+func (m *smtpMailer) Send(to string, subject string, body string) error {
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", m.from)
+	msg.SetHeader("To", to)
+	msg.SetHeader("Subject", subject)
+	msg.SetBody("text/html", body)
+
+	if err := m.dialer.DialAndSend(msg); err != nil {
+		return err
+	}
+
+	return nil
+}
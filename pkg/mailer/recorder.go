@@ -0,0 +1,30 @@
+package mailer
+
+import "sync"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Recorder keeps the emails instead of sending them, the services send from goroutines so tests poll Sent
+type Recorder struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (r *Recorder) Send(to string, subject string, body string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sent = append(r.sent, Message{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+func (r *Recorder) Sent() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Message{}, r.sent...)
+}
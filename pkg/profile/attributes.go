@@ -0,0 +1,188 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+)
+
+// Field describes one custom profile attribute, Default is returned while the user hasn't set it
+type Field struct {
+	Type      string   `json:"type"`
+	Label     string   `json:"label,omitempty"`
+	MaxLength int      `json:"max_length,omitempty"`
+	Enum      []string `json:"enum,omitempty"`
+	Default   any      `json:"default,omitempty"`
+}
+
+// Schema lists the custom attributes a profile can have, keyed by the attribute name
+type Schema map[string]Field
+
+// DefaultSchema is used when PROFILE_SCHEMA_PATH isn't set
+var DefaultSchema = Schema{
+	"job_title":  {Type: TypeString, Label: "Job title", MaxLength: 100},
+	"department": {Type: TypeString, Label: "Department", MaxLength: 100},
+	"bio":        {Type: TypeString, Label: "Bio", MaxLength: 500},
+	"website":    {Type: TypeString, Label: "Website", MaxLength: 255},
+	"birth_date": {Type: TypeDate, Label: "Birth date"},
+	"gender":     {Type: TypeString, Label: "Gender", Enum: []string{"male", "female", "other"}},
+}
+
+// LoadSchema reads a schema from a JSON file and checks every field has a known type
+func LoadSchema(path string) (Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("error decoding profile schema %s", err.Error())
+	}
+
+	for name, field := range schema {
+		switch field.Type {
+		case TypeString, TypeNumber, TypeBoolean, TypeDate:
+		default:
+			return nil, fmt.Errorf("profile attribute %s has unknown type %q", name, field.Type)
+		}
+
+		if field.Default != nil {
+			if err := field.check(field.Default); err != nil {
+				return nil, fmt.Errorf("default of profile attribute %s %s", name, err.Error())
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+// Validate rejects attributes missing from the schema and values of the wrong type, every problem is reported
+func (s Schema) Validate(attrs map[string]any) error {
+	var problems []string
+
+	for _, name := range sortedKeys(attrs) {
+		// null removes the attribute, see Merge
+		if attrs[name] == nil {
+			continue
+		}
+
+		field, ok := s[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown attribute", name))
+			continue
+		}
+
+		if err := field.check(attrs[name]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, err.Error()))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// Resolve returns the stored attributes with the defaults of the schema, attributes no longer in the schema are hidden
+func (s Schema) Resolve(attrs map[string]any) map[string]any {
+	res := map[string]any{}
+
+	for name, field := range s {
+		if value, ok := attrs[name]; ok {
+			res[name] = value
+		} else if field.Default != nil {
+			res[name] = field.Default
+		}
+	}
+
+	return res
+}
+
+// Merge applies a partial update to the stored attributes, a null value removes the attribute
+func Merge(current map[string]any, patch map[string]any) map[string]any {
+	res := make(map[string]any, len(current)+len(patch))
+	for name, value := range current {
+		res[name] = value
+	}
+
+	for name, value := range patch {
+		if value == nil {
+			delete(res, name)
+			continue
+		}
+		res[name] = value
+	}
+
+	return res
+}
+
+func (f Field) check(value any) error {
+	if value == nil {
+		return nil
+	}
+
+	switch f.Type {
+	case TypeNumber:
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("must be a number")
+		}
+		return nil
+	case TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be a boolean")
+		}
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("must be a string")
+	}
+
+	if f.Type == TypeDate {
+		if _, err := time.Parse(time.DateOnly, str); err != nil {
+			return fmt.Errorf("must be a date formatted as YYYY-MM-DD")
+		}
+	}
+
+	if f.MaxLength > 0 && utf8.RuneCountInString(str) > f.MaxLength {
+		return fmt.Errorf("must be at most %d characters", f.MaxLength)
+	}
+
+	if len(f.Enum) > 0 && !contains(f.Enum, str) {
+		return fmt.Errorf("must be one of %s", strings.Join(f.Enum, ", "))
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
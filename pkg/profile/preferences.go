@@ -0,0 +1,167 @@
+package profile
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+	ThemeSystem = "system"
+)
+
+var (
+	Locales = []string{"id", "en"}
+	Themes  = []string{ThemeLight, ThemeDark, ThemeSystem}
+)
+
+type Notifications struct {
+	Email bool `json:"email"`
+	SMS   bool `json:"sms"`
+	Push  bool `json:"push"`
+}
+
+/*
+Preferences are stored as one JSON column, a field added later is simply missing from the rows
+written before it and Resolve fills it from the defaults
+*/
+type Preferences struct {
+	Locale             *string        `json:"locale,omitempty"`
+	Timezone           *string        `json:"timezone,omitempty"`
+	Theme              *string        `json:"theme,omitempty"`
+	Notifications      *Notifications `json:"notifications,omitempty"`
+	MarketingConsent   *bool          `json:"marketing_consent,omitempty"`
+	MarketingConsentAt *time.Time     `json:"marketing_consent_at,omitempty"`
+}
+
+// Resolved is Preferences with every default applied, this is what the API returns
+type Resolved struct {
+	Locale             string        `json:"locale"`
+	Timezone           string        `json:"timezone"`
+	Theme              string        `json:"theme"`
+	Notifications      Notifications `json:"notifications"`
+	MarketingConsent   bool          `json:"marketing_consent"`
+	MarketingConsentAt *time.Time    `json:"marketing_consent_at"`
+}
+
+// Defaults are used for every preference the user never set, marketing is opt in
+var Defaults = Resolved{
+	Locale:        "id",
+	Timezone:      "Asia/Jakarta",
+	Theme:         ThemeSystem,
+	Notifications: Notifications{Email: true},
+}
+
+// NotificationsPatch changes only the channels that are set
+type NotificationsPatch struct {
+	Email *bool `json:"email"`
+	SMS   *bool `json:"sms"`
+	Push  *bool `json:"push"`
+}
+
+// Patch is a partial update of the preferences, the consent time is set by Apply and can't be sent
+type Patch struct {
+	Locale           *string             `json:"locale"`
+	Timezone         *string             `json:"timezone"`
+	Theme            *string             `json:"theme"`
+	Notifications    *NotificationsPatch `json:"notifications"`
+	MarketingConsent *bool               `json:"marketing_consent"`
+}
+
+func (p Patch) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Locale, validation.NilOrNotEmpty, validation.In(toAny(Locales)...)),
+		validation.Field(&p.Timezone, validation.NilOrNotEmpty, validation.By(checkTimezone)),
+		validation.Field(&p.Theme, validation.NilOrNotEmpty, validation.In(toAny(Themes)...)),
+	)
+}
+
+// Resolve returns the preferences with the defaults applied to every field that isn't stored
+func (p *Preferences) Resolve() Resolved {
+	res := Defaults
+	if p == nil {
+		return res
+	}
+
+	if p.Locale != nil {
+		res.Locale = *p.Locale
+	}
+	if p.Timezone != nil {
+		res.Timezone = *p.Timezone
+	}
+	if p.Theme != nil {
+		res.Theme = *p.Theme
+	}
+	if p.Notifications != nil {
+		res.Notifications = *p.Notifications
+	}
+	if p.MarketingConsent != nil {
+		res.MarketingConsent = *p.MarketingConsent
+		res.MarketingConsentAt = p.MarketingConsentAt
+	}
+
+	return res
+}
+
+// Apply returns the preferences with the patch applied, the consent time changes only when the consent does
+func (p *Preferences) Apply(patch Patch, now time.Time) Preferences {
+	var res Preferences
+	if p != nil {
+		res = *p
+	}
+
+	if patch.Locale != nil {
+		res.Locale = patch.Locale
+	}
+	if patch.Timezone != nil {
+		res.Timezone = patch.Timezone
+	}
+	if patch.Theme != nil {
+		res.Theme = patch.Theme
+	}
+
+	if patch.Notifications != nil {
+		notifications := res.Resolve().Notifications
+		if patch.Notifications.Email != nil {
+			notifications.Email = *patch.Notifications.Email
+		}
+		if patch.Notifications.SMS != nil {
+			notifications.SMS = *patch.Notifications.SMS
+		}
+		if patch.Notifications.Push != nil {
+			notifications.Push = *patch.Notifications.Push
+		}
+		res.Notifications = &notifications
+	}
+
+	if patch.MarketingConsent != nil && (res.MarketingConsent == nil || *res.MarketingConsent != *patch.MarketingConsent) {
+		consent := *patch.MarketingConsent
+		res.MarketingConsent = &consent
+		res.MarketingConsentAt = &now
+	}
+
+	return res
+}
+
+func checkTimezone(value any) error {
+	timezone, _ := value.(*string)
+	if timezone == nil || *timezone == "" {
+		return nil
+	}
+
+	if _, err := time.LoadLocation(*timezone); err != nil {
+		return validation.NewError("validation_timezone", "must be a valid IANA time zone")
+	}
+
+	return nil
+}
+
+func toAny(values []string) []any {
+	res := make([]any, len(values))
+	for i, value := range values {
+		res[i] = value
+	}
+	return res
+}
@@ -0,0 +1,105 @@
+package profile_test
+
+import (
+	"clean-arch/pkg/profile"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestPreferencesResolve(t *testing.T) {
+	var empty *profile.Preferences
+	assert.Equal(t, profile.Defaults, empty.Resolve())
+
+	stored := &profile.Preferences{Theme: ptr(profile.ThemeDark)}
+	res := stored.Resolve()
+	assert.Equal(t, profile.ThemeDark, res.Theme)
+	assert.Equal(t, profile.Defaults.Locale, res.Locale)
+	assert.True(t, res.Notifications.Email)
+	assert.False(t, res.MarketingConsent)
+}
+
+func TestPreferencesApply(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	var stored *profile.Preferences
+	res := stored.Apply(profile.Patch{
+		Locale:           ptr("en"),
+		Notifications:    &profile.NotificationsPatch{SMS: ptr(true)},
+		MarketingConsent: ptr(true),
+	}, now)
+
+	resolved := res.Resolve()
+	assert.Equal(t, "en", resolved.Locale)
+	assert.Equal(t, profile.Notifications{Email: true, SMS: true}, resolved.Notifications)
+	assert.True(t, resolved.MarketingConsent)
+	assert.Equal(t, now, *resolved.MarketingConsentAt)
+
+	// sending the same consent again keeps the time it was given
+	res = res.Apply(profile.Patch{MarketingConsent: ptr(true)}, now.Add(time.Hour))
+	assert.Equal(t, now, *res.MarketingConsentAt)
+
+	res = res.Apply(profile.Patch{MarketingConsent: ptr(false)}, now.Add(time.Hour))
+	assert.False(t, *res.MarketingConsent)
+	assert.Equal(t, now.Add(time.Hour), *res.MarketingConsentAt)
+
+	// fields not stored yet are left out of the JSON, so new defaults still apply to them
+	encoded, _ := json.Marshal(profile.Preferences{Theme: ptr(profile.ThemeLight)})
+	assert.JSONEq(t, `{"theme":"light"}`, string(encoded))
+}
+
+func TestPatchValidate(t *testing.T) {
+	assert.Nil(t, profile.Patch{Locale: ptr("id"), Timezone: ptr("Europe/Berlin"), Theme: ptr(profile.ThemeLight)}.Validate())
+	assert.Nil(t, profile.Patch{}.Validate())
+
+	assert.NotNil(t, profile.Patch{Locale: ptr("fr")}.Validate())
+	assert.NotNil(t, profile.Patch{Timezone: ptr("Mars/Olympus")}.Validate())
+	assert.NotNil(t, profile.Patch{Theme: ptr("")}.Validate())
+}
+
+func TestSchema(t *testing.T) {
+	schema := profile.Schema{
+		"bio":        {Type: profile.TypeString, MaxLength: 5},
+		"gender":     {Type: profile.TypeString, Enum: []string{"male", "female"}},
+		"birth_date": {Type: profile.TypeDate},
+		"age":        {Type: profile.TypeNumber},
+		"newsletter": {Type: profile.TypeBoolean, Default: false},
+	}
+
+	assert.Nil(t, schema.Validate(map[string]any{"bio": "hello", "gender": "male", "birth_date": "1990-01-31", "age": 30.0, "newsletter": true}))
+
+	err := schema.Validate(map[string]any{"bio": "too long", "gender": "x", "birth_date": "31-01-1990", "age": "30", "nickname": "a"})
+	assert.EqualError(t, err, "age: must be a number; bio: must be at most 5 characters; birth_date: must be a date formatted as YYYY-MM-DD; gender: must be one of male, female; nickname: unknown attribute")
+
+	merged := profile.Merge(map[string]any{"bio": "hi", "age": 30.0}, map[string]any{"bio": nil, "gender": "female"})
+	assert.Equal(t, map[string]any{"age": 30.0, "gender": "female"}, merged)
+
+	assert.Equal(t, map[string]any{"age": 30.0, "newsletter": false}, schema.Resolve(map[string]any{"age": 30.0, "removed": "x"}))
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "schema.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"team": {"type": "string", "max_length": 20, "default": "core"}}`), 0o644))
+
+	schema, err := profile.LoadSchema(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "core", schema["team"].Default)
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"team": {"type": "object"}}`), 0o644))
+	_, err = profile.LoadSchema(path)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"age": {"type": "number", "default": "ten"}}`), 0o644))
+	_, err = profile.LoadSchema(path)
+	assert.NotNil(t, err)
+}
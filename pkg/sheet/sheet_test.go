@@ -0,0 +1,39 @@
+package sheet_test
+
+import (
+	"bytes"
+	"clean-arch/pkg/sheet"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadCSV(t *testing.T) {
+	rows, err := sheet.Read(strings.NewReader("name,email\nJohn, john@example.com\nJane,jane@example.com,extra\n"), "users.CSV")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"name", "email"},
+		{"John", "john@example.com"},
+		{"Jane", "jane@example.com", "extra"},
+	}, rows)
+}
+
+func TestReadXLSX(t *testing.T) {
+	file := excelize.NewFile()
+	_ = file.SetSheetRow("Sheet1", "A1", &[]any{"name", "email"})
+	_ = file.SetSheetRow("Sheet1", "A2", &[]any{"John", "john@example.com"})
+
+	var buf bytes.Buffer
+	assert.Nil(t, file.Write(&buf))
+
+	rows, err := sheet.Read(&buf, "users.xlsx")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"name", "email"}, {"John", "john@example.com"}}, rows)
+}
+
+func TestReadUnsupported(t *testing.T) {
+	_, err := sheet.Read(strings.NewReader(""), "users.txt")
+	assert.ErrorIs(t, err, sheet.ErrUnsupportedFormat)
+}
@@ -0,0 +1,173 @@
+package sheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// Writer writes rows one by one, Close flushes what is buffered and must always be called
+type Writer interface {
+	Write(values []any) error
+	Close() error
+}
+
+// ContentType returns the mime type of a format, an empty string means the format is not supported
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+/*
+NewWriter writes the header followed by the rows in the given format
+
+	w, _ := sheet.NewWriter(c.Writer, sheet.FormatCSV, []string{"id", "name"})
+	_ = w.Write([]any{1, "John Doe"})
+	_ = w.Close()
+
+csv and ndjson are written as the rows come, xlsx is kept in a temporary file by excelize
+and only copied to w on Close
+*/
+func NewWriter(w io.Writer, format string, header []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		writer := &csvWriter{writer: csv.NewWriter(w)}
+		if err := writer.writer.Write(header); err != nil {
+			return nil, err
+		}
+		return writer, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{writer: bufio.NewWriter(w), header: header}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, header)
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if value != nil {
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// ndjsonWriter writes one object per line, keys keep the header order
+type ndjsonWriter struct {
+	writer *bufio.Writer
+	header []string
+}
+
+func (w *ndjsonWriter) Write(values []any) error {
+	var line bytes.Buffer
+	line.WriteByte('{')
+
+	for i, name := range w.header {
+		var value any
+		if i < len(values) {
+			value = values[i]
+		}
+
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		line.Write(key)
+		line.WriteByte(':')
+		line.Write(encoded)
+	}
+
+	line.WriteString("}\n")
+	_, err := w.writer.Write(line.Bytes())
+	return err
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.writer.Flush()
+}
+
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, header []string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	writer := &xlsxWriter{out: w, file: file, stream: stream}
+
+	values := make([]any, len(header))
+	for i, name := range header {
+		values[i] = name
+	}
+	if err := writer.Write(values); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (w *xlsxWriter) Write(values []any) error {
+	w.row++
+
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, values)
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := w.file.WriteTo(w.out)
+	return err
+}
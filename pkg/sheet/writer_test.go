@@ -0,0 +1,49 @@
+package sheet_test
+
+import (
+	"bytes"
+	"clean-arch/pkg/sheet"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRows(t *testing.T, format string) *bytes.Buffer {
+	var buf bytes.Buffer
+
+	w, err := sheet.NewWriter(&buf, format, []string{"id", "name", "verified_at"})
+	assert.Nil(t, err)
+	assert.Nil(t, w.Write([]any{1, "John, Doe", nil}))
+	assert.Nil(t, w.Write([]any{2, "Jane", "2026-10-19 09:00:00"}))
+	assert.Nil(t, w.Close())
+
+	return &buf
+}
+
+func TestWriteCSV(t *testing.T) {
+	buf := writeRows(t, sheet.FormatCSV)
+	assert.Equal(t, "id,name,verified_at\n1,\"John, Doe\",\n2,Jane,2026-10-19 09:00:00\n", buf.String())
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	buf := writeRows(t, sheet.FormatNDJSON)
+	assert.Equal(t, "{\"id\":1,\"name\":\"John, Doe\",\"verified_at\":null}\n{\"id\":2,\"name\":\"Jane\",\"verified_at\":\"2026-10-19 09:00:00\"}\n", buf.String())
+}
+
+func TestWriteXLSX(t *testing.T) {
+	buf := writeRows(t, sheet.FormatXLSX)
+
+	rows, err := sheet.Read(buf, "users.xlsx")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "name", "verified_at"},
+		{"1", "John, Doe"},
+		{"2", "Jane", "2026-10-19 09:00:00"},
+	}, rows)
+}
+
+func TestWriteUnsupported(t *testing.T) {
+	_, err := sheet.NewWriter(&bytes.Buffer{}, "pdf", []string{"id"})
+	assert.ErrorIs(t, err, sheet.ErrUnsupportedFormat)
+	assert.Equal(t, "", sheet.ContentType("pdf"))
+}
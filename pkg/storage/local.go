@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"clean-arch/pkg/crypto"
+	"context"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid or expired file signature")
+
+// Local keeps files on the disk under Root
+type Local struct {
+	Root    string
+	BaseURL string
+	SignKey string
+	// Now is the clock signed links expire on, nil means time.Now
+	Now func() time.Time
+}
+
+func NewLocal(root string, baseURL string, signKey string) *Local {
+	return &Local{
+		Root:    root,
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		SignKey: signKey,
+	}
+}
+
+// path returns the file of a key, CleanKey keeps it inside Root
+func (l *Local) path(key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put writes to a temporary file first so readers never see a half written file
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	filePath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	filePath, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Open returns ErrNotFound for directories too, only files can be served
+func (l *Local) Open(ctx context.Context, key string) (File, error) {
+	filePath, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.IsDir() {
+		file.Close()
+		return nil, ErrNotFound
+	}
+
+	return file, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	filePath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Exists(ctx context.Context, key string) (bool, error) {
+	filePath, err := l.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+func (l *Local) URL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return l.BaseURL + "/" + key
+}
+
+// SignedURL appends the expiry and its signature, VerifySignature checks them when the file is served
+func (l *Local) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := strconv.FormatInt(l.now().Add(expires).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expiresAt)
+	query.Set("signature", l.signature(cleaned, expiresAt))
+
+	return l.URL(cleaned) + "?" + query.Encode(), nil
+}
+
+// VerifySignature checks the expires and signature params of a link made by SignedURL
+func (l *Local) VerifySignature(key string, expires string, signature string) error {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || l.now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(l.signature(cleaned, expires)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (l *Local) signature(key string, expires string) string {
+	return crypto.EncodeSHA256HMAC(l.SignKey+":storage", key, "\n", expires)
+}
+
+func (l *Local) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
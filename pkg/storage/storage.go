@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	ErrNotFound   = errors.New("file not found")
+	ErrInvalidKey = errors.New("invalid file key")
+)
+
+/*
+Storage keeps files under object keys such as "avatars/1700000000-ab12.jpg", the database stores
+the key and URL or SignedURL turn it into a link when a response is built
+*/
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get returns ErrNotFound when the key doesn't exist, the reader must be closed
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete doesn't fail when the key is already gone
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// URL is the public link of a key, an empty key gives an empty URL
+	URL(key string) string
+	// SignedURL is a link that stops working after expires, for files that aren't public
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// File is a stored file opened for random access, e.g. to answer Range requests
+type File interface {
+	io.ReadSeekCloser
+	Stat() (fs.FileInfo, error)
+}
+
+// Opener is implemented by storages whose files can be opened for random access, the others are served through SignedURL
+type Opener interface {
+	Open(ctx context.Context, key string) (File, error)
+}
+
+// Verifier is implemented by storages that check the links made by their own SignedURL
+type Verifier interface {
+	VerifySignature(key string, expires string, signature string) error
+}
+
+// Config selects and configures the driver, only the fields of the chosen driver are used
+type Config struct {
+	Driver string
+
+	// LocalRoot is the directory files are written to, BaseURL is where the directory is served
+	LocalRoot string
+	BaseURL   string
+	SignKey   string
+
+	S3 S3Config
+
+	// Now is the clock of signed links and requests, nil means time.Now
+	Now func() time.Time
+}
+
+// New builds the storage of the configured driver
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case DriverLocal, "":
+		local := NewLocal(cfg.LocalRoot, cfg.BaseURL, cfg.SignKey)
+		local.Now = cfg.Now
+		return local, nil
+	case DriverS3:
+		s3, err := NewS3(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		s3.Now = cfg.Now
+		return s3, nil
+	}
+
+	return nil, fmt.Errorf("unknown storage driver %s", cfg.Driver)
+}
+
+// CleanKey normalizes a key and rejects the ones that could leave the storage root
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, "\\\x00") || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+
+	return cleaned, nil
+}
+
+// NewKey returns a unique key under prefix that keeps the extension of filename, now is the upload time it starts with
+func NewKey(prefix string, filename string, now time.Time) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	return path.Join(prefix, fmt.Sprintf("%d-%x%s", now.Unix(), b, ext)), nil
+}
@@ -0,0 +1,114 @@
+package token
+
+import (
+	"clean-arch/pkg/clock"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var ErrInvalidToken = errors.New("token not valid")
+
+// Claims is what an access token carries, OrganizationID is the organization picked with /auth/switch-org
+type Claims struct {
+	UserID         int
+	Email          string
+	SessionID      int
+	OrganizationID *int
+}
+
+/*
+Issuer signs the access tokens given out at login and checks the ones sent back in the
+Authorization header, Parse returns ErrInvalidToken for a token that is forged, malformed or expired
+*/
+type Issuer interface {
+	Issue(claims Claims) (string, time.Time, error)
+	Parse(tokenString string) (Claims, error)
+}
+
+// Config is the signing key and lifetime of the tokens, the expiry returned by Issue is in Location
+type Config struct {
+	Secret   []byte
+	Duration time.Duration
+	Location *time.Location
+}
+
+type jwtIssuer struct {
+	cfg   Config
+	clock clock.Clock
+}
+
+// NewJWT issues HS256 tokens, the claims keep the names the tokens issued before had
+func NewJWT(cfg Config, c clock.Clock) Issuer {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	return &jwtIssuer{
+		cfg:   cfg,
+		clock: c,
+	}
+}
+
+func (i *jwtIssuer) Issue(claims Claims) (string, time.Time, error) {
+	expiresAt := i.clock.Now().In(i.cfg.Location).Add(i.cfg.Duration)
+
+	mapClaims := jwt.MapClaims{
+		"user_id": strconv.Itoa(claims.UserID),
+		"email":   claims.Email,
+		"sid":     claims.SessionID,
+		"exp":     expiresAt.Unix(),
+	}
+	if claims.OrganizationID != nil {
+		mapClaims["org"] = *claims.OrganizationID
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims).SignedString(i.cfg.Secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+func (i *jwtIssuer) Parse(tokenString string) (Claims, error) {
+	var res Claims
+
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	parsed, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return i.cfg.Secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return res, ErrInvalidToken
+	}
+
+	mapClaims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || !mapClaims.VerifyExpiresAt(i.clock.Now().Unix(), true) {
+		return res, ErrInvalidToken
+	}
+
+	res.UserID, err = strconv.Atoi(fmt.Sprint(mapClaims["user_id"]))
+	if err != nil {
+		return res, ErrInvalidToken
+	}
+
+	res.Email, _ = mapClaims["email"].(string)
+
+	// tokens issued before sessions were bound to the jwt carry no sid, Authenticate rejects them
+	if sessionID, ok := mapClaims["sid"].(float64); ok {
+		res.SessionID = int(sessionID)
+	}
+
+	if orgID, ok := mapClaims["org"].(float64); ok && orgID > 0 {
+		id := int(orgID)
+		res.OrganizationID = &id
+	}
+
+	return res, nil
+}
@@ -0,0 +1,52 @@
+package token_test
+
+import (
+	"clean-arch/pkg/token"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestIssueAndParse(t *testing.T) {
+	c := &fixedClock{now: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)}
+	issuer := token.NewJWT(token.Config{Secret: []byte("secret"), Duration: time.Hour}, c)
+
+	orgID := 7
+	tokenString, exp, err := issuer.Issue(token.Claims{UserID: 1, Email: "a@example.com", SessionID: 3, OrganizationID: &orgID})
+	assert.Nil(t, err)
+	assert.Equal(t, c.now.Add(time.Hour).Unix(), exp.Unix())
+
+	claims, err := issuer.Parse(tokenString)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, claims.UserID)
+	assert.Equal(t, "a@example.com", claims.Email)
+	assert.Equal(t, 3, claims.SessionID)
+	assert.Equal(t, &orgID, claims.OrganizationID)
+
+	// the expiry is checked against the clock of the issuer
+	c.now = c.now.Add(time.Hour + time.Second)
+	_, err = issuer.Parse(tokenString)
+	assert.Equal(t, token.ErrInvalidToken, err)
+}
+
+func TestParseRejectsOtherSecret(t *testing.T) {
+	c := &fixedClock{now: time.Now()}
+
+	tokenString, _, err := token.NewJWT(token.Config{Secret: []byte("other"), Duration: time.Hour}, c).Issue(token.Claims{UserID: 1, SessionID: 1})
+	assert.Nil(t, err)
+
+	_, err = token.NewJWT(token.Config{Secret: []byte("secret"), Duration: time.Hour}, c).Parse(tokenString)
+	assert.Equal(t, token.ErrInvalidToken, err)
+
+	_, err = token.NewJWT(token.Config{Secret: []byte("secret"), Duration: time.Hour}, c).Parse("not-a-token")
+	assert.Equal(t, token.ErrInvalidToken, err)
+}
@@ -0,0 +1,101 @@
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	DeviceDesktop = "Desktop"
+	DeviceMobile  = "Mobile"
+	DeviceTablet  = "Tablet"
+	DeviceBot     = "Bot"
+	Unknown       = "Other"
+)
+
+// Info is the coarse browser, os and device family of a user agent
+type Info struct {
+	Browser        string `json:"browser"`
+	BrowserVersion string `json:"browser_version"`
+	OS             string `json:"os"`
+	Device         string `json:"device"`
+}
+
+type matcher struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// order matters, Chromium based browsers also send "Chrome" and "Safari" tokens
+var browsers = []matcher{
+	{"Edge", regexp.MustCompile(`(?:Edg|Edge|EdgA|EdgiOS)/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"Yandex", regexp.MustCompile(`YaBrowser/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+	{"curl", regexp.MustCompile(`curl/([\d.]+)`)},
+	{"Postman", regexp.MustCompile(`PostmanRuntime/([\d.]+)`)},
+	{"Go HTTP Client", regexp.MustCompile(`Go-http-client/([\d.]+)`)},
+}
+
+var operatingSystems = []matcher{
+	{"iOS", regexp.MustCompile(`(?:iPhone|iPad|iPod).*OS ([\d_]+)`)},
+	{"Android", regexp.MustCompile(`Android ?([\d.]*)`)},
+	{"ChromeOS", regexp.MustCompile(`CrOS \S+ ([\d.]+)`)},
+	{"Windows", regexp.MustCompile(`Windows NT ([\d.]+)`)},
+	{"macOS", regexp.MustCompile(`Mac OS X ?([\d_.]*)`)},
+	{"Linux", regexp.MustCompile(`Linux()`)},
+}
+
+var (
+	botPattern = regexp.MustCompile(`(?i)bot|crawler|spider|slurp|facebookexternalhit`)
+	// Go regexp has no lookahead, Android without "Mobile" is checked as a tablet in Parse
+	tabletPattern = regexp.MustCompile(`(?i)iPad|Tablet|Kindle|Silk/`)
+)
+
+// Parse extracts the browser, os and device family, anything unrecognised is reported as Other
+func Parse(ua string) Info {
+	info := Info{
+		Browser: Unknown,
+		OS:      Unknown,
+		Device:  DeviceDesktop,
+	}
+
+	if strings.TrimSpace(ua) == "" {
+		return info
+	}
+
+	for _, b := range browsers {
+		if m := b.pattern.FindStringSubmatch(ua); m != nil {
+			info.Browser = b.name
+			info.BrowserVersion = m[1]
+			break
+		}
+	}
+
+	for _, o := range operatingSystems {
+		if o.pattern.MatchString(ua) {
+			info.OS = o.name
+			break
+		}
+	}
+
+	switch {
+	case botPattern.MatchString(ua):
+		info.Device = DeviceBot
+	case tabletPattern.MatchString(ua) || (strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile")):
+		info.Device = DeviceTablet
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone"):
+		info.Device = DeviceMobile
+	}
+
+	return info
+}
+
+// Family is a stable identifier of the client used to decide whether a device was seen before
+func (i Info) Family() string {
+	return i.Browser + "/" + i.OS + "/" + i.Device
+}
@@ -0,0 +1,53 @@
+package useragent_test
+
+import (
+	"clean-arch/pkg/useragent"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		ua      string
+		browser string
+		os      string
+		device  string
+	}{
+		{
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			browser: "Chrome", os: "Windows", device: useragent.DeviceDesktop,
+		},
+		{
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0",
+			browser: "Edge", os: "Windows", device: useragent.DeviceDesktop,
+		},
+		{
+			ua:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			browser: "Safari", os: "iOS", device: useragent.DeviceMobile,
+		},
+		{
+			ua:      "Mozilla/5.0 (Linux; Android 14; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			browser: "Chrome", os: "Android", device: useragent.DeviceTablet,
+		},
+		{
+			ua:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.5; rv:127.0) Gecko/20100101 Firefox/127.0",
+			browser: "Firefox", os: "macOS", device: useragent.DeviceDesktop,
+		},
+		{
+			ua:      "Googlebot/2.1 (+http://www.google.com/bot.html)",
+			browser: useragent.Unknown, os: useragent.Unknown, device: useragent.DeviceBot,
+		},
+		{
+			ua:      "",
+			browser: useragent.Unknown, os: useragent.Unknown, device: useragent.DeviceDesktop,
+		},
+	}
+
+	for _, tc := range cases {
+		info := useragent.Parse(tc.ua)
+		assert.Equal(t, tc.browser, info.Browser, tc.ua)
+		assert.Equal(t, tc.os, info.OS, tc.ua)
+		assert.Equal(t, tc.device, info.Device, tc.ua)
+	}
+}
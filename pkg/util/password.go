@@ -1,32 +1,99 @@
 package util
 
 import (
-	"bytes"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
-var (
-	argonTime    uint32 = 1
-	argonMemory  uint32 = 64 * 1024
-	argonThreads uint8  = 2
-	argonKeyLen  uint32 = 32
-	saltLen      uint32 = 16
-)
+// PasswordParams holds the argon2id cost parameters and the optional server-side pepper
+type PasswordParams struct {
+	Time     uint32
+	Memory   uint32
+	Threads  uint8
+	KeyLen   uint32
+	SaltLen  uint32
+	PepperID string
+	Peppers  map[string]string
+}
+
+var passwordParams = DefaultPasswordParams()
+
+func DefaultPasswordParams() PasswordParams {
+	return PasswordParams{
+		Time:    1,
+		Memory:  64 * 1024,
+		Threads: 2,
+		KeyLen:  32,
+		SaltLen: 16,
+	}
+}
+
+// SetPasswordParams replaces the parameters used by HashPassword, zero values keep the defaults
+func SetPasswordParams(p PasswordParams) {
+	def := DefaultPasswordParams()
+	if p.Time == 0 {
+		p.Time = def.Time
+	}
+	if p.Memory == 0 {
+		p.Memory = def.Memory
+	}
+	if p.Threads == 0 {
+		p.Threads = def.Threads
+	}
+	if p.KeyLen == 0 {
+		p.KeyLen = def.KeyLen
+	}
+	if p.SaltLen == 0 {
+		p.SaltLen = def.SaltLen
+	}
+
+	passwordParams = p
+}
+
+func GetPasswordParams() PasswordParams {
+	return passwordParams
+}
+
+type argonHash struct {
+	memory   uint32
+	time     uint32
+	threads  uint8
+	pepperID string
+	salt     []byte
+	hash     []byte
+}
 
 func HashPassword(password string) (string, error) {
-	salt := make([]byte, saltLen)
+	p := passwordParams
+
+	salt := make([]byte, p.SaltLen)
 	if _, err := rand.Read(salt); err != nil {
 		return "", fmt.Errorf("failed to generate salt: %w", err)
 	}
 
-	hash := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	input, err := pepperPassword(password, p.PepperID)
+	if err != nil {
+		return "", err
+	}
+
+	hash := argon2.IDKey(input, salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", p.Memory, p.Time, p.Threads)
+	if p.PepperID != "" {
+		params += ",k=" + p.PepperID
+	}
 
-	encoded := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$%s$%s",
-		argonMemory, argonTime, argonThreads,
+	encoded := fmt.Sprintf("$argon2id$v=%d$%s$%s$%s",
+		argon2.Version,
+		params,
 		base64.RawStdEncoding.EncodeToString(salt),
 		base64.RawStdEncoding.EncodeToString(hash),
 	)
@@ -34,29 +101,136 @@ func HashPassword(password string) (string, error) {
 	return encoded, nil
 }
 
+// VerifyPassword checks the password against an argon2id hash or a legacy bcrypt hash
 func VerifyPassword(password, encodedHash string) (bool, error) {
-	parts := bytes.Split([]byte(encodedHash), []byte("$"))
-	if len(parts) != 6 {
-		return false, fmt.Errorf("invalid encoded hash format")
+	if isBcryptHash(encodedHash) {
+		err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("invalid bcrypt hash: %w", err)
+		}
+		return true, nil
 	}
 
-	var memory, time uint32
-	var threads uint8
-	if _, err := fmt.Sscanf(string(parts[3]), "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
-		return false, fmt.Errorf("invalid argon2 parameters: %w", err)
+	decoded, err := decodeArgonHash(encodedHash)
+	if err != nil {
+		return false, err
 	}
 
-	salt, err := base64.RawStdEncoding.DecodeString(string(parts[4]))
+	input, err := pepperPassword(password, decoded.pepperID)
 	if err != nil {
-		return false, fmt.Errorf("invalid salt base64: %w", err)
+		return false, err
 	}
 
-	expectedHash, err := base64.RawStdEncoding.DecodeString(string(parts[5]))
+	computedHash := argon2.IDKey(input, decoded.salt, decoded.time, decoded.memory, decoded.threads, uint32(len(decoded.hash)))
+
+	return subtle.ConstantTimeCompare(computedHash, decoded.hash) == 1, nil
+}
+
+// PasswordNeedsRehash reports whether the hash was produced with other parameters than the current ones
+func PasswordNeedsRehash(encodedHash string) bool {
+	if isBcryptHash(encodedHash) {
+		return true
+	}
+
+	decoded, err := decodeArgonHash(encodedHash)
 	if err != nil {
-		return false, fmt.Errorf("invalid hash base64: %w", err)
+		return true
+	}
+
+	p := passwordParams
+
+	return decoded.memory != p.Memory ||
+		decoded.time != p.Time ||
+		decoded.threads != p.Threads ||
+		uint32(len(decoded.hash)) != p.KeyLen ||
+		uint32(len(decoded.salt)) != p.SaltLen ||
+		decoded.pepperID != p.PepperID
+}
+
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+func decodeArgonHash(encodedHash string) (argonHash, error) {
+	var res argonHash
+
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return res, fmt.Errorf("invalid encoded hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return res, fmt.Errorf("invalid argon2 version: %w", err)
+	}
+
+	if version != argon2.Version {
+		return res, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	for _, param := range strings.Split(parts[3], ",") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return res, fmt.Errorf("invalid argon2 parameters")
+		}
+
+		var err error
+		switch key {
+		case "m":
+			_, err = fmt.Sscanf(value, "%d", &res.memory)
+		case "t":
+			_, err = fmt.Sscanf(value, "%d", &res.time)
+		case "p":
+			_, err = fmt.Sscanf(value, "%d", &res.threads)
+		case "k":
+			res.pepperID = value
+		default:
+			err = fmt.Errorf("unknown parameter %s", key)
+		}
+
+		if err != nil {
+			return res, fmt.Errorf("invalid argon2 parameters: %w", err)
+		}
+	}
+
+	if res.memory == 0 || res.time == 0 || res.threads == 0 {
+		return res, fmt.Errorf("invalid argon2 parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return res, fmt.Errorf("invalid salt base64: %w", err)
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return res, fmt.Errorf("invalid hash base64: %w", err)
+	}
+
+	res.salt = salt
+	res.hash = hash
+
+	return res, nil
+}
+
+// pepperPassword mixes the pepper identified by pepperID into the password with HMAC-SHA256
+func pepperPassword(password string, pepperID string) ([]byte, error) {
+	if pepperID == "" {
+		return []byte(password), nil
+	}
+
+	pepper, ok := passwordParams.Peppers[pepperID]
+	if !ok {
+		return nil, fmt.Errorf("unknown password pepper id %s", pepperID)
 	}
 
-	computedHash := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(expectedHash)))
+	mac := hmac.New(sha256.New, []byte(pepper))
+	mac.Write([]byte(password))
 
-	return bytes.Equal(computedHash, expectedHash), nil
+	return mac.Sum(nil), nil
 }
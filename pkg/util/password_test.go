@@ -0,0 +1,77 @@
+package util_test
+
+import (
+	"clean-arch/pkg/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	util.SetPasswordParams(util.PasswordParams{Memory: 1024})
+	defer util.SetPasswordParams(util.DefaultPasswordParams())
+
+	hash, err := util.HashPassword("secret123")
+	assert.Nil(t, err)
+
+	match, err := util.VerifyPassword("secret123", hash)
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	match, err = util.VerifyPassword("wrong", hash)
+	assert.Nil(t, err)
+	assert.False(t, match)
+
+	assert.False(t, util.PasswordNeedsRehash(hash))
+
+	util.SetPasswordParams(util.PasswordParams{Memory: 2048})
+	assert.True(t, util.PasswordNeedsRehash(hash))
+
+	match, err = util.VerifyPassword("secret123", hash)
+	assert.Nil(t, err)
+	assert.True(t, match)
+}
+
+func TestVerifyPasswordWithPepper(t *testing.T) {
+	util.SetPasswordParams(util.PasswordParams{
+		Memory:   1024,
+		PepperID: "v1",
+		Peppers:  map[string]string{"v1": "pepper-one", "v2": "pepper-two"},
+	})
+	defer util.SetPasswordParams(util.DefaultPasswordParams())
+
+	hash, err := util.HashPassword("secret123")
+	assert.Nil(t, err)
+	assert.Contains(t, hash, ",k=v1$")
+
+	match, err := util.VerifyPassword("secret123", hash)
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	util.SetPasswordParams(util.PasswordParams{
+		Memory:   1024,
+		PepperID: "v2",
+		Peppers:  map[string]string{"v1": "pepper-one", "v2": "pepper-two"},
+	})
+	assert.True(t, util.PasswordNeedsRehash(hash))
+
+	match, err = util.VerifyPassword("secret123", hash)
+	assert.Nil(t, err)
+	assert.True(t, match)
+}
+
+func TestVerifyLegacyBcryptPassword(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	assert.Nil(t, err)
+
+	match, err := util.VerifyPassword("secret123", string(legacy))
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	match, err = util.VerifyPassword("wrong", string(legacy))
+	assert.Nil(t, err)
+	assert.False(t, match)
+
+	assert.True(t, util.PasswordNeedsRehash(string(legacy)))
+}
@@ -5,13 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"math"
 	"math/big"
-	"mime/multipart"
 	"os"
-	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -140,37 +137,6 @@ func InArrayStr(array []string, str string) bool {
 	return false
 }
 
-func SaveFile(file *multipart.FileHeader) (string, error) {
-	uniqueName := fmt.Sprintf("%d-%s", time.Now().Unix(), file.Filename)
-	savePath := filepath.Join("uploads", uniqueName)
-
-	if err := os.MkdirAll("uploads", os.ModePerm); err != nil {
-		return "", err
-	}
-
-	src, err := file.Open()
-	if err != nil {
-		return "", err
-	}
-	defer src.Close()
-
-	dst, err := os.Create(savePath)
-	if err != nil {
-		return "", err
-	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, src); err != nil {
-		return "", err
-	}
-
-	appUrl := GetEnv("APP_URL", "http://localhost")
-	appPort := GetEnv("APP_PORT", "8080")
-
-	fileURL := fmt.Sprintf("%s:%s/%s", appUrl, appPort, savePath)
-	return fileURL, nil
-}
-
 func DeleteFile(filePath string) error {
 	if err := os.Remove(filePath); err != nil {
 		return err // Return error if file deletion fails
@@ -186,20 +152,56 @@ func StripHTML(input string) string {
 /*********/
 
 func BuildLikeClause(columns []string, keyword string) (string, []interface{}) {
-	likeKeyword := fmt.Sprintf("%%%s%%", strings.ToLower(keyword))
+	likeKeyword := fmt.Sprintf("%%%s%%", EscapeLike(strings.ToLower(keyword)))
 
 	var conditions []string
 	var params []interface{}
 
 	for _, column := range columns {
-		conditions = append(conditions, fmt.Sprintf("LOWER(%s) LIKE ?", column))
+		conditions = append(conditions, fmt.Sprintf("LOWER(%s) LIKE ? %s", column, LikeEscape))
 		params = append(params, likeKeyword)
 	}
 
 	return strings.Join(conditions, " OR "), params
 }
 
+/*
+LikeEscape is the escape clause of a pattern made with EscapeLike. The escape is ! and not a
+backslash, mysql reads the literal '\\' as one backslash while postgres and sqlite read it as two,
+so no backslash literal works on every driver
+*/
+const LikeEscape = "ESCAPE '!'"
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// EscapeLike makes the % and _ of user input match themselves instead of any text
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
 func RoundFloat(val float64, precision uint) float64 {
 	ratio := math.Pow(10, float64(precision))
 	return math.Round(val*ratio) / ratio
 }
+
+// PickFields keeps the given json keys of v, the order of the keys is not kept
+func PickFields(v any, fields []string) (map[string]any, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	all := map[string]any{}
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			res[field] = value
+		}
+	}
+
+	return res, nil
+}